@@ -0,0 +1,169 @@
+package adaptiveratelimit
+
+import "time"
+
+// Mode selects the admission algorithm of a limiter built by NewAdaptive.
+type Mode int
+
+const (
+	// ModeFixedWindow counts requests in a window that resets every
+	// Window, as NewAdaptivePerSecond does. It is the default.
+	ModeFixedWindow Mode = iota
+
+	// ModeSlidingWindow uses a sliding-window counter, as
+	// NewAdaptiveSlidingWindow does.
+	ModeSlidingWindow
+
+	// ModeTokenBucket admits requests from a token bucket, as
+	// NewAdaptiveTokenBucket does. The burst is set with WithBurst.
+	ModeTokenBucket
+
+	// ModeLeakyBucket shapes requests through a leaky bucket, as
+	// NewAdaptiveLeakyBucket does. The capacity is set with WithBurst.
+	ModeLeakyBucket
+
+	// ModeConcurrency bounds in-flight requests, as
+	// NewAdaptiveConcurrency does.
+	ModeConcurrency
+)
+
+// Defaults applied by NewAdaptive before its options.
+const (
+	defaultTargetLatency = 200 * time.Millisecond
+	defaultMaxErrorRate  = 0.05
+	defaultIncreaseStep  = 1
+	defaultDecreaseStep  = 2
+	defaultMinLimit      = 1
+)
+
+// NewAdaptive creates an adaptive limiter starting at limit, configured
+// by options rather than an AdaptiveConfig.
+//
+// Settings no option provides take defaults: a TargetLatency of 200ms, a
+// MaxErrorRate of 0.05, an IncreaseStep of 1 and a DecreaseStep of 2, and
+// bounds from 1 up to limit itself, so the limiter backs off under stress
+// and recovers to the rate it started at. The remaining fields keep their
+// zero-value defaults, documented on AdaptiveConfig. Options are applied
+// in order, so a later option overrides an earlier one, and WithConfig
+// replaces every setting made before it.
+//
+// The admission algorithm is the fixed window unless WithMode selects
+// another. Invalid input is clamped as described for
+// NewAdaptivePerSecond. The returned Limiter must be stopped with Stop,
+// like those of the other constructors.
+func NewAdaptive(limit int, opts ...Option) *Limiter {
+	o := newOptions(opts)
+
+	cfg := AdaptiveConfig{
+		TargetLatency: defaultTargetLatency,
+		MaxErrorRate:  defaultMaxErrorRate,
+		IncreaseStep:  defaultIncreaseStep,
+		DecreaseStep:  defaultDecreaseStep,
+		MinLimit:      defaultMinLimit,
+		MaxLimit:      limit,
+	}
+	for _, set := range o.config {
+		set(&cfg)
+	}
+
+	burst := o.burst
+	if burst <= 0 {
+		burst = limit
+	}
+
+	switch o.mode {
+	case ModeSlidingWindow:
+		return NewAdaptiveSlidingWindow(limit, cfg, opts...)
+	case ModeTokenBucket:
+		return NewAdaptiveTokenBucket(limit, burst, cfg, opts...)
+	case ModeLeakyBucket:
+		return NewAdaptiveLeakyBucket(limit, burst, cfg, opts...)
+	case ModeConcurrency:
+		return NewAdaptiveConcurrency(limit, cfg, opts...)
+	default:
+		return NewAdaptivePerSecond(limit, cfg, opts...)
+	}
+}
+
+// withConfig returns an Option that applies set to the configuration
+// built by NewAdaptive.
+func withConfig(set func(*AdaptiveConfig)) Option {
+	return func(o *options) {
+		o.config = append(o.config, set)
+	}
+}
+
+// WithConfig makes NewAdaptive start from cfg, replacing the defaults and
+// any setting made by an earlier option. Later options still apply on
+// top of it. The constructors that take an AdaptiveConfig ignore it, as
+// they do every option that sets a field of AdaptiveConfig.
+func WithConfig(cfg AdaptiveConfig) Option {
+	return withConfig(func(c *AdaptiveConfig) { *c = cfg })
+}
+
+// WithTargetLatency sets AdaptiveConfig.TargetLatency for NewAdaptive.
+func WithTargetLatency(d time.Duration) Option {
+	return withConfig(func(c *AdaptiveConfig) { c.TargetLatency = d })
+}
+
+// WithErrorRate sets AdaptiveConfig.MaxErrorRate for NewAdaptive.
+func WithErrorRate(rate float64) Option {
+	return withConfig(func(c *AdaptiveConfig) { c.MaxErrorRate = rate })
+}
+
+// WithSteps sets AdaptiveConfig.IncreaseStep and DecreaseStep for
+// NewAdaptive.
+func WithSteps(increase, decrease int) Option {
+	return withConfig(func(c *AdaptiveConfig) {
+		c.IncreaseStep, c.DecreaseStep = increase, decrease
+	})
+}
+
+// WithBounds sets AdaptiveConfig.MinLimit and MaxLimit for NewAdaptive.
+func WithBounds(minLimit, maxLimit int) Option {
+	return withConfig(func(c *AdaptiveConfig) {
+		c.MinLimit, c.MaxLimit = minLimit, maxLimit
+	})
+}
+
+// WithCooldown sets AdaptiveConfig.Cooldown for NewAdaptive.
+func WithCooldown(d time.Duration) Option {
+	return withConfig(func(c *AdaptiveConfig) { c.Cooldown = d })
+}
+
+// WithWindow sets AdaptiveConfig.Window for NewAdaptive.
+func WithWindow(d time.Duration) Option {
+	return withConfig(func(c *AdaptiveConfig) { c.Window = d })
+}
+
+// WithStrategy sets AdaptiveConfig.Strategy for NewAdaptive.
+func WithStrategy(s Strategy) Option {
+	return withConfig(func(c *AdaptiveConfig) { c.Strategy = s })
+}
+
+// WithOnLimitChange sets AdaptiveConfig.OnLimitChange for NewAdaptive.
+func WithOnLimitChange(fn func(old, new int, reason string)) Option {
+	return withConfig(func(c *AdaptiveConfig) { c.OnLimitChange = fn })
+}
+
+// WithOnReject sets AdaptiveConfig.OnReject for NewAdaptive.
+func WithOnReject(fn func()) Option {
+	return withConfig(func(c *AdaptiveConfig) { c.OnReject = fn })
+}
+
+// WithMode selects the admission algorithm of a limiter built by
+// NewAdaptive. Other constructors ignore it.
+func WithMode(m Mode) Option {
+	return func(o *options) {
+		o.mode = m
+	}
+}
+
+// WithBurst sets the burst of a ModeTokenBucket limiter, or the capacity
+// of a ModeLeakyBucket limiter, built by NewAdaptive. Zero or less means
+// the initial limit. Other modes and constructors ignore it.
+func WithBurst(n int) Option {
+	return func(o *options) {
+		o.burst = n
+	}
+}
@@ -0,0 +1,97 @@
+package adaptiveratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewAdaptiveAppliesDefaults(t *testing.T) {
+	limiter := NewAdaptive(20)
+	defer limiter.Stop()
+
+	got := limiter.cfg
+	if got.TargetLatency != 200*time.Millisecond || got.MaxErrorRate != 0.05 {
+		t.Fatalf("unexpected default thresholds: %v and %v", got.TargetLatency, got.MaxErrorRate)
+	}
+	if got.IncreaseStep != 1 || got.DecreaseStep != 2 {
+		t.Fatalf("unexpected default steps: %d and %d", got.IncreaseStep, got.DecreaseStep)
+	}
+	if got.MinLimit != 1 || got.MaxLimit != 20 {
+		t.Fatalf("expected bounds of [1, 20], got [%d, %d]", got.MinLimit, got.MaxLimit)
+	}
+	if limiter.mode != modeFixedWindow || limiter.CurrentLimit() != 20 {
+		t.Fatalf("expected a fixed window at 20, got mode %d at %d", limiter.mode, limiter.CurrentLimit())
+	}
+}
+
+func TestNewAdaptiveOptionsOverrideDefaults(t *testing.T) {
+	rejected := 0
+	limiter := NewAdaptive(2,
+		WithTargetLatency(50*time.Millisecond),
+		WithErrorRate(0.1),
+		WithSteps(3, 4),
+		WithBounds(2, 50),
+		WithCooldown(time.Second),
+		WithOnReject(func() { rejected++ }),
+	)
+	defer limiter.Stop()
+
+	got := limiter.cfg
+	switch {
+	case got.TargetLatency != 50*time.Millisecond, got.MaxErrorRate != 0.1:
+		t.Fatalf("expected thresholds to be overridden, got %v and %v", got.TargetLatency, got.MaxErrorRate)
+	case got.IncreaseStep != 3, got.DecreaseStep != 4:
+		t.Fatalf("expected steps to be overridden, got %d and %d", got.IncreaseStep, got.DecreaseStep)
+	case got.MinLimit != 2, got.MaxLimit != 50:
+		t.Fatalf("expected bounds to be overridden, got [%d, %d]", got.MinLimit, got.MaxLimit)
+	case got.Cooldown != time.Second:
+		t.Fatalf("expected the cooldown to be overridden, got %v", got.Cooldown)
+	}
+
+	for limiter.Allow() {
+	}
+	if rejected != 1 {
+		t.Fatalf("expected OnReject to fire once, got %d", rejected)
+	}
+}
+
+func TestNewAdaptiveWithConfigReplacesEarlierOptions(t *testing.T) {
+	limiter := NewAdaptive(10,
+		WithSteps(5, 5),
+		WithConfig(cfg),
+		WithTargetLatency(time.Second),
+	)
+	defer limiter.Stop()
+
+	got := limiter.cfg
+	if got.IncreaseStep != cfg.IncreaseStep || got.MaxLimit != cfg.MaxLimit {
+		t.Fatalf("expected WithConfig to replace earlier settings, got %+v", got)
+	}
+	if got.TargetLatency != time.Second {
+		t.Fatalf("expected a later option to apply on top, got %v", got.TargetLatency)
+	}
+}
+
+func TestNewAdaptiveWithMode(t *testing.T) {
+	tests := []struct {
+		mode Mode
+		want admissionMode
+	}{
+		{ModeFixedWindow, modeFixedWindow},
+		{ModeSlidingWindow, modeSlidingWindow},
+		{ModeTokenBucket, modeTokenBucket},
+		{ModeLeakyBucket, modeLeakyBucket},
+		{ModeConcurrency, modeConcurrency},
+	}
+
+	for _, tt := range tests {
+		limiter := NewAdaptive(10, WithMode(tt.mode), WithBurst(4))
+		if limiter.mode != tt.want {
+			t.Errorf("mode %d: expected admission mode %d, got %d", tt.mode, tt.want, limiter.mode)
+		}
+		if (tt.mode == ModeTokenBucket || tt.mode == ModeLeakyBucket) && limiter.burst != 4 {
+			t.Errorf("mode %d: expected a burst of 4, got %d", tt.mode, limiter.burst)
+		}
+		limiter.Stop()
+	}
+}
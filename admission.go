@@ -0,0 +1,179 @@
+package adaptiveratelimit
+
+import "time"
+
+// admissionMode selects how a Limiter decides whether a request fits
+// under its current limit.
+type admissionMode int
+
+const (
+	// modeFixedWindow counts requests in a window that resets every Window.
+	modeFixedWindow admissionMode = iota
+
+	// modeTokenBucket refills tokens continuously at the current limit.
+	modeTokenBucket
+
+	// modeSlidingWindow weights the previous window's count by its
+	// overlap with the rolling window.
+	modeSlidingWindow
+
+	// modeConcurrency bounds the number of in-flight requests.
+	modeConcurrency
+
+	// modeLeakyBucket admits requests into a bucket that drains at the
+	// current limit.
+	modeLeakyBucket
+)
+
+// admit consumes n units if they fit under the current limit.
+//
+// The caller must hold l.mu.
+func (l *Limiter) admit(n int, now time.Time) bool {
+	switch l.mode {
+	case modeTokenBucket:
+		return l.takeTokens(n, now)
+	case modeSlidingWindow:
+		return l.slidingAdmit(n, now)
+	case modeLeakyBucket:
+		return l.leakyAdmit(n, now)
+	default:
+		return l.casAdmit(n)
+	}
+}
+
+// lockFree reports whether the limiter's admission mode can be decided by
+// casAdmit alone, without holding l.mu.
+func (l *Limiter) lockFree() bool {
+	return l.mode == modeFixedWindow || l.mode == modeConcurrency
+}
+
+// overLimit reports whether n more units certainly exceed the current
+// limit, judging by count alone, so that allowN can reject them without
+// contending for l.mu when the limiter is saturated. It never reports
+// true for units that admit would take: in the window and concurrency
+// modes usage is at least count, and the read only races with window
+// resets and releases, which is as if the units had arrived just before
+// them. It always reports false for sharded counts and the bucket modes,
+// whose state is not in count.
+//
+// It is safe to call without l.mu.
+func (l *Limiter) overLimit(n int) bool {
+	if l.shards != nil || l.mode == modeTokenBucket || l.mode == modeLeakyBucket {
+		return false
+	}
+	return l.count.Load()+int64(n) > l.currentLimit.Load()
+}
+
+// casAdmit adds n to count if the result stays within the current limit.
+//
+// It is safe to call without l.mu: the compare-and-swap loop ensures that
+// concurrent callers never push count past the limit they observed.
+func (l *Limiter) casAdmit(n int) bool {
+	if l.shards != nil {
+		return l.shardedAdmit(n)
+	}
+
+	for {
+		count := l.count.Load()
+		if count+int64(n) > l.currentLimit.Load() {
+			return false
+		}
+		if l.count.CompareAndSwap(count, count+int64(n)) {
+			return true
+		}
+	}
+}
+
+// limit returns the current limit. It is safe to call without l.mu.
+func (l *Limiter) limit() int {
+	return int(l.currentLimit.Load())
+}
+
+// setLimit replaces the current limit.
+//
+// The caller must hold l.mu.
+func (l *Limiter) setLimit(limit int) {
+	l.currentLimit.Store(int64(limit))
+}
+
+// refund returns n previously admitted units.
+//
+// The caller must hold l.mu.
+func (l *Limiter) refund(n int) {
+	switch l.mode {
+	case modeTokenBucket:
+		l.tokens = min(l.tokens+float64(n), float64(l.burst))
+	case modeLeakyBucket:
+		l.drainedAt = l.drainedAt.Add(-time.Duration(n) * l.drainInterval())
+	default:
+		if l.shards != nil {
+			l.refundShards(int64(n))
+			return
+		}
+		for {
+			count := l.count.Load()
+			if l.count.CompareAndSwap(count, max(count-int64(n), 0)) {
+				return
+			}
+		}
+	}
+}
+
+// resetWindow starts a new admission window.
+//
+// The caller must hold l.mu.
+func (l *Limiter) resetWindow(now time.Time) {
+	switch l.mode {
+	case modeTokenBucket, modeLeakyBucket:
+		// Buckets refill or drain continuously; there is no window to
+		// reset.
+	case modeSlidingWindow:
+		count := int(l.count.Swap(0))
+		l.windowPeak = max(l.windowPeak, count)
+		// The window ending now only precedes the next one if no other
+		// window passed unobserved, e.g. while the process was paused.
+		l.prevCount = count
+		if l.windowsElapsed(now) > 1 {
+			l.prevCount = 0
+		}
+	case modeConcurrency:
+		// count tracks in-flight requests, which outlive any window.
+	default:
+		l.windowPeak = max(l.windowPeak, int(l.swapCount()))
+	}
+	l.lastReset = now
+}
+
+// windowsElapsed returns how many full windows have passed since the
+// last reset, measured on the clock rather than counted in ticks, which
+// coalesce when the process is paused.
+//
+// The caller must hold l.mu.
+func (l *Limiter) windowsElapsed(now time.Time) int64 {
+	return int64(now.Sub(l.lastReset) / l.cfg.window())
+}
+
+// utilization returns the fraction of capacity used since the last
+// evaluation of the control loop.
+//
+// The caller must hold l.mu.
+func (l *Limiter) utilization(now time.Time) float64 {
+	switch l.mode {
+	case modeFixedWindow, modeSlidingWindow:
+		limit := l.limit()
+		if limit <= 0 {
+			return 1
+		}
+		used := max(l.windowPeak, int(l.windowCount()))
+		return float64(used) / float64(limit)
+	default:
+		capacity := l.limit()
+		if l.mode == modeTokenBucket || l.mode == modeLeakyBucket {
+			capacity = l.burst
+		}
+		if capacity <= 0 {
+			return 1
+		}
+		return float64(capacity-l.remaining(now)) / float64(capacity)
+	}
+}
@@ -0,0 +1,35 @@
+package adaptiveratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// AllowCtx reports whether a request carrying ctx is allowed.
+//
+// It returns false without consulting the limit if ctx is already done,
+// or if ctx has a deadline less than DeadlineSlack away, so that requests
+// unlikely to finish in time do not consume scarce capacity. Otherwise it
+// behaves exactly like Allow. Requests turned away because of their
+// context are not counted as rejections and do not fire OnReject.
+//
+// Unlike Wait, AllowCtx never blocks.
+func (l *Limiter) AllowCtx(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		l.mu.Lock()
+		slack := l.cfg.DeadlineSlack
+		l.mu.Unlock()
+
+		// Context deadlines are wall-clock times, so they are compared
+		// with the system clock rather than the limiter's Clock.
+		if time.Until(deadline) < slack {
+			return false
+		}
+	}
+
+	return l.Allow()
+}
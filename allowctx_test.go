@@ -0,0 +1,59 @@
+package adaptiveratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAllowCtx(t *testing.T) {
+	c := cfg
+	c.DeadlineSlack = 50 * time.Millisecond
+
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(1, c, WithClock(clock))
+	defer limiter.Stop()
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	nearDeadline, cancel := context.WithDeadline(context.Background(), time.Now().Add(10*time.Millisecond))
+	defer cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want bool
+	}{
+		{"cancelled", cancelled, false},
+		{"deadline within slack", nearDeadline, false},
+		{"no deadline", context.Background(), true},
+		{"over limit", context.Background(), false},
+	}
+
+	for _, tt := range tests {
+		if got := limiter.AllowCtx(tt.ctx); got != tt.want {
+			t.Fatalf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+
+	if got := limiter.Rejected(); got != 1 {
+		t.Fatalf("expected only the over-limit request to count as rejected, got %d", got)
+	}
+}
+
+func TestAllowCtxAdmitsDeadlineBeyondSlack(t *testing.T) {
+	c := cfg
+	c.DeadlineSlack = 50 * time.Millisecond
+
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(1, c, WithClock(clock))
+	defer limiter.Stop()
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(time.Hour))
+	defer cancel()
+
+	if !limiter.AllowCtx(ctx) {
+		t.Fatal("expected a request with ample time left to be allowed")
+	}
+}
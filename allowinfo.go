@@ -0,0 +1,68 @@
+package adaptiveratelimit
+
+// AllowInfo describes the pressure on a limiter around the time of an
+// admission decision.
+//
+// It is a point-in-time estimate: other requests may be admitted between
+// the decision and the moment the fields are read.
+type AllowInfo struct {
+	// CurrentLimit is the limit the request was checked against.
+	CurrentLimit int
+
+	// Remaining is the capacity left after the decision.
+	Remaining int
+
+	// Saturation is the fraction of capacity in use after the decision.
+	// In the fixed-window and concurrency modes it is the window count
+	// (or in-flight count) over CurrentLimit and can exceed 1 just after
+	// the limit is lowered; in the other modes it is at most 1.
+	Saturation float64
+}
+
+// AllowWithInfo is like Allow but also reports how close the limiter is
+// to its limit, so that a handler can shed optional work as Saturation
+// approaches 1 even when its request is admitted.
+//
+// In the fixed-window and concurrency modes the info is read without
+// taking the limiter's lock.
+func (l *Limiter) AllowWithInfo() (bool, AllowInfo) {
+	ok := l.Allow()
+	return ok, l.allowInfo()
+}
+
+// allowInfo reads the current limit and remaining capacity.
+func (l *Limiter) allowInfo() AllowInfo {
+	if l.lockFree() {
+		limit := l.limit()
+		used := int(l.windowCount())
+		return AllowInfo{
+			CurrentLimit: limit,
+			Remaining:    max(limit-used, 0),
+			Saturation:   saturation(used, limit),
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limit := l.limit()
+	capacity := limit
+	if l.mode == modeTokenBucket || l.mode == modeLeakyBucket {
+		capacity = l.burst
+	}
+	remaining := l.remaining(l.clock.Now())
+	return AllowInfo{
+		CurrentLimit: limit,
+		Remaining:    remaining,
+		Saturation:   saturation(capacity-remaining, capacity),
+	}
+}
+
+// saturation returns used over capacity, treating no capacity as fully
+// saturated.
+func saturation(used, capacity int) float64 {
+	if capacity <= 0 {
+		return 1
+	}
+	return float64(used) / float64(capacity)
+}
@@ -0,0 +1,38 @@
+package adaptiveratelimit
+
+import "testing"
+
+func TestAllowWithInfoSaturationRises(t *testing.T) {
+	limiter := NewAdaptivePerSecond(4, cfg, WithClock(newFakeClock()))
+	defer limiter.Stop()
+
+	prev := -1.0
+	for i := 1; i <= 4; i++ {
+		ok, info := limiter.AllowWithInfo()
+		if !ok {
+			t.Fatalf("request %d: expected admission", i)
+		}
+		if info.CurrentLimit != 4 || info.Remaining != 4-i {
+			t.Fatalf("request %d: unexpected info %+v", i, info)
+		}
+		if info.Saturation <= prev {
+			t.Fatalf("request %d: expected saturation to rise above %f, got %f", i, prev, info.Saturation)
+		}
+		prev = info.Saturation
+	}
+
+	ok, info := limiter.AllowWithInfo()
+	if ok || info.Saturation != 1 || info.Remaining != 0 {
+		t.Fatalf("expected a saturated rejection, got ok=%v info=%+v", ok, info)
+	}
+}
+
+func TestAllowWithInfoTokenBucket(t *testing.T) {
+	limiter := NewAdaptiveTokenBucket(10, 2, cfg, WithClock(newFakeClock()))
+	defer limiter.Stop()
+
+	_, info := limiter.AllowWithInfo()
+	if info.Remaining != 1 || info.Saturation != 0.5 {
+		t.Fatalf("expected half the burst used, got %+v", info)
+	}
+}
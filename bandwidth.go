@@ -0,0 +1,42 @@
+package adaptiveratelimit
+
+import "math"
+
+// NewAdaptiveBandwidth creates an adaptive limiter whose budget is a byte
+// rate rather than a request rate, for bandwidth-bound services such as
+// file or streaming servers. Requests are admitted with AllowBytes.
+//
+// It is a token bucket counted in bytes: the budget refills continuously
+// at rate bytes per second (or per cfg.Window, if set), up to burst
+// bytes, and each admitted request consumes the bytes it asked for. A
+// request larger than burst is never admitted, so burst must cover the
+// largest response to be served in one piece.
+//
+// The adaptive control loop adjusts the byte rate from the latency and
+// errors passed to Record, exactly as it adjusts a request rate, so every
+// limit in cfg is reinterpreted in bytes: MinLimit, MaxLimit and
+// GuaranteedRate bound the byte rate, and IncreaseStep and DecreaseStep
+// are in bytes too, which makes StrategyAIMD or StrategyProportional a
+// better fit than a fixed linear step. CurrentLimit reports the current
+// byte rate per window and Remaining the bytes available now.
+//
+// Invalid input is clamped as described for NewAdaptiveTokenBucket.
+func NewAdaptiveBandwidth(rate int, burst int, cfg AdaptiveConfig, opts ...Option) *Limiter {
+	return NewAdaptiveTokenBucket(rate, burst, cfg, opts...)
+}
+
+// AllowBytes reports whether a request transferring n bytes is allowed,
+// and if so consumes n bytes of the budget of a limiter built with
+// NewAdaptiveBandwidth. It is AllowN with an int64 count: admission is
+// all-or-nothing, and AllowBytes returns false for n <= 0 or an n that
+// does not fit in an int.
+//
+// In the other modes n is counted against the request limit like any
+// other cost.
+func (l *Limiter) AllowBytes(n int64) bool {
+	if n <= 0 || n > math.MaxInt {
+		return false
+	}
+	_, ok := l.allowN(int(n), 1)
+	return ok
+}
@@ -0,0 +1,73 @@
+package adaptiveratelimit
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// byteCfg expresses every limit in bytes.
+var byteCfg = AdaptiveConfig{
+	TargetLatency: 200 * time.Millisecond,
+	MaxErrorRate:  0.05,
+	IncreaseStep:  1 << 10,
+	DecreaseStep:  4 << 10,
+	MinLimit:      1 << 10,
+	MaxLimit:      1 << 20,
+}
+
+func TestAllowBytesConsumesByteBudget(t *testing.T) {
+	c := byteCfg
+	c.MinSamples = 1000 // keep the rate fixed
+
+	clock := newFakeClock()
+	limiter := NewAdaptiveBandwidth(16<<10, 16<<10, c, WithClock(clock))
+	defer limiter.Stop()
+
+	if !limiter.AllowBytes(10 << 10) {
+		t.Fatal("expected a 10KiB response within the burst to be allowed")
+	}
+	if limiter.AllowBytes(8 << 10) {
+		t.Fatal("expected an 8KiB response beyond the remaining budget to be rejected")
+	}
+	if got := limiter.Remaining(); got != 6<<10 {
+		t.Fatalf("expected a rejected request to consume nothing, got %d bytes left", got)
+	}
+
+	// At 16KiB per second, 4KiB refill in 250ms.
+	clock.Advance(250 * time.Millisecond)
+	if !limiter.AllowBytes(10 << 10) {
+		t.Fatal("expected the budget to refill with time")
+	}
+
+	if limiter.AllowBytes(32 << 10) {
+		t.Fatal("expected a request larger than the burst to be rejected")
+	}
+	for _, n := range []int64{0, -1, math.MaxInt64} {
+		if limiter.AllowBytes(n) {
+			t.Fatalf("expected %d bytes to be rejected", n)
+		}
+	}
+}
+
+func TestBandwidthAdaptsByteRate(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewAdaptiveBandwidth(64<<10, 64<<10, byteCfg, WithClock(clock))
+	defer limiter.Stop()
+
+	limiter.AllowBytes(32 << 10)
+	limiter.Record(500*time.Millisecond, nil)
+	clock.Advance(time.Second)
+	if got := limiter.CurrentLimit(); got != 60<<10 {
+		t.Fatalf("expected high latency to lower the rate by DecreaseStep bytes, got %d", got)
+	}
+
+	limiter.Record(0, nil)
+	for limiter.AverageLatency() > 100*time.Millisecond {
+		limiter.Record(0, nil)
+	}
+	clock.Advance(time.Second)
+	if got := limiter.CurrentLimit(); got != 61<<10 {
+		t.Fatalf("expected healthy latency to raise the rate by IncreaseStep bytes, got %d", got)
+	}
+}
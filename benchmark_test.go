@@ -1,6 +1,7 @@
 package adaptiveratelimit
 
 import (
+	"runtime"
 	"testing"
 	"time"
 )
@@ -44,3 +45,143 @@ func BenchmarkRecord(b *testing.B) {
 		limiter.Record(100*time.Millisecond, nil)
 	}
 }
+
+func BenchmarkAllowRejected(b *testing.B) {
+	cfg := AdaptiveConfig{
+		TargetLatency: 200 * time.Millisecond,
+		MaxErrorRate:  0.05,
+		IncreaseStep:  1,
+		DecreaseStep:  2,
+		MinLimit:      1,
+		MaxLimit:      100,
+		Cooldown:      time.Second,
+	}
+
+	limiter := NewAdaptivePerSecond(1, cfg)
+	defer limiter.Stop()
+
+	limiter.Allow()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		limiter.Allow()
+	}
+}
+
+// BenchmarkAllowParallel compares the lock-free fixed-window path with
+// the mutex-guarded sliding window under contention.
+func BenchmarkAllowParallel(b *testing.B) {
+	cfg := AdaptiveConfig{
+		TargetLatency: 200 * time.Millisecond,
+		MaxErrorRate:  0.05,
+		IncreaseStep:  1,
+		DecreaseStep:  2,
+		MinLimit:      1,
+		MaxLimit:      1 << 30,
+		Cooldown:      time.Second,
+	}
+
+	limiters := map[string]func() *Limiter{
+		"FixedWindow": func() *Limiter {
+			return NewAdaptivePerSecond(1<<30, cfg)
+		},
+		"SlidingWindow": func() *Limiter {
+			return NewAdaptiveSlidingWindow(1<<30, cfg)
+		},
+	}
+
+	for name, newLimiter := range limiters {
+		b.Run(name, func(b *testing.B) {
+			limiter := newLimiter()
+			defer limiter.Stop()
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					limiter.Allow()
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkAllowParallelSaturated measures rejections under contention,
+// which are decided without taking the mutex.
+func BenchmarkAllowParallelSaturated(b *testing.B) {
+	cfg := AdaptiveConfig{
+		TargetLatency: 200 * time.Millisecond,
+		MaxErrorRate:  0.05,
+		IncreaseStep:  1,
+		DecreaseStep:  2,
+		MinLimit:      1,
+		MaxLimit:      100,
+		Cooldown:      time.Second,
+	}
+
+	limiters := map[string]func() *Limiter{
+		"FixedWindow": func() *Limiter {
+			return NewAdaptivePerSecond(1, cfg)
+		},
+		"SlidingWindow": func() *Limiter {
+			return NewAdaptiveSlidingWindow(1, cfg)
+		},
+		"Concurrency": func() *Limiter {
+			return NewAdaptiveConcurrency(1, cfg)
+		},
+	}
+
+	for name, newLimiter := range limiters {
+		b.Run(name, func(b *testing.B) {
+			limiter := newLimiter()
+			defer limiter.Stop()
+
+			limiter.Allow()
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					limiter.Allow()
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkAllowSharded compares a single window counter with a sharded
+// one on 32 cores.
+func BenchmarkAllowSharded(b *testing.B) {
+	cfg := AdaptiveConfig{
+		TargetLatency: 200 * time.Millisecond,
+		MaxErrorRate:  0.05,
+		IncreaseStep:  1,
+		DecreaseStep:  2,
+		MinLimit:      1,
+		MaxLimit:      1 << 30,
+		Cooldown:      time.Second,
+	}
+
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(32))
+
+	limiters := map[string]func() *Limiter{
+		"SingleCounter": func() *Limiter {
+			return NewAdaptivePerSecond(1<<30, cfg)
+		},
+		"Sharded": func() *Limiter {
+			return NewAdaptivePerSecond(1<<30, cfg, WithShardedCount(0))
+		},
+	}
+
+	for name, newLimiter := range limiters {
+		b.Run(name, func(b *testing.B) {
+			limiter := newLimiter()
+			defer limiter.Stop()
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					limiter.Allow()
+				}
+			})
+		})
+	}
+}
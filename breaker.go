@@ -0,0 +1,205 @@
+package adaptiveratelimit
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrBreakerOpen is returned by Wait on a leaky bucket limiter while its
+// circuit breaker is not closed, since the bucket cannot hold callers
+// indefinitely.
+var ErrBreakerOpen = errors.New("adaptiveratelimit: circuit breaker open")
+
+// BreakerState is the state of a limiter's circuit breaker; see
+// AdaptiveConfig.BreakerDuration.
+type BreakerState int32
+
+const (
+	// BreakerClosed means requests are admitted under the adaptive limit.
+	BreakerClosed BreakerState = iota
+
+	// BreakerOpen means every request is rejected.
+	BreakerOpen
+
+	// BreakerHalfOpen means a few probe requests are admitted to test
+	// whether the downstream has recovered; see ProbeCount.
+	BreakerHalfOpen
+)
+
+// String returns the state's name.
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// State returns the current state of the limiter's circuit breaker. It is
+// always BreakerClosed unless BreakerDuration is set.
+func (l *Limiter) State() BreakerState {
+	return BreakerState(l.breaker.Load())
+}
+
+// stepBreaker advances the circuit breaker on a control loop tick: it
+// opens the breaker once the error rate has stayed above MaxErrorRate with
+// the limit at MinLimit for BreakerDuration, and moves an open breaker to
+// half-open after BreakerOpenDuration.
+//
+// The caller must hold l.mu.
+func (l *Limiter) stepBreaker(now time.Time) {
+	if l.cfg.BreakerDuration <= 0 || l.cfg.GuaranteedRate > 0 {
+		if l.State() != BreakerClosed {
+			l.closeBreaker()
+		}
+		return
+	}
+
+	switch l.State() {
+	case BreakerClosed:
+		if l.limit() > l.cfg.floor() || l.errorEWMA.Value() <= l.cfg.MaxErrorRate {
+			l.pinnedSince = time.Time{}
+			return
+		}
+		if l.pinnedSince.IsZero() {
+			l.pinnedSince = now
+		}
+		if now.Sub(l.pinnedSince) >= l.cfg.BreakerDuration {
+			l.openBreaker(now)
+		}
+	case BreakerOpen:
+		if now.Sub(l.breakerOpenedAt) >= l.cfg.breakerOpenDuration() {
+			l.breaker.Store(int32(BreakerHalfOpen))
+			l.probes = probeRound{}
+		}
+	}
+}
+
+// openBreaker starts rejecting every request.
+//
+// The caller must hold l.mu.
+func (l *Limiter) openBreaker(now time.Time) {
+	l.breaker.Store(int32(BreakerOpen))
+	l.breakerOpenedAt = now
+	l.probes = probeRound{}
+}
+
+// closeBreaker resumes admission under the adaptive limit and hands any
+// freed capacity to queued waiters.
+//
+// The caller must hold l.mu.
+func (l *Limiter) closeBreaker() {
+	l.breaker.Store(int32(BreakerClosed))
+	l.pinnedSince = time.Time{}
+	l.probes = probeRound{}
+	l.grantWaiters()
+}
+
+// ProbeResult describes the outcome of a half-open probe, for
+// AdaptiveConfig.OnProbe.
+type ProbeResult struct {
+	// Name is the limiter's name; see WithName.
+	Name string
+
+	// Latency and Err are the probe's recorded outcome. Latency is zero
+	// for outcomes recorded with RecordResult.
+	Latency time.Duration
+	Err     error
+
+	// Healthy reports whether the probe counted as a success: no error
+	// and latency within the high watermark of TargetLatency.
+	Healthy bool
+
+	// State is the breaker state after the probe was counted.
+	State BreakerState
+}
+
+// probeRound tracks half-open probes. admitted and pending count the
+// probes of the round that started at started; healthy counts healthy
+// probes since the breaker became half-open.
+type probeRound struct {
+	started  time.Time
+	admitted int
+	pending  int
+	healthy  int
+}
+
+// admitProbe reports whether a request may be admitted while the breaker
+// is not closed, and whether it is a half-open probe. Each ProbeInterval
+// admits up to ProbeCount probes, which still need capacity under the
+// current limit.
+func (l *Limiter) admitProbe() (allowed, probe bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	switch l.State() {
+	case BreakerClosed:
+		return true, false
+	case BreakerHalfOpen:
+		now := l.clock.Now()
+		if l.probes.started.IsZero() || now.Sub(l.probes.started) >= l.cfg.probeInterval() {
+			// Probes still pending from an earlier round are
+			// abandoned; their outcomes are not counted.
+			l.probes.started = now
+			l.probes.admitted = 0
+			l.probes.pending = 0
+		}
+		if l.probes.admitted >= l.cfg.probeCount() {
+			return false, false
+		}
+		l.probes.admitted++
+		l.probes.pending++
+		return true, true
+	default:
+		return false, false
+	}
+}
+
+// cancelProbe gives up a probe that was not admitted after all, so
+// another request may probe instead.
+func (l *Limiter) cancelProbe() {
+	l.mu.Lock()
+	if l.probes.pending > 0 {
+		l.probes.admitted--
+		l.probes.pending--
+	}
+	l.mu.Unlock()
+}
+
+// recordProbe counts an outcome recorded while the breaker is half-open
+// against the pending probes. An unhealthy probe reopens the breaker,
+// and ProbeCount healthy probes in a row close it.
+func (l *Limiter) recordProbe(latency time.Duration, err error) {
+	if l.State() != BreakerHalfOpen {
+		return
+	}
+
+	l.mu.Lock()
+	if l.State() != BreakerHalfOpen || l.probes.pending == 0 {
+		l.mu.Unlock()
+		return
+	}
+
+	l.probes.pending--
+	healthy := err == nil && latency <= l.cfg.highLatency()
+	switch {
+	case !healthy:
+		l.openBreaker(l.clock.Now())
+	case l.probes.healthy+1 >= l.cfg.probeCount():
+		l.closeBreaker()
+	default:
+		l.probes.healthy++
+	}
+	result := ProbeResult{Name: l.name, Latency: latency, Err: err, Healthy: healthy, State: l.State()}
+	onProbe := l.cfg.OnProbe
+	l.mu.Unlock()
+
+	if onProbe != nil {
+		onProbe(result)
+	}
+}
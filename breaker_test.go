@@ -0,0 +1,195 @@
+package adaptiveratelimit
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func newBreakerLimiter(t *testing.T) (*Limiter, *fakeClock) {
+	t.Helper()
+
+	c := cfg
+	c.BreakerDuration = 2 * time.Second
+	c.BreakerOpenDuration = 3 * time.Second
+
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(1, c, WithClock(clock))
+	t.Cleanup(limiter.Stop)
+	return limiter, clock
+}
+
+func TestBreakerOpensHalfOpensAndCloses(t *testing.T) {
+	limiter, clock := newBreakerLimiter(t)
+	failure := errors.New("failed")
+
+	limiter.Record(10*time.Millisecond, failure)
+
+	// Pinned at MinLimit with errors from the first tick; the breaker
+	// opens once that has lasted BreakerDuration.
+	clock.Advance(2 * time.Second)
+	if got := limiter.State(); got != BreakerClosed {
+		t.Fatalf("expected the breaker to stay closed before BreakerDuration, got %v", got)
+	}
+	clock.Advance(time.Second)
+	if got := limiter.State(); got != BreakerOpen {
+		t.Fatalf("expected the breaker to open, got %v", got)
+	}
+	if limiter.Allow() {
+		t.Fatal("expected an open breaker to reject every request")
+	}
+
+	clock.Advance(3 * time.Second)
+	if got := limiter.State(); got != BreakerHalfOpen {
+		t.Fatalf("expected the breaker to become half-open, got %v", got)
+	}
+	if !limiter.Allow() {
+		t.Fatal("expected a half-open breaker to admit a probe")
+	}
+	clock.Advance(time.Second) // new window
+	if limiter.Allow() {
+		t.Fatal("expected only one probe at a time")
+	}
+
+	limiter.Record(10*time.Millisecond, nil)
+	if got := limiter.State(); got != BreakerClosed {
+		t.Fatalf("expected a healthy probe to close the breaker, got %v", got)
+	}
+	if !limiter.Allow() {
+		t.Fatal("expected a closed breaker to admit requests again")
+	}
+}
+
+func TestBreakerFailedProbeReopens(t *testing.T) {
+	limiter, clock := newBreakerLimiter(t)
+	failure := errors.New("failed")
+
+	limiter.Record(10*time.Millisecond, failure)
+	clock.Advance(6 * time.Second)
+	if got := limiter.State(); got != BreakerHalfOpen {
+		t.Fatalf("expected the breaker to become half-open, got %v", got)
+	}
+
+	limiter.Allow()
+	limiter.Record(10*time.Millisecond, failure)
+
+	if got := limiter.State(); got != BreakerOpen {
+		t.Fatalf("expected a failed probe to reopen the breaker, got %v", got)
+	}
+}
+
+func TestBreakerDisabledByDefault(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(1, cfg, WithClock(clock))
+	defer limiter.Stop()
+
+	limiter.Record(10*time.Millisecond, errors.New("failed"))
+	clock.Advance(time.Minute)
+
+	if got := limiter.State(); got != BreakerClosed {
+		t.Fatalf("expected no breaker without BreakerDuration, got %v", got)
+	}
+	if !limiter.Allow() {
+		t.Fatal("expected MinLimit requests to keep flowing")
+	}
+}
+
+func TestBreakerProbeRoundsRestoreCapacity(t *testing.T) {
+	var results []ProbeResult
+	c := cfg
+	c.BreakerDuration = 2 * time.Second
+	c.BreakerOpenDuration = 3 * time.Second
+	c.ProbeCount = 2
+	c.ProbeInterval = time.Second
+	c.OnProbe = func(r ProbeResult) { results = append(results, r) }
+
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(1, c, WithClock(clock))
+	defer limiter.Stop()
+
+	limiter.Record(10*time.Millisecond, errors.New("failed"))
+	clock.Advance(6 * time.Second)
+	if got := limiter.State(); got != BreakerHalfOpen {
+		t.Fatalf("expected the breaker to become half-open, got %v", got)
+	}
+
+	// A probe whose outcome is never recorded is abandoned when the next
+	// round starts, so it cannot keep the breaker shut.
+	if !limiter.Allow() {
+		t.Fatal("expected the first probe to be admitted")
+	}
+	clock.Advance(time.Second)
+
+	for i := range 2 {
+		if !limiter.Allow() {
+			t.Fatalf("expected probe %d to be admitted", i+1)
+		}
+		limiter.Record(10*time.Millisecond, nil)
+		clock.Advance(time.Second) // new window for the next probe
+	}
+
+	if got := limiter.State(); got != BreakerClosed {
+		t.Fatalf("expected %d healthy probes to close the breaker, got %v", c.ProbeCount, got)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected OnProbe for each recorded probe, got %d calls", len(results))
+	}
+	if !results[0].Healthy || results[0].State != BreakerHalfOpen {
+		t.Fatalf("expected the first healthy probe to leave the breaker half-open, got %+v", results[0])
+	}
+	if results[1].State != BreakerClosed {
+		t.Fatalf("expected the last probe to report the breaker closed, got %+v", results[1])
+	}
+	if !limiter.Allow() {
+		t.Fatal("expected capacity to be restored once the breaker closes")
+	}
+}
+
+func TestProbeResultCarriesName(t *testing.T) {
+	var results []ProbeResult
+	c := cfg
+	c.BreakerDuration = 2 * time.Second
+	c.BreakerOpenDuration = 3 * time.Second
+	c.OnProbe = func(r ProbeResult) { results = append(results, r) }
+
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(1, c, WithClock(clock), WithName("payments"))
+	defer limiter.Stop()
+
+	limiter.Record(10*time.Millisecond, errors.New("failed"))
+	clock.Advance(6 * time.Second)
+	if !limiter.Allow() {
+		t.Fatal("expected a probe to be admitted once the breaker is half-open")
+	}
+	limiter.Record(10*time.Millisecond, nil)
+
+	if len(results) != 1 || results[0].Name != "payments" {
+		t.Fatalf("expected OnProbe to be passed the limiter's name, got %+v", results)
+	}
+}
+
+func TestBreakerProbeCountLimitsRound(t *testing.T) {
+	c := cfg
+	c.BreakerDuration = 2 * time.Second
+	c.BreakerOpenDuration = 3 * time.Second
+	c.ProbeCount = 2
+	c.ProbeInterval = time.Minute
+
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(1, c, WithClock(clock))
+	defer limiter.Stop()
+
+	limiter.Record(10*time.Millisecond, errors.New("failed"))
+	clock.Advance(6 * time.Second)
+
+	admitted := 0
+	for range 4 {
+		if limiter.Allow() {
+			admitted++
+		}
+		clock.Advance(time.Second)
+	}
+	if admitted != c.ProbeCount {
+		t.Fatalf("expected %d probes per round, got %d", c.ProbeCount, admitted)
+	}
+}
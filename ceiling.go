@@ -0,0 +1,83 @@
+package adaptiveratelimit
+
+import "time"
+
+// defaultCeilingProbeAfter is the time the limit must stay healthy at its
+// ceiling before the ceiling ramp raises it, when CeilingProbeAfter is
+// unset.
+const defaultCeilingProbeAfter = time.Minute
+
+// ceilingRamp reports whether the ceiling ramp is enabled.
+func (c AdaptiveConfig) ceilingRamp() bool {
+	return c.AbsoluteMaxLimit > c.MaxLimit
+}
+
+// ceilingStep returns how much the ceiling ramp moves the ceiling at a
+// time.
+func (c AdaptiveConfig) ceilingStep() int {
+	switch {
+	case c.CeilingStep > 0:
+		return c.CeilingStep
+	case c.IncreaseStep > 0:
+		return c.IncreaseStep
+	default:
+		return 1
+	}
+}
+
+// ceilingProbeAfter returns CeilingProbeAfter, applying the default.
+func (c AdaptiveConfig) ceilingProbeAfter() time.Duration {
+	if c.CeilingProbeAfter == 0 {
+		return defaultCeilingProbeAfter
+	}
+	return c.CeilingProbeAfter
+}
+
+// ceiling returns the upper bound currently in force on the limit.
+//
+// The caller must hold l.mu.
+func (l *Limiter) ceiling() int {
+	if !l.cfg.ceilingRamp() {
+		return l.cfg.MaxLimit
+	}
+	return min(max(l.raisedCeiling, l.cfg.MaxLimit), l.cfg.AbsoluteMaxLimit)
+}
+
+// loopConfig returns the configuration the control loop adjusts the limit
+// by: l.cfg with MaxLimit replaced by the current ceiling.
+//
+// The caller must hold l.mu.
+func (l *Limiter) loopConfig() AdaptiveConfig {
+	c := l.cfg
+	c.MaxLimit = l.ceiling()
+	return c
+}
+
+// rampCeiling runs the ceiling ramp after the control loop has moved the
+// limit to limit in direction dir, or held it, and returns the ceiling
+// before and after. A decrease lowers the ceiling by a step, while an
+// increase held back by the ceiling for CeilingProbeAfter raises it.
+//
+// The caller must hold l.mu.
+func (l *Limiter) rampCeiling(now time.Time, dir Decision, hold bool, limit int) (int, int) {
+	old := l.ceiling()
+	if !l.cfg.ceilingRamp() {
+		return old, old
+	}
+
+	switch {
+	case dir == DecisionDecrease:
+		l.raisedCeiling = max(old-l.cfg.ceilingStep(), l.cfg.MaxLimit)
+		l.ceilingSince = time.Time{}
+	case dir == DecisionIncrease && !hold && limit >= old:
+		if l.ceilingSince.IsZero() {
+			l.ceilingSince = now
+		} else if now.Sub(l.ceilingSince) >= l.cfg.ceilingProbeAfter() {
+			l.raisedCeiling = min(old+l.cfg.ceilingStep(), l.cfg.AbsoluteMaxLimit)
+			l.ceilingSince = now
+		}
+	default:
+		l.ceilingSince = time.Time{}
+	}
+	return old, l.ceiling()
+}
@@ -0,0 +1,70 @@
+package adaptiveratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCeilingRampDiscoversHeadroomAndBacksOff(t *testing.T) {
+	c := cfg
+	c.MaxLimit = 10
+	c.AbsoluteMaxLimit = 12
+	c.CeilingProbeAfter = 3 * time.Second
+	c.CeilingStep = 1
+
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(10, c, WithClock(clock))
+	defer limiter.Stop()
+
+	limiter.Record(0, nil)
+	for i := 0; i < 3; i++ {
+		clock.Advance(time.Second)
+		if got := limiter.Snapshot(); got.CurrentLimit != 10 || got.Ceiling != 10 {
+			t.Fatalf("tick %d: expected the limit to wait at MaxLimit, got %d under %d", i, got.CurrentLimit, got.Ceiling)
+		}
+	}
+
+	clock.Advance(time.Second)
+	if got := limiter.Snapshot().Ceiling; got != 11 {
+		t.Fatalf("expected sustained health to raise the ceiling to 11, got %d", got)
+	}
+	clock.Advance(time.Second)
+	if got := limiter.CurrentLimit(); got != 11 {
+		t.Fatalf("expected the limit to climb into the new headroom, got %d", got)
+	}
+
+	clock.Advance(10 * time.Second)
+	if got := limiter.Snapshot(); got.CurrentLimit != 12 || got.Ceiling != 12 {
+		t.Fatalf("expected the ramp to stop at AbsoluteMaxLimit, got %d under %d", got.CurrentLimit, got.Ceiling)
+	}
+
+	limiter.Record(time.Second, nil)
+	clock.Advance(time.Second)
+	if got := limiter.Snapshot(); got.CurrentLimit != 10 || got.Ceiling != 11 {
+		t.Fatalf("expected a latency breach to lower the limit and the ceiling, got %d under %d", got.CurrentLimit, got.Ceiling)
+	}
+	clock.Advance(time.Second)
+	if got := limiter.Snapshot(); got.CurrentLimit != 8 || got.Ceiling != 10 {
+		t.Fatalf("expected the ceiling to fall back no further than MaxLimit, got %d under %d", got.CurrentLimit, got.Ceiling)
+	}
+	clock.Advance(time.Second)
+	if got := limiter.Snapshot().Ceiling; got != 10 {
+		t.Fatalf("expected the ceiling to stay at MaxLimit, got %d", got)
+	}
+}
+
+func TestCeilingRampDisabledByDefault(t *testing.T) {
+	c := cfg
+	c.MaxLimit = 10
+	c.CeilingProbeAfter = time.Second
+
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(10, c, WithClock(clock))
+	defer limiter.Stop()
+
+	limiter.Record(0, nil)
+	clock.Advance(10 * time.Second)
+	if got := limiter.Snapshot(); got.CurrentLimit != 10 || got.Ceiling != 10 {
+		t.Fatalf("expected MaxLimit to stay a hard cap, got %d under %d", got.CurrentLimit, got.Ceiling)
+	}
+}
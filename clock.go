@@ -0,0 +1,44 @@
+package adaptiveratelimit
+
+import "time"
+
+// Clock is the source of time used by a Limiter.
+//
+// The default clock is the system clock. A custom Clock can be injected
+// with WithClock, typically to drive the limiter deterministically in
+// tests.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// NewTicker returns a Ticker that fires every d.
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers periodic ticks, like time.Ticker.
+type Ticker interface {
+	// C returns the channel on which ticks are delivered.
+	C() <-chan time.Time
+
+	// Stop turns off the ticker.
+	Stop()
+
+	// Reset changes the ticker period to d.
+	Reset(d time.Duration)
+}
+
+// realClock is the system clock.
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+// realTicker adapts a time.Ticker to the Ticker interface.
+type realTicker struct {
+	*time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time { return t.Ticker.C }
@@ -0,0 +1,190 @@
+package adaptiveratelimit
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced Clock.
+//
+// Advance delivers due ticks synchronously: it returns only after every
+// loop that received a tick has finished handling it and is waiting for
+// the next one.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(1_000_000, 0)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTicker(d time.Duration) Ticker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTicker{
+		clock:  c,
+		period: d,
+		next:   c.now.Add(d),
+		ch:     make(chan time.Time),
+		parked: make(chan struct{}, 1),
+	}
+	c.tickers = append(c.tickers, t)
+	return t
+}
+
+// Advance moves the clock forward by d, firing every tick that falls due
+// in order.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	end := c.now.Add(d)
+	c.mu.Unlock()
+
+	for {
+		c.mu.Lock()
+		var due *fakeTicker
+		for _, t := range c.tickers {
+			if !t.stopped && !t.next.After(end) && (due == nil || t.next.Before(due.next)) {
+				due = t
+			}
+		}
+		if due == nil {
+			c.now = end
+			c.mu.Unlock()
+			return
+		}
+		c.now = due.next
+		due.next = due.next.Add(due.period)
+		now := c.now
+		c.mu.Unlock()
+
+		due.fire(now)
+	}
+}
+
+// Jump moves the clock forward by d at once, as if the process had been
+// paused: each overdue ticker fires a single tick, like a runtime ticker
+// dropping the ticks it could not deliver.
+func (c *fakeClock) Jump(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	for _, t := range c.tickers {
+		if !t.stopped && t.next.Before(c.now) {
+			t.next = c.now
+		}
+	}
+	c.mu.Unlock()
+
+	c.Advance(0)
+}
+
+type fakeTicker struct {
+	clock   *fakeClock
+	period  time.Duration
+	next    time.Time
+	stopped bool
+
+	ch chan time.Time
+	// parked holds a token while the owning loop is waiting in select.
+	parked chan struct{}
+}
+
+// C is called by the owning loop each time it waits for a tick, which
+// lets fire detect when a tick has been fully handled.
+func (t *fakeTicker) C() <-chan time.Time {
+	select {
+	case t.parked <- struct{}{}:
+	default:
+	}
+	return t.ch
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.stopped = true
+}
+
+func (t *fakeTicker) Reset(d time.Duration) {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.period = d
+	t.next = t.clock.now.Add(d)
+}
+
+// fire delivers a tick and waits until the loop is parked again.
+func (t *fakeTicker) fire(now time.Time) {
+	select {
+	case <-t.parked:
+	case <-time.After(time.Second):
+		return // loop has exited
+	}
+
+	select {
+	case t.ch <- now:
+	case <-time.After(time.Second):
+		return
+	}
+
+	select {
+	case <-t.parked:
+		t.parked <- struct{}{}
+	case <-time.After(time.Second):
+	}
+}
+
+func TestFakeClockDrivesLoops(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(1, cfg, WithClock(clock))
+	defer limiter.Stop()
+
+	if !limiter.Allow() {
+		t.Fatal("expected request to be allowed")
+	}
+
+	if limiter.Allow() {
+		t.Fatal("expected second request to be rate-limited")
+	}
+
+	clock.Advance(time.Second)
+
+	if !limiter.Allow() {
+		t.Fatal("expected limiter to reset after the clock advanced one second")
+	}
+}
+
+func TestLimiterUsesSingleTickerAndGoroutine(t *testing.T) {
+	const limiters = 50
+
+	clock := newFakeClock()
+	before := runtime.NumGoroutine()
+	started := make([]*Limiter, 0, limiters)
+	for range limiters {
+		started = append(started, NewAdaptivePerSecond(10, cfg, WithClock(clock)))
+	}
+	defer func() {
+		for _, l := range started {
+			l.Stop()
+		}
+	}()
+
+	clock.mu.Lock()
+	tickers := len(clock.tickers)
+	clock.mu.Unlock()
+	if tickers != limiters {
+		t.Fatalf("expected one ticker per limiter, got %d for %d limiters", tickers, limiters)
+	}
+	if got := runtime.NumGoroutine() - before; got > limiters {
+		t.Fatalf("expected at most one goroutine per limiter, got %d for %d limiters", got, limiters)
+	}
+}
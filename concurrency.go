@@ -0,0 +1,158 @@
+package adaptiveratelimit
+
+import (
+	"container/list"
+	"errors"
+	"time"
+)
+
+// errHoldExpired is recorded for a slot reclaimed after MaxHoldDuration.
+var errHoldExpired = errors.New("adaptiveratelimit: slot held past MaxHoldDuration")
+
+// NewAdaptiveConcurrency creates an adaptive limiter that bounds the number
+// of in-flight requests instead of the request rate.
+//
+// A request is admitted only while fewer than CurrentLimit requests are in
+// flight. The adaptive control loop raises and lowers this concurrency
+// ceiling using the same latency and error signals as the rate modes, and
+// there is no per-second window.
+//
+// An admitted request holds its slot until its outcome is recorded: each
+// Record call completes one in-flight request. Callers that prefer not to
+// pair Allow and Record by hand can use Acquire, whose release function
+// records the outcome and frees the slot.
+//
+// Invalid input is clamped as described for NewAdaptivePerSecond.
+func NewAdaptiveConcurrency(limit int, cfg AdaptiveConfig, opts ...Option) *Limiter {
+	cfg = cfg.sanitize(limit)
+
+	return newLimiter(cfg.clampLimit(limit), cfg, func(l *Limiter) {
+		l.mode = modeConcurrency
+	}, opts)
+}
+
+// Acquire admits a single request and returns a function that must be
+// called exactly once when the request completes.
+//
+// The release function records the latency since admission and the given
+// error, exactly as Record would, and in concurrency mode it frees the
+// request's slot. Calls after the first are no-ops, so it is safe to both
+// defer release and call it explicitly. To ensure a slot is returned even
+// if the handler panics, defer the call immediately after a successful
+// Acquire:
+//
+//	release, ok := l.Acquire()
+//	if !ok {
+//		return errRejected
+//	}
+//	defer func() { release(err) }()
+//
+// If ok is false, release is a no-op. If AdaptiveConfig.MaxHoldDuration
+// is set, a slot that is not released in time is reclaimed, and a later
+// release is a no-op too.
+func (l *Limiter) Acquire() (release func(err error), ok bool) {
+	ok, release = l.AllowWithDone()
+	return release, ok
+}
+
+// InFlight returns the number of requests currently holding a slot.
+//
+// In the rate-based modes it returns zero.
+func (l *Limiter) InFlight() int {
+	if l.mode != modeConcurrency {
+		return 0
+	}
+
+	return int(l.count.Load())
+}
+
+// releaseSlot frees one in-flight slot in concurrency mode and hands it
+// to a queued waiter if there is one.
+func (l *Limiter) releaseSlot() {
+	if l.mode != modeConcurrency {
+		return
+	}
+
+	l.mu.Lock()
+	l.refund(1)
+	l.grantWaiters()
+	l.notifyIdle()
+	l.mu.Unlock()
+}
+
+// hold is a slot watched for MaxHoldDuration. elem is nil once the slot
+// has been released or reclaimed.
+type hold struct {
+	start time.Time
+	elem  *list.Element
+}
+
+// watchHold starts watching a slot admitted at start, or returns nil if
+// no watchdog applies.
+func (l *Limiter) watchHold(start time.Time) *hold {
+	if l.mode != modeConcurrency {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.cfg.MaxHoldDuration <= 0 {
+		return nil
+	}
+	h := &hold{start: start}
+	h.elem = l.holds.PushBack(h)
+	return h
+}
+
+// releaseHold stops watching h and reports whether its slot is still
+// held, that is whether the caller should release it.
+func (l *Limiter) releaseHold(h *hold) bool {
+	if h == nil {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if h.elem == nil {
+		return false
+	}
+	l.holds.Remove(h.elem)
+	h.elem = nil
+	return true
+}
+
+// expireHolds stops watching the slots held for MaxHoldDuration at now
+// and returns them for reclaimHolds.
+//
+// The caller must hold l.mu.
+func (l *Limiter) expireHolds(now time.Time) []*hold {
+	if l.cfg.MaxHoldDuration <= 0 {
+		return nil
+	}
+
+	var expired []*hold
+	for e := l.holds.Front(); e != nil; e = l.holds.Front() {
+		h := e.Value.(*hold)
+		if now.Sub(h.start) < l.cfg.MaxHoldDuration {
+			break
+		}
+		l.holds.Remove(e)
+		h.elem = nil
+		expired = append(expired, h)
+	}
+	return expired
+}
+
+// reclaimHolds frees the slots returned by expireHolds, recording each as
+// an error. It must be called without l.mu held.
+func (l *Limiter) reclaimHolds(expired []*hold, now time.Time) {
+	for _, h := range expired {
+		held := now.Sub(h.start)
+		if l.logger != nil {
+			l.log(LevelWarn, "slot held past MaxHoldDuration", "held", held)
+		}
+		l.Record(held, errHoldExpired)
+	}
+}
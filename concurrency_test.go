@@ -0,0 +1,181 @@
+package adaptiveratelimit
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestConcurrencyLimitsInFlight(t *testing.T) {
+	limiter := NewAdaptiveConcurrency(2, cfg)
+	defer limiter.Stop()
+
+	if !limiter.Allow() || !limiter.Allow() {
+		t.Fatal("expected two in-flight requests to be allowed")
+	}
+
+	if limiter.Allow() {
+		t.Fatal("expected third concurrent request to be rejected")
+	}
+
+	if got := limiter.InFlight(); got != 2 {
+		t.Fatalf("expected 2 in flight, got %d", got)
+	}
+
+	limiter.Record(10*time.Millisecond, nil)
+
+	if got := limiter.InFlight(); got != 1 {
+		t.Fatalf("expected Record to free a slot, got %d in flight", got)
+	}
+
+	if !limiter.Allow() {
+		t.Fatal("expected freed slot to be reusable")
+	}
+}
+
+func TestConcurrencySlotsSurviveWindowReset(t *testing.T) {
+	limiter := NewAdaptiveConcurrency(1, cfg)
+	defer limiter.Stop()
+
+	if !limiter.Allow() {
+		t.Fatal("expected request to be allowed")
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+
+	if limiter.InFlight() != 1 {
+		t.Fatal("expected in-flight request to hold its slot across ticks")
+	}
+}
+
+func TestAcquireReleaseIsIdempotent(t *testing.T) {
+	limiter := NewAdaptiveConcurrency(2, cfg)
+	defer limiter.Stop()
+
+	release, ok := limiter.Acquire()
+	if !ok {
+		t.Fatal("expected Acquire to succeed")
+	}
+
+	release(nil)
+	release(nil)
+
+	if got := limiter.InFlight(); got != 0 {
+		t.Fatalf("expected 0 in flight after release, got %d", got)
+	}
+}
+
+func TestAcquireReleasesOnPanic(t *testing.T) {
+	limiter := NewAdaptiveConcurrency(1, cfg)
+	defer limiter.Stop()
+
+	func() {
+		defer func() { _ = recover() }()
+
+		release, ok := limiter.Acquire()
+		if !ok {
+			t.Fatal("expected Acquire to succeed")
+		}
+		defer release(errors.New("panic"))
+
+		panic("handler failure")
+	}()
+
+	if got := limiter.InFlight(); got != 0 {
+		t.Fatalf("expected deferred release to free slot, got %d in flight", got)
+	}
+
+	if limiter.ErrorRate() <= 0 {
+		t.Fatal("expected release to record the error")
+	}
+}
+
+func TestAcquireRejectedReleaseIsNoop(t *testing.T) {
+	limiter := NewAdaptiveConcurrency(1, cfg)
+	defer limiter.Stop()
+
+	limiter.Allow()
+
+	release, ok := limiter.Acquire()
+	if ok {
+		t.Fatal("expected Acquire to be rejected")
+	}
+
+	release(nil)
+
+	if got := limiter.InFlight(); got != 1 {
+		t.Fatalf("expected rejected release not to free a slot, got %d in flight", got)
+	}
+}
+
+func TestMaxHoldDurationReclaimsLeakedSlot(t *testing.T) {
+	c := cfg
+	c.MaxHoldDuration = 2 * time.Second
+	c.MinSamples = 1000 // keep the limit fixed
+
+	clock := newFakeClock()
+	logger := &recordingLogger{}
+	limiter := NewAdaptiveConcurrency(1, c, WithClock(clock), WithLogger(logger))
+	defer limiter.Stop()
+
+	leaked, ok := limiter.Acquire()
+	if !ok {
+		t.Fatal("expected Acquire to succeed")
+	}
+
+	clock.Advance(time.Second)
+	if got := limiter.InFlight(); got != 1 {
+		t.Fatalf("expected the slot to be held before MaxHoldDuration, got %d in flight", got)
+	}
+
+	clock.Advance(time.Second)
+	if got := limiter.InFlight(); got != 0 {
+		t.Fatalf("expected the leaked slot to be reclaimed, got %d in flight", got)
+	}
+	if limiter.ErrorRate() <= 0 {
+		t.Fatal("expected the reclaimed slot to count as an error")
+	}
+	if !logger.has(LevelWarn, "slot held past MaxHoldDuration") {
+		t.Fatal("expected a warning for the reclaimed slot")
+	}
+
+	if !limiter.Allow() {
+		t.Fatal("expected the reclaimed slot to be available")
+	}
+	leaked(nil)
+	if got := limiter.InFlight(); got != 1 {
+		t.Fatalf("expected a late release to be a no-op, got %d in flight", got)
+	}
+}
+
+func TestAllowWithResultReleasesHold(t *testing.T) {
+	c := cfg
+	c.MaxHoldDuration = time.Second
+	c.MinSamples = 1000 // keep the limit fixed
+
+	clock := newFakeClock()
+	limiter := NewAdaptiveConcurrency(2, c, WithClock(clock))
+	defer limiter.Stop()
+
+	ok, done := limiter.AllowWithResult()
+	if !ok {
+		t.Fatal("expected AllowWithResult to succeed")
+	}
+	done(nil)
+	done(nil)
+
+	if !limiter.Allow() {
+		t.Fatal("expected the released slot to be available")
+	}
+	clock.Advance(2 * time.Second)
+
+	if got := limiter.InFlight(); got != 1 {
+		t.Fatalf("expected a released slot not to be reclaimed, got %d in flight", got)
+	}
+	if got := limiter.ErrorRate(); got != 0 {
+		t.Fatalf("expected no expired hold to be recorded, got error rate %v", got)
+	}
+	if got := limiter.AverageLatency(); got != 0 {
+		t.Fatalf("expected AllowWithResult not to record a latency, got %v", got)
+	}
+}
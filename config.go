@@ -0,0 +1,448 @@
+package adaptiveratelimit
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidConfig is returned (wrapped) when an AdaptiveConfig fails
+// validation.
+var ErrInvalidConfig = errors.New("adaptiveratelimit: invalid config")
+
+// Validate reports whether the configuration is usable.
+//
+// The returned error wraps ErrInvalidConfig and describes the first
+// offending field.
+func (c AdaptiveConfig) Validate() error {
+	switch {
+	case c.TargetLatency <= 0:
+		return fmt.Errorf("%w: TargetLatency must be positive, got %v", ErrInvalidConfig, c.TargetLatency)
+	case c.HighWatermark < 0 || c.HighWatermark >= 1:
+		return fmt.Errorf("%w: HighWatermark must be within [0, 1), got %v", ErrInvalidConfig, c.HighWatermark)
+	case c.LowWatermark < 0 || c.LowWatermark >= 1:
+		return fmt.Errorf("%w: LowWatermark must be within [0, 1), got %v", ErrInvalidConfig, c.LowWatermark)
+	case c.MaxErrorRate < 0 || c.MaxErrorRate > 1:
+		return fmt.Errorf("%w: MaxErrorRate must be within [0, 1], got %v", ErrInvalidConfig, c.MaxErrorRate)
+	case c.IncreaseStep < 0:
+		return fmt.Errorf("%w: IncreaseStep must not be negative, got %d", ErrInvalidConfig, c.IncreaseStep)
+	case c.DecreaseStep < 0:
+		return fmt.Errorf("%w: DecreaseStep must not be negative, got %d", ErrInvalidConfig, c.DecreaseStep)
+	case c.MinLimit < 0:
+		return fmt.Errorf("%w: MinLimit must not be negative, got %d", ErrInvalidConfig, c.MinLimit)
+	case c.MaxLimit <= 0:
+		return fmt.Errorf("%w: MaxLimit must be positive, got %d", ErrInvalidConfig, c.MaxLimit)
+	case c.MinLimit > c.MaxLimit:
+		return fmt.Errorf("%w: MinLimit (%d) exceeds MaxLimit (%d)", ErrInvalidConfig, c.MinLimit, c.MaxLimit)
+	case c.AbsoluteMaxLimit < 0:
+		return fmt.Errorf("%w: AbsoluteMaxLimit must not be negative, got %d", ErrInvalidConfig, c.AbsoluteMaxLimit)
+	case c.AbsoluteMaxLimit > 0 && c.AbsoluteMaxLimit < c.MaxLimit:
+		return fmt.Errorf("%w: AbsoluteMaxLimit (%d) is below MaxLimit (%d)", ErrInvalidConfig, c.AbsoluteMaxLimit, c.MaxLimit)
+	case c.CeilingProbeAfter < 0:
+		return fmt.Errorf("%w: CeilingProbeAfter must not be negative, got %v", ErrInvalidConfig, c.CeilingProbeAfter)
+	case c.CeilingStep < 0:
+		return fmt.Errorf("%w: CeilingStep must not be negative, got %d", ErrInvalidConfig, c.CeilingStep)
+	case c.GuaranteedRate < 0:
+		return fmt.Errorf("%w: GuaranteedRate must not be negative, got %d", ErrInvalidConfig, c.GuaranteedRate)
+	case c.GuaranteedRate > c.MaxLimit:
+		return fmt.Errorf("%w: GuaranteedRate (%d) exceeds MaxLimit (%d)", ErrInvalidConfig, c.GuaranteedRate, c.MaxLimit)
+	case c.Cooldown < 0:
+		return fmt.Errorf("%w: Cooldown must not be negative, got %v", ErrInvalidConfig, c.Cooldown)
+	case c.IncreaseCooldown < 0:
+		return fmt.Errorf("%w: IncreaseCooldown must not be negative, got %v", ErrInvalidConfig, c.IncreaseCooldown)
+	case c.DecreaseCooldown < 0:
+		return fmt.Errorf("%w: DecreaseCooldown must not be negative, got %v", ErrInvalidConfig, c.DecreaseCooldown)
+	case c.MinUtilization < 0 || c.MinUtilization >= 1:
+		return fmt.Errorf("%w: MinUtilization must be within [0, 1), got %v", ErrInvalidConfig, c.MinUtilization)
+	case c.BreakerDuration < 0:
+		return fmt.Errorf("%w: BreakerDuration must not be negative, got %v", ErrInvalidConfig, c.BreakerDuration)
+	case c.BreakerOpenDuration < 0:
+		return fmt.Errorf("%w: BreakerOpenDuration must not be negative, got %v", ErrInvalidConfig, c.BreakerOpenDuration)
+	case c.MaxWaiters < 0:
+		return fmt.Errorf("%w: MaxWaiters must not be negative, got %d", ErrInvalidConfig, c.MaxWaiters)
+	case c.MaxHoldDuration < 0:
+		return fmt.Errorf("%w: MaxHoldDuration must not be negative, got %v", ErrInvalidConfig, c.MaxHoldDuration)
+	case c.IdleTimeout < 0:
+		return fmt.Errorf("%w: IdleTimeout must not be negative, got %v", ErrInvalidConfig, c.IdleTimeout)
+	case c.ProbeCount < 0:
+		return fmt.Errorf("%w: ProbeCount must not be negative, got %d", ErrInvalidConfig, c.ProbeCount)
+	case c.ProbeInterval < 0:
+		return fmt.Errorf("%w: ProbeInterval must not be negative, got %v", ErrInvalidConfig, c.ProbeInterval)
+	case c.DeadlineSlack < 0:
+		return fmt.Errorf("%w: DeadlineSlack must not be negative, got %v", ErrInvalidConfig, c.DeadlineSlack)
+	case c.Warmup < 0:
+		return fmt.Errorf("%w: Warmup must not be negative, got %v", ErrInvalidConfig, c.Warmup)
+	case c.MinSamples < 0:
+		return fmt.Errorf("%w: MinSamples must not be negative, got %d", ErrInvalidConfig, c.MinSamples)
+	case c.LatencyAlpha < 0 || c.LatencyAlpha > 1:
+		return fmt.Errorf("%w: LatencyAlpha must be within (0, 1], got %v", ErrInvalidConfig, c.LatencyAlpha)
+	case c.ErrorAlpha < 0 || c.ErrorAlpha > 1:
+		return fmt.Errorf("%w: ErrorAlpha must be within (0, 1], got %v", ErrInvalidConfig, c.ErrorAlpha)
+	case c.SampleRate < 0 || c.SampleRate > 1:
+		return fmt.Errorf("%w: SampleRate must be within (0, 1], got %v", ErrInvalidConfig, c.SampleRate)
+	case !c.PriorityThresholds.valid():
+		return fmt.Errorf("%w: PriorityThresholds must be within (0, 1], got %+v", ErrInvalidConfig, c.PriorityThresholds)
+	case c.LatencyWeight < 0:
+		return fmt.Errorf("%w: LatencyWeight must not be negative, got %v", ErrInvalidConfig, c.LatencyWeight)
+	case c.ErrorWeight < 0:
+		return fmt.Errorf("%w: ErrorWeight must not be negative, got %v", ErrInvalidConfig, c.ErrorWeight)
+	case !c.Strategy.valid():
+		return fmt.Errorf("%w: unknown Strategy %d", ErrInvalidConfig, c.Strategy)
+	case c.DecreaseFactor < 0 || c.DecreaseFactor >= 1:
+		return fmt.Errorf("%w: DecreaseFactor must be within (0, 1), got %v", ErrInvalidConfig, c.DecreaseFactor)
+	case c.LatencyPercentile < 0 || c.LatencyPercentile >= 1:
+		return fmt.Errorf("%w: LatencyPercentile must be within (0, 1), got %v", ErrInvalidConfig, c.LatencyPercentile)
+	case c.Window < 0:
+		return fmt.Errorf("%w: Window must not be negative, got %v", ErrInvalidConfig, c.Window)
+	case c.AdjustInterval < 0:
+		return fmt.Errorf("%w: AdjustInterval must not be negative, got %v", ErrInvalidConfig, c.AdjustInterval)
+	case c.SaturationThreshold < 0 || c.SaturationThreshold >= 1:
+		return fmt.Errorf("%w: SaturationThreshold must be within [0, 1), got %v", ErrInvalidConfig, c.SaturationThreshold)
+	case c.Jitter < 0 || c.Jitter >= 1:
+		return fmt.Errorf("%w: Jitter must be within [0, 1), got %v", ErrInvalidConfig, c.Jitter)
+	}
+	return nil
+}
+
+// sanitize returns a copy of the configuration with invalid bounds and
+// steps replaced by safe values, so that a limiter starting at limit can
+// always make progress. It is used by constructors that do not return an
+// error.
+func (c AdaptiveConfig) sanitize(limit int) AdaptiveConfig {
+	if c.HighWatermark < 0 || c.HighWatermark >= 1 {
+		c.HighWatermark = 0
+	}
+	if c.LowWatermark < 0 || c.LowWatermark >= 1 {
+		c.LowWatermark = 0
+	}
+	if c.IncreaseStep < 0 {
+		c.IncreaseStep = 0
+	}
+	if c.DecreaseStep < 0 {
+		c.DecreaseStep = 0
+	}
+	if c.MinLimit < 0 {
+		c.MinLimit = 0
+	}
+	if c.MaxLimit <= 0 {
+		c.MaxLimit = max(limit, c.MinLimit, 1)
+	}
+	if c.MinLimit > c.MaxLimit {
+		c.MaxLimit = c.MinLimit
+	}
+	c.GuaranteedRate = min(max(c.GuaranteedRate, 0), c.MaxLimit)
+	if c.AbsoluteMaxLimit < c.MaxLimit {
+		c.AbsoluteMaxLimit = 0
+	}
+	if c.CeilingProbeAfter < 0 {
+		c.CeilingProbeAfter = 0
+	}
+	if c.CeilingStep < 0 {
+		c.CeilingStep = 0
+	}
+	if c.Cooldown < 0 {
+		c.Cooldown = 0
+	}
+	if c.IncreaseCooldown < 0 {
+		c.IncreaseCooldown = 0
+	}
+	if c.DecreaseCooldown < 0 {
+		c.DecreaseCooldown = 0
+	}
+	if c.MinUtilization < 0 || c.MinUtilization >= 1 {
+		c.MinUtilization = 0
+	}
+	if c.BreakerDuration < 0 {
+		c.BreakerDuration = 0
+	}
+	if c.BreakerOpenDuration < 0 {
+		c.BreakerOpenDuration = 0
+	}
+	if c.MaxWaiters < 0 {
+		c.MaxWaiters = 0
+	}
+	if c.MaxHoldDuration < 0 {
+		c.MaxHoldDuration = 0
+	}
+	if c.IdleTimeout < 0 {
+		c.IdleTimeout = 0
+	}
+	if c.ProbeCount < 0 {
+		c.ProbeCount = 0
+	}
+	if c.ProbeInterval < 0 {
+		c.ProbeInterval = 0
+	}
+	if c.DeadlineSlack < 0 {
+		c.DeadlineSlack = 0
+	}
+	if c.Warmup < 0 {
+		c.Warmup = 0
+	}
+	if c.MinSamples < 0 {
+		c.MinSamples = 0
+	}
+	if c.LatencyAlpha < 0 || c.LatencyAlpha > 1 {
+		c.LatencyAlpha = 0
+	}
+	if c.ErrorAlpha < 0 || c.ErrorAlpha > 1 {
+		c.ErrorAlpha = 0
+	}
+	if c.SampleRate < 0 || c.SampleRate > 1 {
+		c.SampleRate = 0
+	}
+	if !c.PriorityThresholds.valid() {
+		c.PriorityThresholds = PriorityThresholds{}
+	}
+	if !c.Strategy.valid() {
+		c.Strategy = StrategyLinear
+	}
+	if c.LatencyWeight < 0 {
+		c.LatencyWeight = 0
+	}
+	if c.ErrorWeight < 0 {
+		c.ErrorWeight = 0
+	}
+	if c.DecreaseFactor < 0 || c.DecreaseFactor >= 1 {
+		c.DecreaseFactor = 0
+	}
+	if c.LatencyPercentile < 0 || c.LatencyPercentile >= 1 {
+		c.LatencyPercentile = 0
+	}
+	if c.Window < 0 {
+		c.Window = 0
+	}
+	if c.AdjustInterval < 0 {
+		c.AdjustInterval = 0
+	}
+	if c.Jitter < 0 || c.Jitter >= 1 {
+		c.Jitter = 0
+	}
+	if c.SaturationThreshold < 0 || c.SaturationThreshold >= 1 {
+		c.SaturationThreshold = 0
+	}
+	return c
+}
+
+// increaseCooldown returns the cooldown before the limit may be raised,
+// falling back to Cooldown when unset.
+func (c AdaptiveConfig) increaseCooldown() time.Duration {
+	if c.IncreaseCooldown == 0 {
+		return c.Cooldown
+	}
+	return c.IncreaseCooldown
+}
+
+// decreaseCooldown returns the cooldown before the limit may be lowered
+// again, falling back to Cooldown when unset.
+func (c AdaptiveConfig) decreaseCooldown() time.Duration {
+	if c.DecreaseCooldown == 0 {
+		return c.Cooldown
+	}
+	return c.DecreaseCooldown
+}
+
+// defaultWatermark is the width of each side of the band around
+// TargetLatency when HighWatermark or LowWatermark is unset.
+const defaultWatermark = 0.05
+
+// highWatermark returns HighWatermark, applying the default.
+func (c AdaptiveConfig) highWatermark() float64 {
+	if c.HighWatermark == 0 {
+		return defaultWatermark
+	}
+	return c.HighWatermark
+}
+
+// lowWatermark returns LowWatermark, applying the default.
+func (c AdaptiveConfig) lowWatermark() float64 {
+	if c.LowWatermark == 0 {
+		return defaultWatermark
+	}
+	return c.LowWatermark
+}
+
+// highLatency returns the latency above which the limit is lowered.
+func (c AdaptiveConfig) highLatency() time.Duration {
+	return time.Duration(float64(c.TargetLatency) * (1 + c.highWatermark()))
+}
+
+// lowLatency returns the latency below which the limit is raised.
+func (c AdaptiveConfig) lowLatency() time.Duration {
+	return time.Duration(float64(c.TargetLatency) * (1 - c.lowWatermark()))
+}
+
+// breakerOpenDuration returns how long the breaker stays open, falling
+// back to BreakerDuration when unset.
+func (c AdaptiveConfig) breakerOpenDuration() time.Duration {
+	if c.BreakerOpenDuration == 0 {
+		return c.BreakerDuration
+	}
+	return c.BreakerOpenDuration
+}
+
+// probeCount returns the number of probes per half-open round,
+// defaulting to one.
+func (c AdaptiveConfig) probeCount() int {
+	return max(c.ProbeCount, 1)
+}
+
+// probeInterval returns how often a half-open probe round may start,
+// falling back to the breaker's open duration when unset.
+func (c AdaptiveConfig) probeInterval() time.Duration {
+	if c.ProbeInterval == 0 {
+		return c.breakerOpenDuration()
+	}
+	return c.ProbeInterval
+}
+
+// Default EWMA smoothing factors used when LatencyAlpha or ErrorAlpha is
+// unset.
+const (
+	defaultLatencyAlpha = 0.3
+	defaultErrorAlpha   = 0.2
+)
+
+// latencyAlpha returns the latency EWMA smoothing factor, applying the
+// default when unset.
+func (c AdaptiveConfig) latencyAlpha() float64 {
+	if c.LatencyAlpha == 0 {
+		return defaultLatencyAlpha
+	}
+	return c.LatencyAlpha
+}
+
+// errorAlpha returns the error EWMA smoothing factor, applying the
+// default when unset.
+func (c AdaptiveConfig) errorAlpha() float64 {
+	if c.ErrorAlpha == 0 {
+		return defaultErrorAlpha
+	}
+	return c.ErrorAlpha
+}
+
+// sampleRate returns the fraction of outcomes fed to the averages,
+// applying the default when unset.
+func (c AdaptiveConfig) sampleRate() float64 {
+	if c.SampleRate == 0 {
+		return 1
+	}
+	return c.SampleRate
+}
+
+// window returns the admission window, defaulting to one second.
+func (c AdaptiveConfig) window() time.Duration {
+	if c.Window <= 0 {
+		return time.Second
+	}
+	return c.Window
+}
+
+// adjustInterval returns the control loop interval, defaulting to one
+// second.
+func (c AdaptiveConfig) adjustInterval() time.Duration {
+	if c.AdjustInterval <= 0 {
+		return time.Second
+	}
+	return c.AdjustInterval
+}
+
+// floor returns the lowest limit the control loop may set: the higher of
+// MinLimit and GuaranteedRate, but at least one, so that a limit driven
+// down by a large DecreaseStep can always recover.
+func (c AdaptiveConfig) floor() int {
+	return max(c.MinLimit, c.GuaranteedRate, 1)
+}
+
+// clampLimit bounds limit to [floor, MaxLimit].
+func (c AdaptiveConfig) clampLimit(limit int) int {
+	return min(max(limit, c.floor()), c.MaxLimit)
+}
+
+// UpdateConfig validates cfg and atomically replaces the limiter's
+// configuration, preserving its adaptive state.
+//
+// The current limit is immediately clamped into the new [MinLimit,
+// MaxLimit] range, firing OnLimitChange with ReasonReconfigured if it
+// changes. Other fields, such as Cooldown and the thresholds, take effect
+// on the next control loop tick.
+//
+// If cfg is invalid, UpdateConfig returns an error wrapping
+// ErrInvalidConfig and leaves the limiter unchanged.
+func (l *Limiter) UpdateConfig(cfg AdaptiveConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	// Signal tracking is fixed at construction, and the alphas change
+	// only through SetLatencyAlpha and SetErrorAlpha.
+	cfg.UsePercentile = l.cfg.UsePercentile
+	cfg.LatencyPercentile = l.cfg.LatencyPercentile
+	cfg.LatencyAlpha = l.cfg.LatencyAlpha
+	cfg.ErrorAlpha = l.cfg.ErrorAlpha
+
+	oldLimit := l.limit()
+	l.cfg = cfg
+	l.publishConfig()
+	newLimit := l.loopConfig().clampLimit(oldLimit)
+	l.setLimit(newLimit)
+	l.grantWaiters()
+	l.mu.Unlock()
+
+	if cfg.OnLimitChange != nil && newLimit != oldLimit {
+		cfg.OnLimitChange(oldLimit, newLimit, ReasonReconfigured)
+	}
+	return nil
+}
+
+// SetLatencyAlpha changes the smoothing factor of the latency EWMA at
+// runtime, for example to smooth harder through a deploy known to be
+// noisy and then restore the configured value. alpha must be in (0, 1];
+// otherwise SetLatencyAlpha returns an error wrapping ErrInvalidConfig
+// and leaves the limiter unchanged.
+//
+// The current average is kept: the new alpha only weighs the samples
+// recorded from now on. The latency percentile estimates, if
+// UsePercentile is set, are unaffected.
+func (l *Limiter) SetLatencyAlpha(alpha float64) error {
+	if !validAlpha(alpha) {
+		return fmt.Errorf("%w: LatencyAlpha must be within (0, 1], got %v", ErrInvalidConfig, alpha)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.cfg.LatencyAlpha = alpha
+	l.latencyEWMA.SetAlpha(alpha)
+	return nil
+}
+
+// SetErrorAlpha is like SetLatencyAlpha for the error rate EWMA.
+func (l *Limiter) SetErrorAlpha(alpha float64) error {
+	if !validAlpha(alpha) {
+		return fmt.Errorf("%w: ErrorAlpha must be within (0, 1], got %v", ErrInvalidConfig, alpha)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.cfg.ErrorAlpha = alpha
+	l.errorEWMA.SetAlpha(alpha)
+	return nil
+}
+
+// LatencyAlpha returns the smoothing factor of the latency EWMA in
+// effect, with the default applied.
+func (l *Limiter) LatencyAlpha() float64 {
+	return l.latencyEWMA.Alpha()
+}
+
+// ErrorAlpha returns the smoothing factor of the error rate EWMA in
+// effect, with the default applied.
+func (l *Limiter) ErrorAlpha() float64 {
+	return l.errorEWMA.Alpha()
+}
+
+// validAlpha reports whether alpha is a usable smoothing factor.
+func validAlpha(alpha float64) bool {
+	return alpha > 0 && alpha <= 1
+}
@@ -0,0 +1,247 @@
+package adaptiveratelimit
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestConfigValidateAcceptsValidConfig(t *testing.T) {
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected valid config, got %v", err)
+	}
+}
+
+func TestConfigValidateRejectsInvalidFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*AdaptiveConfig)
+	}{
+		{"zero target latency", func(c *AdaptiveConfig) { c.TargetLatency = 0 }},
+		{"negative high watermark", func(c *AdaptiveConfig) { c.HighWatermark = -0.1 }},
+		{"low watermark of one", func(c *AdaptiveConfig) { c.LowWatermark = 1 }},
+		{"negative error rate", func(c *AdaptiveConfig) { c.MaxErrorRate = -0.1 }},
+		{"error rate above one", func(c *AdaptiveConfig) { c.MaxErrorRate = 1.5 }},
+		{"negative increase step", func(c *AdaptiveConfig) { c.IncreaseStep = -1 }},
+		{"negative decrease step", func(c *AdaptiveConfig) { c.DecreaseStep = -1 }},
+		{"negative min limit", func(c *AdaptiveConfig) { c.MinLimit = -1 }},
+		{"zero max limit", func(c *AdaptiveConfig) { c.MinLimit = 0; c.MaxLimit = 0 }},
+		{"min above max", func(c *AdaptiveConfig) { c.MinLimit = 50; c.MaxLimit = 10 }},
+		{"negative absolute max limit", func(c *AdaptiveConfig) { c.AbsoluteMaxLimit = -1 }},
+		{"absolute max below max", func(c *AdaptiveConfig) { c.AbsoluteMaxLimit = 50 }},
+		{"negative ceiling probe after", func(c *AdaptiveConfig) { c.CeilingProbeAfter = -time.Second }},
+		{"negative ceiling step", func(c *AdaptiveConfig) { c.CeilingStep = -1 }},
+		{"negative latency weight", func(c *AdaptiveConfig) { c.LatencyWeight = -1 }},
+		{"negative error weight", func(c *AdaptiveConfig) { c.ErrorWeight = -1 }},
+		{"negative cooldown", func(c *AdaptiveConfig) { c.Cooldown = -time.Second }},
+		{"negative increase cooldown", func(c *AdaptiveConfig) { c.IncreaseCooldown = -time.Second }},
+		{"negative decrease cooldown", func(c *AdaptiveConfig) { c.DecreaseCooldown = -time.Second }},
+		{"priority threshold above one", func(c *AdaptiveConfig) { c.PriorityThresholds.Low = 1.5 }},
+		{"negative warmup", func(c *AdaptiveConfig) { c.Warmup = -time.Second }},
+		{"negative min samples", func(c *AdaptiveConfig) { c.MinSamples = -1 }},
+		{"negative min utilization", func(c *AdaptiveConfig) { c.MinUtilization = -0.1 }},
+		{"min utilization of one", func(c *AdaptiveConfig) { c.MinUtilization = 1 }},
+		{"negative breaker duration", func(c *AdaptiveConfig) { c.BreakerDuration = -time.Second }},
+		{"negative breaker open duration", func(c *AdaptiveConfig) { c.BreakerOpenDuration = -time.Second }},
+		{"negative guaranteed rate", func(c *AdaptiveConfig) { c.GuaranteedRate = -1 }},
+		{"guaranteed rate above max limit", func(c *AdaptiveConfig) { c.GuaranteedRate = c.MaxLimit + 1 }},
+		{"negative max waiters", func(c *AdaptiveConfig) { c.MaxWaiters = -1 }},
+		{"negative max hold duration", func(c *AdaptiveConfig) { c.MaxHoldDuration = -time.Second }},
+		{"negative idle timeout", func(c *AdaptiveConfig) { c.IdleTimeout = -time.Second }},
+		{"negative probe count", func(c *AdaptiveConfig) { c.ProbeCount = -1 }},
+		{"negative probe interval", func(c *AdaptiveConfig) { c.ProbeInterval = -time.Second }},
+		{"negative deadline slack", func(c *AdaptiveConfig) { c.DeadlineSlack = -time.Second }},
+		{"negative latency alpha", func(c *AdaptiveConfig) { c.LatencyAlpha = -0.1 }},
+		{"latency alpha above one", func(c *AdaptiveConfig) { c.LatencyAlpha = 1.1 }},
+		{"error alpha above one", func(c *AdaptiveConfig) { c.ErrorAlpha = 2 }},
+		{"negative sample rate", func(c *AdaptiveConfig) { c.SampleRate = -0.5 }},
+		{"sample rate above one", func(c *AdaptiveConfig) { c.SampleRate = 1.5 }},
+		{"negative jitter", func(c *AdaptiveConfig) { c.Jitter = -0.1 }},
+		{"jitter of one", func(c *AdaptiveConfig) { c.Jitter = 1 }},
+		{"negative saturation threshold", func(c *AdaptiveConfig) { c.SaturationThreshold = -0.1 }},
+		{"saturation threshold of one", func(c *AdaptiveConfig) { c.SaturationThreshold = 1 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := cfg
+			tt.mutate(&c)
+
+			err := c.Validate()
+			if !errors.Is(err, ErrInvalidConfig) {
+				t.Fatalf("expected ErrInvalidConfig, got %v", err)
+			}
+
+			if _, err := NewAdaptivePerSecondWithError(10, c); !errors.Is(err, ErrInvalidConfig) {
+				t.Fatalf("expected constructor to reject config, got %v", err)
+			}
+		})
+	}
+}
+
+func TestNewAdaptivePerSecondWithErrorClampsInitialLimit(t *testing.T) {
+	c := cfg
+	c.MinLimit = 5
+	c.MaxLimit = 20
+
+	low, err := NewAdaptivePerSecondWithError(1, c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer low.Stop()
+
+	if got := low.CurrentLimit(); got != 5 {
+		t.Fatalf("expected initial limit clamped up to 5, got %d", got)
+	}
+
+	high, err := NewAdaptivePerSecondWithError(100, c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer high.Stop()
+
+	if got := high.CurrentLimit(); got != 20 {
+		t.Fatalf("expected initial limit clamped down to 20, got %d", got)
+	}
+}
+
+func TestNewAdaptivePerSecondClampsInvalidConfig(t *testing.T) {
+	limiter := NewAdaptivePerSecond(10, AdaptiveConfig{})
+	defer limiter.Stop()
+
+	if got := limiter.CurrentLimit(); got != 10 {
+		t.Fatalf("expected zero MaxLimit to fall back to the initial limit, got %d", got)
+	}
+
+	if !limiter.Allow() {
+		t.Fatal("expected limiter with clamped config to admit requests")
+	}
+}
+
+func TestUpdateConfigClampsCurrentLimit(t *testing.T) {
+	limiter := NewAdaptivePerSecond(50, cfg)
+	defer limiter.Stop()
+
+	var changed [2]int
+	c := cfg
+	c.MaxLimit = 20
+	c.OnLimitChange = func(old, new int, reason string) {
+		if reason == ReasonReconfigured {
+			changed = [2]int{old, new}
+		}
+	}
+
+	if err := limiter.UpdateConfig(c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := limiter.CurrentLimit(); got != 20 {
+		t.Fatalf("expected limit clamped to 20, got %d", got)
+	}
+
+	if changed != [2]int{50, 20} {
+		t.Fatalf("expected OnLimitChange(50, 20), got %v", changed)
+	}
+}
+
+func TestUpdateConfigRejectsInvalidConfig(t *testing.T) {
+	limiter := NewAdaptivePerSecond(50, cfg)
+	defer limiter.Stop()
+
+	c := cfg
+	c.MinLimit = 200
+
+	if err := limiter.UpdateConfig(c); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("expected ErrInvalidConfig, got %v", err)
+	}
+
+	if got := limiter.CurrentLimit(); got != 50 {
+		t.Fatalf("expected limit unchanged at 50, got %d", got)
+	}
+}
+
+func TestConfigValidateRejectsInvalidStrategy(t *testing.T) {
+	c := cfg
+	c.Strategy = Strategy(99)
+	if err := c.Validate(); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("expected unknown strategy to be rejected, got %v", err)
+	}
+
+	c = cfg
+	c.Strategy = StrategyAIMD
+	c.DecreaseFactor = 1
+	if err := c.Validate(); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("expected DecreaseFactor of 1 to be rejected, got %v", err)
+	}
+}
+
+func TestSetLatencyAlphaSpeedsReaction(t *testing.T) {
+	c := cfg
+	c.LatencyAlpha = 0.1
+	limiter := NewAdaptivePerSecond(10, c, WithClock(newFakeClock()))
+	defer limiter.Stop()
+
+	limiter.Record(100*time.Millisecond, nil)
+	limiter.Record(300*time.Millisecond, nil)
+	if got := limiter.AverageLatency(); got != 120*time.Millisecond {
+		t.Fatalf("expected heavy smoothing to give 120ms, got %v", got)
+	}
+
+	if err := limiter.SetLatencyAlpha(0.9); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := limiter.AverageLatency(); got != 120*time.Millisecond {
+		t.Fatalf("expected changing alpha to keep the average, got %v", got)
+	}
+	limiter.Record(300*time.Millisecond, nil)
+	if got := limiter.AverageLatency(); got != 282*time.Millisecond {
+		t.Fatalf("expected the higher alpha to react faster, got %v", got)
+	}
+	if got := limiter.LatencyAlpha(); got != 0.9 {
+		t.Fatalf("expected LatencyAlpha to report 0.9, got %v", got)
+	}
+
+	// UpdateConfig keeps the alpha set at runtime.
+	if err := limiter.UpdateConfig(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := limiter.LatencyAlpha(); got != 0.9 {
+		t.Fatalf("expected UpdateConfig to keep the runtime alpha, got %v", got)
+	}
+}
+
+func TestSetErrorAlphaSpeedsReaction(t *testing.T) {
+	limiter := NewAdaptivePerSecond(10, cfg, WithClock(newFakeClock()))
+	defer limiter.Stop()
+
+	if got := limiter.ErrorAlpha(); got != defaultErrorAlpha {
+		t.Fatalf("expected the default alpha %v, got %v", defaultErrorAlpha, got)
+	}
+
+	limiter.Record(0, nil)
+	if err := limiter.SetErrorAlpha(1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	limiter.Record(0, errors.New("failed"))
+	if got := limiter.ErrorRate(); got != 1 {
+		t.Fatalf("expected an alpha of 1 to track the latest sample, got %v", got)
+	}
+}
+
+func TestSetAlphaRejectsInvalidValues(t *testing.T) {
+	limiter := NewAdaptivePerSecond(10, cfg, WithClock(newFakeClock()))
+	defer limiter.Stop()
+
+	for _, alpha := range []float64{0, -0.5, 1.5, math.NaN()} {
+		if err := limiter.SetLatencyAlpha(alpha); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("expected latency alpha %v to be rejected, got %v", alpha, err)
+		}
+		if err := limiter.SetErrorAlpha(alpha); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("expected error alpha %v to be rejected, got %v", alpha, err)
+		}
+	}
+	if limiter.LatencyAlpha() != defaultLatencyAlpha || limiter.ErrorAlpha() != defaultErrorAlpha {
+		t.Fatalf("expected rejected values to leave the alphas unchanged, got %v and %v", limiter.LatencyAlpha(), limiter.ErrorAlpha())
+	}
+}
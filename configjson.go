@@ -0,0 +1,236 @@
+package adaptiveratelimit
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"time"
+)
+
+// configJSON is the JSON document MarshalJSON writes for an
+// AdaptiveConfig. Durations are strings in time.ParseDuration syntax.
+type configJSON struct {
+	TargetLatency     duration `json:"target_latency"`
+	HighWatermark     float64  `json:"high_watermark,omitempty"`
+	LowWatermark      float64  `json:"low_watermark,omitempty"`
+	MaxErrorRate      float64  `json:"max_error_rate"`
+	IncreaseStep      int      `json:"increase_step"`
+	DecreaseStep      int      `json:"decrease_step"`
+	MinLimit          int      `json:"min_limit"`
+	MaxLimit          int      `json:"max_limit"`
+	AbsoluteMaxLimit  int      `json:"absolute_max_limit,omitempty"`
+	CeilingProbeAfter duration `json:"ceiling_probe_after,omitempty"`
+	CeilingStep       int      `json:"ceiling_step,omitempty"`
+	GuaranteedRate    int      `json:"guaranteed_rate,omitempty"`
+
+	Cooldown         duration `json:"cooldown,omitempty"`
+	IncreaseCooldown duration `json:"increase_cooldown,omitempty"`
+	DecreaseCooldown duration `json:"decrease_cooldown,omitempty"`
+	Warmup           duration `json:"warmup,omitempty"`
+	MinSamples       int      `json:"min_samples,omitempty"`
+	IdleDecay        bool     `json:"idle_decay,omitempty"`
+	MinUtilization   float64  `json:"min_utilization,omitempty"`
+
+	BreakerDuration     duration `json:"breaker_duration,omitempty"`
+	BreakerOpenDuration duration `json:"breaker_open_duration,omitempty"`
+	ProbeCount          int      `json:"probe_count,omitempty"`
+	ProbeInterval       duration `json:"probe_interval,omitempty"`
+	DeadlineSlack       duration `json:"deadline_slack,omitempty"`
+
+	LatencyAlpha       float64                 `json:"latency_alpha,omitempty"`
+	ErrorAlpha         float64                 `json:"error_alpha,omitempty"`
+	SampleRate         float64                 `json:"sample_rate,omitempty"`
+	Strategy           Strategy                `json:"strategy,omitempty"`
+	DecreaseFactor     float64                 `json:"decrease_factor,omitempty"`
+	LatencyWeight      float64                 `json:"latency_weight,omitempty"`
+	ErrorWeight        float64                 `json:"error_weight,omitempty"`
+	UsePercentile      bool                    `json:"use_percentile,omitempty"`
+	LatencyPercentile  float64                 `json:"latency_percentile,omitempty"`
+	PriorityThresholds *priorityThresholdsJSON `json:"priority_thresholds,omitempty"`
+
+	Window              duration           `json:"window,omitempty"`
+	AdjustInterval      duration           `json:"adjust_interval,omitempty"`
+	Jitter              float64            `json:"jitter,omitempty"`
+	IdleTimeout         duration           `json:"idle_timeout,omitempty"`
+	MaxWaiters          int                `json:"max_waiters,omitempty"`
+	MaxHoldDuration     duration           `json:"max_hold_duration,omitempty"`
+	SignalThresholds    map[string]float64 `json:"signal_thresholds,omitempty"`
+	Shadow              bool               `json:"shadow,omitempty"`
+	SaturationThreshold float64            `json:"saturation_threshold,omitempty"`
+}
+
+type priorityThresholdsJSON struct {
+	Low    float64 `json:"low,omitempty"`
+	Normal float64 `json:"normal,omitempty"`
+	High   float64 `json:"high,omitempty"`
+}
+
+// MarshalJSON encodes the configuration as a JSON object with snake_case
+// keys, such as "target_latency" for TargetLatency. Durations are written
+// as strings like "200ms" or "1m30s", and Strategy by name. Fields left
+// at their zero value are omitted, except the thresholds, steps and
+// bounds every configuration sets. The callbacks, Decide and Controller
+// cannot be represented and are not encoded.
+func (c AdaptiveConfig) MarshalJSON() ([]byte, error) {
+	return json.Marshal(newConfigJSON(c))
+}
+
+// newConfigJSON returns the JSON document for c.
+func newConfigJSON(c AdaptiveConfig) configJSON {
+	j := configJSON{
+		TargetLatency:     duration(c.TargetLatency),
+		HighWatermark:     c.HighWatermark,
+		LowWatermark:      c.LowWatermark,
+		MaxErrorRate:      c.MaxErrorRate,
+		IncreaseStep:      c.IncreaseStep,
+		DecreaseStep:      c.DecreaseStep,
+		MinLimit:          c.MinLimit,
+		MaxLimit:          c.MaxLimit,
+		AbsoluteMaxLimit:  c.AbsoluteMaxLimit,
+		CeilingProbeAfter: duration(c.CeilingProbeAfter),
+		CeilingStep:       c.CeilingStep,
+		GuaranteedRate:    c.GuaranteedRate,
+
+		Cooldown:         duration(c.Cooldown),
+		IncreaseCooldown: duration(c.IncreaseCooldown),
+		DecreaseCooldown: duration(c.DecreaseCooldown),
+		Warmup:           duration(c.Warmup),
+		MinSamples:       c.MinSamples,
+		IdleDecay:        c.IdleDecay,
+		MinUtilization:   c.MinUtilization,
+
+		BreakerDuration:     duration(c.BreakerDuration),
+		BreakerOpenDuration: duration(c.BreakerOpenDuration),
+		ProbeCount:          c.ProbeCount,
+		ProbeInterval:       duration(c.ProbeInterval),
+		DeadlineSlack:       duration(c.DeadlineSlack),
+
+		LatencyAlpha:      c.LatencyAlpha,
+		ErrorAlpha:        c.ErrorAlpha,
+		SampleRate:        c.SampleRate,
+		Strategy:          c.Strategy,
+		DecreaseFactor:    c.DecreaseFactor,
+		LatencyWeight:     c.LatencyWeight,
+		ErrorWeight:       c.ErrorWeight,
+		UsePercentile:     c.UsePercentile,
+		LatencyPercentile: c.LatencyPercentile,
+
+		Window:              duration(c.Window),
+		AdjustInterval:      duration(c.AdjustInterval),
+		Jitter:              c.Jitter,
+		IdleTimeout:         duration(c.IdleTimeout),
+		MaxWaiters:          c.MaxWaiters,
+		MaxHoldDuration:     duration(c.MaxHoldDuration),
+		SignalThresholds:    c.SignalThresholds,
+		Shadow:              c.Shadow,
+		SaturationThreshold: c.SaturationThreshold,
+	}
+	if p := c.PriorityThresholds; p != (PriorityThresholds{}) {
+		j.PriorityThresholds = &priorityThresholdsJSON{Low: p.Low, Normal: p.Normal, High: p.High}
+	}
+	return j
+}
+
+// UnmarshalJSON decodes a configuration in the format written by
+// MarshalJSON and validates it. Durations must be strings accepted by
+// time.ParseDuration, such as "200ms" or "2s"; bare numbers are rejected
+// rather than read as nanoseconds. Keys missing from data leave the
+// corresponding fields as they were, and the callbacks, Decide and
+// Controller are never changed, so they can be set before or after
+// decoding.
+//
+// If data is malformed or the decoded configuration fails Validate,
+// UnmarshalJSON returns an error wrapping ErrInvalidConfig and leaves c
+// unchanged.
+func (c *AdaptiveConfig) UnmarshalJSON(data []byte) error {
+	j := newConfigJSON(*c)
+	j.SignalThresholds = maps.Clone(j.SignalThresholds)
+	if err := json.Unmarshal(data, &j); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+
+	cfg := *c
+	j.apply(&cfg)
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	*c = cfg
+	return nil
+}
+
+// apply copies the settings in j to cfg.
+func (j configJSON) apply(cfg *AdaptiveConfig) {
+	cfg.TargetLatency = time.Duration(j.TargetLatency)
+	cfg.HighWatermark = j.HighWatermark
+	cfg.LowWatermark = j.LowWatermark
+	cfg.MaxErrorRate = j.MaxErrorRate
+	cfg.IncreaseStep = j.IncreaseStep
+	cfg.DecreaseStep = j.DecreaseStep
+	cfg.MinLimit = j.MinLimit
+	cfg.MaxLimit = j.MaxLimit
+	cfg.AbsoluteMaxLimit = j.AbsoluteMaxLimit
+	cfg.CeilingProbeAfter = time.Duration(j.CeilingProbeAfter)
+	cfg.CeilingStep = j.CeilingStep
+	cfg.GuaranteedRate = j.GuaranteedRate
+
+	cfg.Cooldown = time.Duration(j.Cooldown)
+	cfg.IncreaseCooldown = time.Duration(j.IncreaseCooldown)
+	cfg.DecreaseCooldown = time.Duration(j.DecreaseCooldown)
+	cfg.Warmup = time.Duration(j.Warmup)
+	cfg.MinSamples = j.MinSamples
+	cfg.IdleDecay = j.IdleDecay
+	cfg.MinUtilization = j.MinUtilization
+
+	cfg.BreakerDuration = time.Duration(j.BreakerDuration)
+	cfg.BreakerOpenDuration = time.Duration(j.BreakerOpenDuration)
+	cfg.ProbeCount = j.ProbeCount
+	cfg.ProbeInterval = time.Duration(j.ProbeInterval)
+	cfg.DeadlineSlack = time.Duration(j.DeadlineSlack)
+
+	cfg.LatencyAlpha = j.LatencyAlpha
+	cfg.ErrorAlpha = j.ErrorAlpha
+	cfg.SampleRate = j.SampleRate
+	cfg.Strategy = j.Strategy
+	cfg.DecreaseFactor = j.DecreaseFactor
+	cfg.LatencyWeight = j.LatencyWeight
+	cfg.ErrorWeight = j.ErrorWeight
+	cfg.UsePercentile = j.UsePercentile
+	cfg.LatencyPercentile = j.LatencyPercentile
+	cfg.PriorityThresholds = PriorityThresholds{}
+	if p := j.PriorityThresholds; p != nil {
+		cfg.PriorityThresholds = PriorityThresholds{Low: p.Low, Normal: p.Normal, High: p.High}
+	}
+
+	cfg.Window = time.Duration(j.Window)
+	cfg.AdjustInterval = time.Duration(j.AdjustInterval)
+	cfg.Jitter = j.Jitter
+	cfg.IdleTimeout = time.Duration(j.IdleTimeout)
+	cfg.MaxWaiters = j.MaxWaiters
+	cfg.MaxHoldDuration = time.Duration(j.MaxHoldDuration)
+	cfg.SignalThresholds = j.SignalThresholds
+	cfg.Shadow = j.Shadow
+	cfg.SaturationThreshold = j.SaturationThreshold
+}
+
+// duration is a time.Duration encoded in JSON as a string in
+// time.ParseDuration syntax.
+type duration time.Duration
+
+// MarshalJSON encodes d as a string such as "200ms".
+func (d duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON decodes a string such as "200ms" into d.
+func (d *duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"200ms\", got %s", data)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = duration(v)
+	return nil
+}
@@ -0,0 +1,98 @@
+package adaptiveratelimit
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveConfigJSONRoundTrip(t *testing.T) {
+	c := cfg
+	c.Cooldown = 2 * time.Second
+	c.Window = time.Minute
+	c.Strategy = StrategyAIMD
+	c.DecreaseFactor = 0.7
+	c.SampleRate = 0.25
+	c.PriorityThresholds = PriorityThresholds{Low: 0.5}
+	c.SignalThresholds = map[string]float64{"queue_depth": 100}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{`"target_latency":"200ms"`, `"cooldown":"2s"`, `"window":"1m0s"`, `"strategy":"aimd"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected %s in %s", want, data)
+		}
+	}
+	if strings.Contains(string(data), "warmup") {
+		t.Errorf("expected unset durations to be omitted, got %s", data)
+	}
+
+	var got AdaptiveConfig
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, c) {
+		t.Fatalf("expected the config to round-trip, got %+v", got)
+	}
+}
+
+func TestAdaptiveConfigUnmarshalJSON(t *testing.T) {
+	var called bool
+	c := AdaptiveConfig{OnReject: func() { called = true }, Warmup: time.Second}
+
+	err := json.Unmarshal([]byte(`{
+		"target_latency": "150ms",
+		"max_error_rate": 0.1,
+		"increase_step": 1,
+		"decrease_step": 2,
+		"min_limit": 1,
+		"max_limit": 50,
+		"breaker_duration": "1m30s"
+	}`), &c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	switch {
+	case c.TargetLatency != 150*time.Millisecond, c.BreakerDuration != 90*time.Second:
+		t.Fatalf("expected durations to be parsed, got %v and %v", c.TargetLatency, c.BreakerDuration)
+	case c.MaxErrorRate != 0.1, c.MaxLimit != 50:
+		t.Fatalf("expected numbers to be decoded, got %v and %d", c.MaxErrorRate, c.MaxLimit)
+	case c.Warmup != time.Second:
+		t.Fatalf("expected a missing key to keep its field, got %v", c.Warmup)
+	}
+	if c.OnReject(); !called {
+		t.Fatal("expected the callbacks to be kept")
+	}
+}
+
+func TestAdaptiveConfigUnmarshalJSONRejectsInvalidInput(t *testing.T) {
+	valid := `"max_error_rate": 0.05, "increase_step": 1, "decrease_step": 2, "min_limit": 1, "max_limit": 10`
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"invalid duration", `{"target_latency": "fast", ` + valid + `}`, `"fast"`},
+		{"numeric duration", `{"target_latency": 200000000, ` + valid + `}`, `"200ms"`},
+		{"unknown strategy", `{"target_latency": "200ms", "strategy": "random", ` + valid + `}`, `"random"`},
+		{"failed validation", `{"target_latency": "200ms", "max_error_rate": 2, "max_limit": 10}`, "MaxErrorRate"},
+		{"wrong type", `{"target_latency": "200ms", "max_error_rate": 0.05, "max_limit": "ten"}`, "max_limit"},
+	}
+
+	for _, tt := range tests {
+		c := cfg
+		err := json.Unmarshal([]byte(tt.data), &c)
+		if !errors.Is(err, ErrInvalidConfig) || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected an ErrInvalidConfig mentioning %s, got %v", tt.name, tt.want, err)
+		}
+		if !reflect.DeepEqual(c, cfg) {
+			t.Errorf("%s: expected the config to be left unchanged, got %+v", tt.name, c)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package adaptiveratelimit
+
+// ReasonController is the reason reported for a change made by a
+// Controller set in AdaptiveConfig.Controller.
+const ReasonController = "controller"
+
+// Controller is a pluggable adaptation algorithm. On every evaluation of
+// the control loop, Next receives the current limit, a snapshot of the
+// limiter's state and its configuration, and returns the next limit.
+// Returning current holds the limit.
+//
+// Unlike a Decide policy, which picks a direction and leaves the step to
+// the limiter, a Controller computes the limit itself, which suits
+// algorithms such as Vegas or PID control that are not expressed as
+// fixed steps.
+type Controller interface {
+	Next(current int, stats Stats, cfg AdaptiveConfig) int
+}
+
+// ControllerFunc adapts an ordinary function to the Controller
+// interface.
+type ControllerFunc func(current int, stats Stats, cfg AdaptiveConfig) int
+
+// Next calls f(current, stats, cfg).
+func (f ControllerFunc) Next(current int, stats Stats, cfg AdaptiveConfig) int {
+	return f(current, stats, cfg)
+}
+
+// StepController is the built-in algorithm, used when
+// AdaptiveConfig.Controller is nil: it lowers the limit when latency, the
+// error rate or a signal exceed their thresholds, raises it when latency
+// is below the low watermark, and holds it in between, stepping as
+// configured by Strategy. It is exported so that custom controllers can
+// delegate to it.
+//
+// Setting it explicitly is the same as leaving Controller nil: its
+// changes keep the reasons of the built-in algorithm, such as
+// ReasonHighLatency, rather than ReasonController.
+type StepController struct{}
+
+// Next returns the limit the built-in algorithm would move current to.
+func (c StepController) Next(current int, stats Stats, cfg AdaptiveConfig) int {
+	next, _, _ := c.nextStep(current, stats, cfg)
+	return next
+}
+
+// nextStep is Next, also returning the Decision and reason of the step.
+func (StepController) nextStep(current int, stats Stats, cfg AdaptiveConfig) (int, Decision, string) {
+	latency := stats.AverageLatency
+	if cfg.UsePercentile {
+		latency = stats.LatencyPercentile
+	}
+	signal := signalBreached(cfg.SignalThresholds, stats.Signals)
+	return cfg.step(current, latency, stats.ErrorRate, signal)
+}
+
+// stepper is implemented by controllers that know why they moved the
+// limit, so that the control loop reports their own Decision and reason
+// instead of deriving them from the returned limit.
+type stepper interface {
+	nextStep(current int, stats Stats, cfg AdaptiveConfig) (int, Decision, string)
+}
+
+// direction returns the Decision that moving the limit from old to next
+// amounts to.
+func direction(old, next int) Decision {
+	switch {
+	case next > old:
+		return DecisionIncrease
+	case next < old:
+		return DecisionDecrease
+	default:
+		return DecisionHold
+	}
+}
@@ -0,0 +1,123 @@
+package adaptiveratelimit
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStepControllerMatchesBuiltInStep(t *testing.T) {
+	tests := []struct {
+		name    string
+		latency time.Duration
+		errors  float64
+		signals map[string]float64
+		want    int
+	}{
+		{"high latency", 500 * time.Millisecond, 0, nil, 8},
+		{"high error rate", 10 * time.Millisecond, 0.5, nil, 8},
+		{"signal", 10 * time.Millisecond, 0, map[string]float64{"queue": 2}, 8},
+		{"within band", 190 * time.Millisecond, 0, nil, 10},
+		{"healthy", 10 * time.Millisecond, 0, nil, 11},
+	}
+
+	c := cfg
+	c.SignalThresholds = map[string]float64{"queue": 1}
+	for _, tt := range tests {
+		stats := Stats{AverageLatency: tt.latency, ErrorRate: tt.errors, Signals: tt.signals}
+		if got := (StepController{}).Next(10, stats, c); got != tt.want {
+			t.Errorf("%s: expected the limit to move from 10 to %d, got %d", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestStepControllerAsControllerMatchesDefault(t *testing.T) {
+	type step struct {
+		limit, ceiling int
+		reason         string
+	}
+
+	run := func(controller Controller) []step {
+		c := cfg
+		c.MaxLimit = 10
+		c.AbsoluteMaxLimit = 12
+		c.CeilingProbeAfter = 2 * time.Second
+		c.CeilingStep = 1
+		c.Controller = controller
+
+		var reason string
+		c.OnLimitChange = func(_, _ int, r string) { reason = r }
+
+		clock := newFakeClock()
+		limiter := NewAdaptivePerSecond(9, c, WithClock(clock))
+		defer limiter.Stop()
+
+		var trace []step
+		for i := 0; i < 8; i++ {
+			latency := 10 * time.Millisecond
+			if i == 6 {
+				latency = time.Second
+			}
+			limiter.Record(latency, nil)
+			reason = ""
+			clock.Advance(time.Second)
+			s := limiter.Snapshot()
+			trace = append(trace, step{s.CurrentLimit, s.Ceiling, reason})
+		}
+		return trace
+	}
+
+	want, got := run(nil), run(StepController{})
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("tick %d: expected an explicit StepController to match the default %+v, got %+v", i, want[i], got[i])
+		}
+	}
+	if last := want[len(want)-2]; last.reason != ReasonHighLatency {
+		t.Fatalf("expected the latency breach to be reported as such, got %+v", last)
+	}
+	if peak := want[5]; peak.ceiling <= 10 {
+		t.Fatalf("expected sustained health at MaxLimit to raise the ceiling, got %+v", peak)
+	}
+}
+
+func TestCustomControllerSetsLimit(t *testing.T) {
+	var seen []int
+	c := cfg
+	c.Controller = ControllerFunc(func(current int, stats Stats, _ AdaptiveConfig) int {
+		seen = append(seen, current)
+		if stats.ErrorRate > 0 {
+			return 0
+		}
+		return 7
+	})
+
+	var reasons []string
+	c.OnLimitChange = func(old, new int, reason string) {
+		reasons = append(reasons, reason)
+	}
+
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(10, c, WithClock(clock))
+	defer limiter.Stop()
+
+	// High latency would lower the built-in limit to 8.
+	limiter.Record(500*time.Millisecond, nil)
+	clock.Advance(time.Second)
+	if got := limiter.CurrentLimit(); got != 7 {
+		t.Fatalf("expected the controller to set the limit to 7, got %d", got)
+	}
+
+	limiter.Record(10*time.Millisecond, errors.New("failed"))
+	clock.Advance(time.Second)
+	if got := limiter.CurrentLimit(); got != cfg.MinLimit {
+		t.Fatalf("expected the result to be clamped to MinLimit, got %d", got)
+	}
+
+	if len(seen) != 2 || seen[0] != 10 || seen[1] != 7 {
+		t.Fatalf("expected the controller to see limits [10 7], got %v", seen)
+	}
+	if len(reasons) != 2 || reasons[0] != ReasonController || reasons[1] != ReasonController {
+		t.Fatalf("expected reasons [controller controller], got %v", reasons)
+	}
+}
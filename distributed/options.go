@@ -0,0 +1,39 @@
+package distributed
+
+import "time"
+
+// Option configures the behavior of a RedisLimiter.
+type Option func(*options)
+
+type options struct {
+	failOpen bool
+	timeout  time.Duration
+}
+
+func newOptions(opts []Option) options {
+	o := options{timeout: 100 * time.Millisecond}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithFailOpen makes Allow admit requests when Redis cannot be reached.
+//
+// By default a RedisLimiter fails closed: any Redis error rejects the
+// request, which protects the backend at the cost of availability.
+func WithFailOpen() Option {
+	return func(o *options) {
+		o.failOpen = true
+	}
+}
+
+// WithTimeout bounds each Redis round trip made by Allow and the control
+// loop. The default is 100ms. Non-positive values are ignored.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.timeout = d
+		}
+	}
+}
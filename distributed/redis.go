@@ -0,0 +1,350 @@
+// Package distributed provides an adaptive rate limiter whose budget is
+// shared by every instance of a service through Redis.
+//
+// Each RedisLimiter admits requests against a per-window counter stored in
+// Redis and reads the current limit from a shared key, so all replicas
+// enforce the same aggregate budget. Latency and error observations are
+// recorded locally and flushed to Redis every AdjustInterval; one instance
+// per interval then folds the aggregated signals into a new shared limit,
+// using the same rules as the single-process Limiter.
+//
+// # Failure modes
+//
+// When Redis is unreachable Allow cannot consult the shared counter. By
+// default the limiter fails closed and rejects the request; WithFailOpen
+// admits it instead. In both cases the control loop skips its adjustment
+// until Redis is reachable again, and CurrentLimit keeps reporting the
+// last limit seen.
+package distributed
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+	"github.com/redis/go-redis/v9"
+)
+
+// allowScript atomically admits n units against the window counter in
+// KEYS[1] if they fit under the shared limit in KEYS[2]. ARGV[1] is the
+// initial limit used when KEYS[2] does not exist yet, ARGV[2] is n and
+// ARGV[3] is the counter's expiry in milliseconds. It returns whether the
+// request was admitted and the limit it was checked against.
+var allowScript = redis.NewScript(`
+local limit = tonumber(redis.call('GET', KEYS[2])) or tonumber(ARGV[1])
+local count = tonumber(redis.call('GET', KEYS[1])) or 0
+local n = tonumber(ARGV[2])
+if count + n > limit then
+	return {0, limit}
+end
+redis.call('INCRBY', KEYS[1], n)
+if count == 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[3])
+end
+return {1, limit}
+`)
+
+// takeSignalsScript returns the aggregated signals in KEYS[1] as
+// samples, latency sum (ms) and errors, and deletes them.
+var takeSignalsScript = redis.NewScript(`
+local v = redis.call('HMGET', KEYS[1], 'samples', 'latency_ms', 'errors')
+redis.call('DEL', KEYS[1])
+return {v[1] or '0', v[2] or '0', v[3] or '0'}
+`)
+
+// RedisLimiter is an adaptive rate limiter whose limit and per-window
+// count are shared through Redis.
+//
+// It offers the same Allow, Record and CurrentLimit surface as
+// adaptiveratelimit.Limiter. Linear and AIMD strategies are supported;
+// StrategyGradient is treated as linear. Warmup, percentile latency and
+// priority thresholds apply to the single-process limiter only.
+//
+// RedisLimiter is safe for concurrent use.
+type RedisLimiter struct {
+	client redis.UniversalClient
+	cfg    adaptiveratelimit.AdaptiveConfig
+	o      options
+
+	initial int
+	limit   atomic.Int64
+
+	limitKey   string
+	countKey   string
+	signalsKey string
+	leaderKey  string
+
+	// local latency, reported by AverageLatency.
+	latencyEWMA *adaptiveratelimit.EWMA
+
+	// mu guards the signals recorded since the last flush.
+	mu        sync.Mutex
+	samples   int64
+	latencyMS float64
+	errors    int64
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// NewRedisLimiter creates a limiter sharing the budget named key with
+// every other RedisLimiter using the same key and Redis deployment.
+//
+// limit is the initial shared limit, in requests per cfg.Window (one
+// second by default). It only takes effect if no instance has published a
+// limit for key yet. NewRedisLimiter returns an error wrapping
+// adaptiveratelimit.ErrInvalidConfig if cfg fails validation.
+//
+// All keys are wrapped in a hash tag so the limiter also works against
+// Redis Cluster.
+func NewRedisLimiter(client redis.UniversalClient, key string, limit int, cfg adaptiveratelimit.AdaptiveConfig, opts ...Option) (*RedisLimiter, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	prefix := "{" + key + "}"
+	l := &RedisLimiter{
+		client:      client,
+		cfg:         cfg,
+		o:           newOptions(opts),
+		initial:     clamp(limit, cfg),
+		limitKey:    prefix + ":limit",
+		countKey:    prefix + ":count",
+		signalsKey:  prefix + ":signals",
+		leaderKey:   prefix + ":leader",
+		latencyEWMA: adaptiveratelimit.NewEWMA(alpha(cfg.LatencyAlpha, 0.3)),
+		stopCh:      make(chan struct{}),
+	}
+	l.limit.Store(int64(l.initial))
+	l.startAdaptiveLoop()
+	return l, nil
+}
+
+// Allow reports whether a request is allowed under the shared limit.
+//
+// If Redis cannot be reached, Allow rejects the request unless the
+// limiter was created with WithFailOpen.
+func (l *RedisLimiter) Allow() bool {
+	return l.AllowN(1)
+}
+
+// AllowN reports whether n units are allowed under the shared limit.
+// It returns false if n <= 0.
+func (l *RedisLimiter) AllowN(n int) bool {
+	if n <= 0 {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), l.o.timeout)
+	defer cancel()
+
+	window := l.window()
+	now := time.Now()
+	bucket := strconv.FormatInt(now.UnixNano()/int64(window), 10)
+
+	res, err := allowScript.Run(ctx, l.client,
+		[]string{l.countKey + ":" + bucket, l.limitKey},
+		l.initial, n, (2 * window).Milliseconds(),
+	).Int64Slice()
+	if err != nil || len(res) != 2 {
+		return l.o.failOpen
+	}
+
+	l.limit.Store(res[1])
+	return res[0] == 1
+}
+
+// Record records the outcome of a completed request.
+//
+// Observations are kept locally and flushed to Redis by the control loop,
+// so Record never blocks on the network.
+func (l *RedisLimiter) Record(latency time.Duration, err error) {
+	ms := float64(latency) / float64(time.Millisecond)
+	l.latencyEWMA.Update(ms)
+
+	l.mu.Lock()
+	l.samples++
+	l.latencyMS += ms
+	if err != nil {
+		l.errors++
+	}
+	l.mu.Unlock()
+}
+
+// CurrentLimit returns the most recently observed shared limit, in
+// requests per window.
+func (l *RedisLimiter) CurrentLimit() int {
+	return int(l.limit.Load())
+}
+
+// AverageLatency returns this instance's exponentially weighted moving
+// average of recorded latencies.
+func (l *RedisLimiter) AverageLatency() time.Duration {
+	return time.Duration(l.latencyEWMA.Value() * float64(time.Millisecond))
+}
+
+// Stop terminates the limiter's background control loop. It does not
+// close the Redis client. It is safe to call Stop multiple times.
+func (l *RedisLimiter) Stop() {
+	l.stopOnce.Do(func() {
+		close(l.stopCh)
+	})
+}
+
+func (l *RedisLimiter) startAdaptiveLoop() {
+	go func() {
+		ticker := time.NewTicker(l.adjustInterval())
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				_ = l.adjust()
+			case <-l.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// adjust flushes the local signals and, if this instance wins the
+// interval's leadership, folds the aggregated signals into a new shared
+// limit.
+func (l *RedisLimiter) adjust() error {
+	ctx, cancel := context.WithTimeout(context.Background(), l.o.timeout)
+	defer cancel()
+
+	if err := l.flush(ctx); err != nil {
+		return err
+	}
+
+	// Leadership lasts for an interval, or for the cooldown if that is
+	// longer, so the shared limit moves at most once per period.
+	hold := max(l.adjustInterval(), l.cfg.Cooldown)
+	leader, err := l.client.SetNX(ctx, l.leaderKey, 1, hold).Result()
+	if err != nil {
+		return err
+	}
+	if !leader {
+		return l.refreshLimit(ctx)
+	}
+
+	res, err := takeSignalsScript.Run(ctx, l.client, []string{l.signalsKey}).StringSlice()
+	if err != nil {
+		return err
+	}
+	if len(res) != 3 {
+		return fmt.Errorf("distributed: unexpected signals reply %q", res)
+	}
+	samples, _ := strconv.ParseFloat(res[0], 64)
+	latencyMS, _ := strconv.ParseFloat(res[1], 64)
+	errors, _ := strconv.ParseFloat(res[2], 64)
+
+	var avgLatency time.Duration
+	var errorRate float64
+	if samples > 0 {
+		avgLatency = time.Duration(latencyMS / samples * float64(time.Millisecond))
+		errorRate = errors / samples
+	}
+
+	if err := l.refreshLimit(ctx); err != nil {
+		return err
+	}
+	next := l.nextLimit(l.CurrentLimit(), avgLatency, errorRate)
+	if err := l.client.Set(ctx, l.limitKey, next, 0).Err(); err != nil {
+		return err
+	}
+	l.limit.Store(int64(next))
+	return nil
+}
+
+// flush adds the signals recorded since the last flush to the shared
+// aggregate. On failure the signals are kept for the next attempt.
+func (l *RedisLimiter) flush(ctx context.Context) error {
+	l.mu.Lock()
+	samples, latencyMS, errors := l.samples, l.latencyMS, l.errors
+	l.samples, l.latencyMS, l.errors = 0, 0, 0
+	l.mu.Unlock()
+
+	if samples == 0 {
+		return nil
+	}
+
+	_, err := l.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.HIncrBy(ctx, l.signalsKey, "samples", samples)
+		p.HIncrByFloat(ctx, l.signalsKey, "latency_ms", latencyMS)
+		p.HIncrBy(ctx, l.signalsKey, "errors", errors)
+		return nil
+	})
+	if err != nil {
+		l.mu.Lock()
+		l.samples += samples
+		l.latencyMS += latencyMS
+		l.errors += errors
+		l.mu.Unlock()
+	}
+	return err
+}
+
+// refreshLimit loads the shared limit into the local cache.
+func (l *RedisLimiter) refreshLimit(ctx context.Context) error {
+	limit, err := l.client.Get(ctx, l.limitKey).Int()
+	switch {
+	case err == redis.Nil:
+		l.limit.Store(int64(l.initial))
+		return nil
+	case err != nil:
+		return err
+	}
+	l.limit.Store(int64(limit))
+	return nil
+}
+
+// nextLimit applies one control decision to limit.
+func (l *RedisLimiter) nextLimit(limit int, avgLatency time.Duration, errorRate float64) int {
+	if avgLatency <= l.cfg.TargetLatency && errorRate <= l.cfg.MaxErrorRate {
+		return clamp(limit+l.cfg.IncreaseStep, l.cfg)
+	}
+
+	if l.cfg.Strategy == adaptiveratelimit.StrategyAIMD {
+		factor := l.cfg.DecreaseFactor
+		if factor == 0 {
+			factor = 0.5
+		}
+		next := int(math.Floor(float64(limit) * factor))
+		return clamp(min(next, limit-1), l.cfg)
+	}
+	return clamp(limit-l.cfg.DecreaseStep, l.cfg)
+}
+
+func (l *RedisLimiter) window() time.Duration {
+	if l.cfg.Window > 0 {
+		return l.cfg.Window
+	}
+	return time.Second
+}
+
+func (l *RedisLimiter) adjustInterval() time.Duration {
+	if l.cfg.AdjustInterval > 0 {
+		return l.cfg.AdjustInterval
+	}
+	return time.Second
+}
+
+// clamp bounds limit by cfg.MinLimit and cfg.MaxLimit, never going below
+// one, as the local limiter does.
+func clamp(limit int, cfg adaptiveratelimit.AdaptiveConfig) int {
+	return min(max(limit, cfg.MinLimit, 1), cfg.MaxLimit)
+}
+
+func alpha(v, def float64) float64 {
+	if v == 0 {
+		return def
+	}
+	return v
+}
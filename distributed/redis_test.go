@@ -0,0 +1,228 @@
+package distributed
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+	"github.com/redis/go-redis/v9"
+)
+
+var cfg = adaptiveratelimit.AdaptiveConfig{
+	TargetLatency: 200 * time.Millisecond,
+	MaxErrorRate:  0.05,
+	IncreaseStep:  1,
+	DecreaseStep:  2,
+	MinLimit:      1,
+	MaxLimit:      100,
+	// Keep the background loop out of the way; tests call adjust.
+	AdjustInterval: time.Hour,
+}
+
+func newRedis(t *testing.T) (*miniredis.Miniredis, redis.UniversalClient) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return mr, client
+}
+
+func newLimiter(t *testing.T, client redis.UniversalClient, limit int, opts ...Option) *RedisLimiter {
+	t.Helper()
+
+	l, err := NewRedisLimiter(client, "api", limit, cfg, opts...)
+	if err != nil {
+		t.Fatalf("NewRedisLimiter: %v", err)
+	}
+	t.Cleanup(l.Stop)
+	return l
+}
+
+func TestRedisLimiterSharesBudget(t *testing.T) {
+	_, client := newRedis(t)
+	a := newLimiter(t, client, 4)
+	b := newLimiter(t, client, 4)
+
+	allowed := 0
+	for i := 0; i < 4; i++ {
+		for _, l := range []*RedisLimiter{a, b} {
+			if l.Allow() {
+				allowed++
+			}
+		}
+	}
+
+	if allowed != 4 {
+		t.Fatalf("expected 4 admissions across instances, got %d", allowed)
+	}
+}
+
+func TestRedisLimiterAllowN(t *testing.T) {
+	_, client := newRedis(t)
+	l := newLimiter(t, client, 5)
+
+	if l.AllowN(0) {
+		t.Fatal("expected AllowN(0) to be rejected")
+	}
+	if !l.AllowN(3) {
+		t.Fatal("expected AllowN(3) to be allowed")
+	}
+	if l.AllowN(3) {
+		t.Fatal("expected AllowN(3) beyond the limit to be rejected")
+	}
+	if !l.AllowN(2) {
+		t.Fatal("expected the remaining 2 units to be allowed")
+	}
+}
+
+func TestRedisLimiterAdjustsSharedLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		latency time.Duration
+		err     error
+		want    int
+	}{
+		{"healthy", 50 * time.Millisecond, nil, 11},
+		{"high latency", 500 * time.Millisecond, nil, 8},
+		{"high error rate", 50 * time.Millisecond, errors.New("boom"), 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, client := newRedis(t)
+			a := newLimiter(t, client, 10)
+			b := newLimiter(t, client, 10)
+
+			for i := 0; i < 10; i++ {
+				b.Record(tt.latency, tt.err)
+			}
+			if err := b.adjust(); err != nil {
+				t.Fatalf("adjust: %v", err)
+			}
+
+			if got := b.CurrentLimit(); got != tt.want {
+				t.Fatalf("expected limit %d, got %d", tt.want, got)
+			}
+
+			// The other instance follows the shared limit.
+			if err := a.adjust(); err != nil {
+				t.Fatalf("adjust: %v", err)
+			}
+			if got := a.CurrentLimit(); got != tt.want {
+				t.Fatalf("expected other instance to see limit %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRedisLimiterAggregatesSignalsAcrossInstances(t *testing.T) {
+	mr, client := newRedis(t)
+	a := newLimiter(t, client, 10)
+	b := newLimiter(t, client, 10)
+
+	// Neither instance is unhealthy on its own, but together the error
+	// rate is 1/10.
+	for i := 0; i < 5; i++ {
+		a.Record(10*time.Millisecond, nil)
+	}
+	for i := 0; i < 4; i++ {
+		b.Record(10*time.Millisecond, nil)
+	}
+	b.Record(10*time.Millisecond, errors.New("boom"))
+
+	if err := b.flush(t.Context()); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if err := a.adjust(); err != nil {
+		t.Fatalf("adjust: %v", err)
+	}
+	if got := a.CurrentLimit(); got != 8 {
+		t.Fatalf("expected aggregated error rate to lower the limit to 8, got %d", got)
+	}
+
+	if mr.Exists("{api}:signals") {
+		t.Fatal("expected the leader to consume the aggregated signals")
+	}
+}
+
+func TestRedisLimiterInitialLimitDoesNotOverrideShared(t *testing.T) {
+	mr, client := newRedis(t)
+	if err := mr.Set("{api}:limit", "2"); err != nil {
+		t.Fatal(err)
+	}
+
+	l := newLimiter(t, client, 50)
+	l.Allow()
+	l.Allow()
+
+	if l.Allow() {
+		t.Fatal("expected the shared limit of 2 to apply")
+	}
+	if got := l.CurrentLimit(); got != 2 {
+		t.Fatalf("expected CurrentLimit 2, got %d", got)
+	}
+}
+
+func TestRedisLimiterFailureModes(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want bool
+	}{
+		{"fail closed by default", nil, false},
+		{"fail open", []Option{WithFailOpen()}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mr, client := newRedis(t)
+			l := newLimiter(t, client, 10, append(tt.opts, WithTimeout(50*time.Millisecond))...)
+			mr.Close()
+
+			if got := l.Allow(); got != tt.want {
+				t.Fatalf("expected Allow to return %v with Redis down, got %v", tt.want, got)
+			}
+
+			l.Record(500*time.Millisecond, nil)
+			if err := l.adjust(); err == nil {
+				t.Fatal("expected adjust to fail with Redis down")
+			}
+			if got := l.CurrentLimit(); got != 10 {
+				t.Fatalf("expected CurrentLimit to keep the last known limit, got %d", got)
+			}
+		})
+	}
+}
+
+func TestRedisLimiterSignalsSurviveFailedFlush(t *testing.T) {
+	mr, client := newRedis(t)
+	l := newLimiter(t, client, 10)
+
+	l.Record(500*time.Millisecond, nil)
+
+	mr.SetError("down")
+	if err := l.adjust(); err == nil {
+		t.Fatal("expected adjust to fail")
+	}
+	mr.SetError("")
+
+	if err := l.adjust(); err != nil {
+		t.Fatalf("adjust: %v", err)
+	}
+	if got := l.CurrentLimit(); got != 8 {
+		t.Fatalf("expected retained signals to lower the limit to 8, got %d", got)
+	}
+}
+
+func TestNewRedisLimiterRejectsInvalidConfig(t *testing.T) {
+	_, client := newRedis(t)
+
+	bad := cfg
+	bad.TargetLatency = 0
+	if _, err := NewRedisLimiter(client, "api", 10, bad); !errors.Is(err, adaptiveratelimit.ErrInvalidConfig) {
+		t.Fatalf("expected ErrInvalidConfig, got %v", err)
+	}
+}
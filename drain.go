@@ -0,0 +1,58 @@
+package adaptiveratelimit
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrDraining is returned by Wait and Reserve once GracefulStop has been
+// called.
+var ErrDraining = errors.New("adaptiveratelimit: limiter is draining")
+
+// GracefulStop stops admitting requests and then stops the limiter,
+// waiting in concurrency mode for the requests already in flight to
+// finish.
+//
+// From the first call on, Allow and its variants return false without
+// counting a rejection, and Wait returns ErrDraining, including for
+// callers already queued. In concurrency mode GracefulStop then blocks
+// until every in-flight slot has been released with Record (or a done or
+// release callback), returning nil, or until ctx is done, returning
+// ctx.Err(). In the rate-based modes it returns nil immediately. Either
+// way the control goroutine is stopped as by Stop.
+//
+// It is safe to call GracefulStop more than once and concurrently.
+func (l *Limiter) GracefulStop(ctx context.Context) error {
+	defer l.Stop()
+
+	l.mu.Lock()
+	if !l.draining.Swap(true) {
+		close(l.drainCh)
+	}
+	if l.mode != modeConcurrency || l.count.Load() == 0 {
+		l.mu.Unlock()
+		return nil
+	}
+	if l.idleCh == nil {
+		l.idleCh = make(chan struct{})
+	}
+	idle := l.idleCh
+	l.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// notifyIdle wakes GracefulStop callers once no requests are in flight.
+//
+// The caller must hold l.mu.
+func (l *Limiter) notifyIdle() {
+	if l.idleCh != nil && l.count.Load() == 0 {
+		close(l.idleCh)
+		l.idleCh = nil
+	}
+}
@@ -0,0 +1,146 @@
+package adaptiveratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGracefulStopWaitsForInFlight(t *testing.T) {
+	limiter := NewAdaptiveConcurrency(3, cfg)
+
+	for i := 0; i < 3; i++ {
+		if !limiter.Allow() {
+			t.Fatalf("request %d: expected admission", i)
+		}
+	}
+
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- limiter.GracefulStop(context.Background())
+	}()
+
+	for i := 0; i < 3; i++ {
+		select {
+		case err := <-stopped:
+			t.Fatalf("expected GracefulStop to wait with %d requests in flight, got %v", 3-i, err)
+		case <-time.After(20 * time.Millisecond):
+		}
+		limiter.Record(10*time.Millisecond, nil)
+	}
+
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Fatalf("expected a clean drain, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected GracefulStop to return once in-flight work drained")
+	}
+
+	if limiter.Allow() {
+		t.Fatal("expected a drained limiter to reject new requests")
+	}
+	if got := limiter.Rejected(); got != 0 {
+		t.Fatalf("expected draining rejections not to be counted, got %d", got)
+	}
+}
+
+func TestGracefulStopHonorsContext(t *testing.T) {
+	limiter := NewAdaptiveConcurrency(1, cfg)
+	limiter.Allow()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := limiter.GracefulStop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the deadline to cut the drain short, got %v", err)
+	}
+}
+
+func TestGracefulStopReleasesWaiters(t *testing.T) {
+	limiter := NewAdaptivePerSecond(1, cfg, WithClock(newFakeClock()))
+	limiter.Allow()
+
+	waited := make(chan error, 1)
+	go func() {
+		waited <- limiter.Wait(context.Background())
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	if err := limiter.GracefulStop(context.Background()); err != nil {
+		t.Fatalf("expected rate-based GracefulStop to return at once, got %v", err)
+	}
+
+	select {
+	case err := <-waited:
+		if !errors.Is(err, ErrDraining) {
+			t.Fatalf("expected the queued waiter to get ErrDraining, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the queued waiter to be released")
+	}
+
+	if err := limiter.Wait(context.Background()); !errors.Is(err, ErrDraining) {
+		t.Fatalf("expected new waiters to get ErrDraining, got %v", err)
+	}
+}
+
+func TestAllowFailsClosedAfterStop(t *testing.T) {
+	rejects := 0
+	c := cfg
+	c.OnReject = func() { rejects++ }
+	limiter := NewAdaptivePerSecond(10, c, WithClock(newFakeClock()))
+	limiter.Stop()
+
+	if limiter.Allow() || limiter.AllowN(1) || limiter.AllowPriority(PriorityHigh) {
+		t.Fatal("expected a stopped limiter to reject every request")
+	}
+	if got := limiter.Rejected(); got != 0 || rejects != 0 {
+		t.Fatalf("expected rejections after Stop not to be counted, got %d (OnReject fired %d times)", got, rejects)
+	}
+}
+
+func TestRecordAfterStopIsNoOp(t *testing.T) {
+	limiter := NewAdaptivePerSecond(10, cfg, WithClock(newFakeClock()))
+	limiter.Record(100*time.Millisecond, nil)
+	limiter.Stop()
+
+	limiter.Record(time.Second, errors.New("late"))
+	limiter.RecordResult(errors.New("late"))
+	limiter.RecordBatch([]Sample{{Latency: time.Second, Err: errors.New("late")}})
+
+	if got := limiter.AverageLatency(); got != 100*time.Millisecond {
+		t.Fatalf("expected late samples to leave latency at 100ms, got %v", got)
+	}
+	if got := limiter.ErrorRate(); got != 0 {
+		t.Fatalf("expected late samples to leave the error rate at 0, got %f", got)
+	}
+}
+
+func TestStopReleasesWaiters(t *testing.T) {
+	limiter := NewAdaptivePerSecond(1, cfg, WithClock(newFakeClock()))
+	limiter.Allow()
+
+	waited := make(chan error, 1)
+	go func() {
+		waited <- limiter.Wait(context.Background())
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	limiter.Stop()
+
+	select {
+	case err := <-waited:
+		if !errors.Is(err, ErrStopped) {
+			t.Fatalf("expected the queued waiter to get ErrStopped, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the queued waiter to be released")
+	}
+
+	if _, err := limiter.Reserve(1); !errors.Is(err, ErrStopped) {
+		t.Fatalf("expected new reservations to get ErrStopped, got %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+// Package echo provides adaptive rate limiting middleware for the Echo web
+// framework.
+package echo
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+	"github.com/bhatpriyanka8/adaptiveratelimit/internal/limiting"
+	"github.com/bhatpriyanka8/adaptiveratelimit/internal/status"
+	"github.com/labstack/echo/v4"
+)
+
+// Middleware returns an echo.MiddlewareFunc that applies adaptive rate
+// limiting to incoming requests.
+//
+// Requests that exceed the current limit are rejected by returning an
+// *echo.HTTPError with status 429 (Too Many Requests), after setting a
+// Retry-After header. Handler errors are recorded using the status Echo
+// would respond with, so an *echo.HTTPError below 500 is not counted as a
+// failure while any other error is; see WithErrorStatus.
+//
+// l is usually a *adaptiveratelimit.Limiter, but any Limiting will do,
+// such as a MultiLimiter or a fake from the mock package. Retry-After is
+// one second unless l reports a TimeToReset.
+func Middleware(l adaptiveratelimit.Limiting, opts ...Option) echo.MiddlewareFunc {
+	o := newOptions(opts)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ok, done := limiting.AllowWithDone(l)
+			if !ok {
+				c.Response().Header().Set("Retry-After", status.RetryAfter(limiting.TimeToReset(l)))
+				return echo.NewHTTPError(http.StatusTooManyRequests, "rate limited")
+			}
+
+			err := next(c)
+			done(status.Check(responseStatus(c, err), o.errorStatus))
+			return err
+		}
+	}
+}
+
+// responseStatus returns the status the request is answered with, given
+// the error returned by the handler.
+func responseStatus(c echo.Context, err error) int {
+	if err == nil {
+		return c.Response().Status
+	}
+
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		return he.Code
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,86 @@
+package echo
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+	"github.com/labstack/echo/v4"
+)
+
+var cfg = adaptiveratelimit.AdaptiveConfig{
+	TargetLatency: 200 * time.Millisecond,
+	MaxErrorRate:  0.05,
+	IncreaseStep:  1,
+	DecreaseStep:  2,
+	MinLimit:      1,
+	MaxLimit:      100,
+}
+
+func serve(e *echo.Echo) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	return rec
+}
+
+func TestMiddlewareRejectsOverLimit(t *testing.T) {
+	limiter := adaptiveratelimit.NewAdaptivePerSecond(1, cfg)
+	defer limiter.Stop()
+
+	e := echo.New()
+	e.Use(Middleware(limiter))
+	e.GET("/", func(c echo.Context) error { return c.String(http.StatusOK, "ok") })
+
+	tests := []struct {
+		name string
+		code int
+	}{
+		{"allowed", http.StatusOK},
+		{"rejected", http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		rec := serve(e)
+		if rec.Code != tt.code {
+			t.Fatalf("%s: expected status %d, got %d", tt.name, tt.code, rec.Code)
+		}
+	}
+
+	if rec := serve(e); rec.Header().Get("Retry-After") == "" {
+		t.Fatal("expected Retry-After on rejection")
+	}
+}
+
+func TestMiddlewareRecordsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler echo.HandlerFunc
+		opts    []Option
+		failed  bool
+	}{
+		{"ok", func(c echo.Context) error { return c.NoContent(http.StatusOK) }, nil, false},
+		{"written server error", func(c echo.Context) error { return c.NoContent(http.StatusBadGateway) }, nil, true},
+		{"http error below threshold", func(echo.Context) error { return echo.ErrNotFound }, nil, false},
+		{"plain error", func(echo.Context) error { return errors.New("boom") }, nil, true},
+		{"custom threshold", func(echo.Context) error { return echo.ErrNotFound }, []Option{WithErrorStatus(400)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := adaptiveratelimit.NewAdaptivePerSecond(10, cfg)
+			defer limiter.Stop()
+
+			e := echo.New()
+			e.Use(Middleware(limiter, tt.opts...))
+			e.GET("/", tt.handler)
+			serve(e)
+
+			if got := limiter.ErrorRate() > 0; got != tt.failed {
+				t.Fatalf("expected failure recorded = %v, got error rate %v", tt.failed, limiter.ErrorRate())
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package adaptiveratelimit
+
+// SetEnabled turns limiting on or off at runtime. Limiters start enabled.
+//
+// While disabled, Allow, AllowN, AllowPriority and Wait admit every
+// request without consuming capacity, so callers such as the HTTP and gRPC
+// wrappers fail open. Record keeps updating the latency and error averages
+// and the control loop keeps adapting, so the limit is warm when limiting
+// is re-enabled.
+//
+// In concurrency mode, requests admitted while disabled do not hold a
+// slot, so toggling the switch with requests in flight can briefly
+// undercount InFlight.
+func (l *Limiter) SetEnabled(enabled bool) {
+	l.disabled.Store(!enabled)
+}
+
+// Enabled reports whether limiting is enabled.
+func (l *Limiter) Enabled() bool {
+	return !l.disabled.Load()
+}
@@ -0,0 +1,49 @@
+package adaptiveratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSetEnabledKillSwitch(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(1, cfg, WithClock(clock))
+	defer limiter.Stop()
+
+	if !limiter.Enabled() {
+		t.Fatal("expected limiter to start enabled")
+	}
+
+	limiter.Allow()
+	if limiter.Allow() {
+		t.Fatal("expected rejection while enabled")
+	}
+
+	limiter.SetEnabled(false)
+	if limiter.Enabled() {
+		t.Fatal("expected Enabled to report false")
+	}
+
+	for i := 0; i < 100; i++ {
+		if !limiter.Allow() || !limiter.AllowN(5) || !limiter.AllowPriority(PriorityLow) {
+			t.Fatal("expected every request to be allowed while disabled")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := limiter.Wait(ctx); err != nil {
+		t.Fatalf("expected Wait to return immediately while disabled, got %v", err)
+	}
+
+	limiter.Record(500*time.Millisecond, nil)
+	if limiter.AverageLatency() != 500*time.Millisecond {
+		t.Fatalf("expected Record to update averages while disabled, got %v", limiter.AverageLatency())
+	}
+
+	limiter.SetEnabled(true)
+	if limiter.Allow() {
+		t.Fatal("expected limiting to resume with the window count from before")
+	}
+}
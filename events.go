@@ -0,0 +1,129 @@
+package adaptiveratelimit
+
+import "time"
+
+// eventBuffer is the capacity of the channel returned by Events.
+const eventBuffer = 64
+
+// Decision is the direction of a control loop adjustment.
+type Decision int
+
+// Decisions reported in Event.
+const (
+	// DecisionHold means the limit did not change, for example because
+	// it is already pinned at MinLimit or MaxLimit.
+	DecisionHold Decision = iota
+
+	// DecisionIncrease means the limit was raised.
+	DecisionIncrease
+
+	// DecisionDecrease means the limit was lowered.
+	DecisionDecrease
+)
+
+// String returns the decision's name.
+func (d Decision) String() string {
+	switch d {
+	case DecisionHold:
+		return "hold"
+	case DecisionIncrease:
+		return "increase"
+	case DecisionDecrease:
+		return "decrease"
+	default:
+		return "unknown"
+	}
+}
+
+// Event describes one evaluation of the control loop.
+type Event struct {
+	// Name is the limiter's name; see WithName.
+	Name string
+
+	// Time is when the evaluation happened.
+	Time time.Time
+
+	// Latency is the observed latency compared against TargetLatency:
+	// the average, or the configured percentile if UsePercentile is set.
+	Latency time.Duration
+
+	// ErrorRate is the observed error rate.
+	ErrorRate float64
+
+	// OldLimit and NewLimit are the limit before and after evaluation.
+	OldLimit int
+	NewLimit int
+
+	// Decision is the direction the limit moved.
+	Decision Decision
+
+	// Reason is why the control loop acted, as passed to OnLimitChange.
+	Reason string
+}
+
+// Events returns a channel receiving an Event for every evaluation of the
+// control loop. Evaluations skipped during warmup or cooldown produce no
+// event.
+//
+// Sends never block the control loop: if the consumer falls more than
+// 64 events behind, newer events are dropped. The channel is closed
+// once the limiter is stopped. Every call returns the same channel; the
+// first call allocates it, so limiters whose events are never consumed pay
+// nothing.
+func (l *Limiter) Events() <-chan Event {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.events == nil {
+		l.events = make(chan Event, eventBuffer)
+		select {
+		case <-l.stopCh:
+			l.closeEvents()
+		default:
+		}
+	}
+	return l.events
+}
+
+// emit sends e to the events channel without blocking.
+//
+// The caller must hold l.mu.
+func (l *Limiter) emit(e Event) {
+	if l.events == nil || l.eventsClosed {
+		return
+	}
+	e.Name = l.name
+	select {
+	case l.events <- e:
+	default:
+	}
+}
+
+// closeEvents closes the events channel, if it exists and is still open.
+//
+// The caller must hold l.mu.
+func (l *Limiter) closeEvents() {
+	if l.events != nil && !l.eventsClosed {
+		close(l.events)
+		l.eventsClosed = true
+	}
+}
+
+// event converts d, made at now, to an Event.
+func (d decision) event(now time.Time) Event {
+	e := Event{
+		Time:      now,
+		Latency:   d.latency,
+		ErrorRate: d.errorRate,
+		OldLimit:  d.oldLimit,
+		NewLimit:  d.newLimit,
+		Reason:    d.reason,
+	}
+	switch {
+	case d.newLimit > d.oldLimit:
+		e.Decision = DecisionIncrease
+	case d.newLimit < d.oldLimit:
+		e.Decision = DecisionDecrease
+	}
+	return e
+}
@@ -0,0 +1,118 @@
+package adaptiveratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventsReportsDecrease(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(10, cfg, WithClock(clock))
+	defer limiter.Stop()
+
+	events := limiter.Events()
+
+	limiter.Record(500*time.Millisecond, nil)
+	clock.Advance(time.Second)
+
+	select {
+	case e := <-events:
+		if e.Decision != DecisionDecrease || e.OldLimit != 10 || e.NewLimit != 8 {
+			t.Fatalf("expected decrease from 10 to 8, got %+v", e)
+		}
+		if e.Reason != ReasonHighLatency || e.Latency != 500*time.Millisecond {
+			t.Fatalf("expected high latency of 500ms, got %+v", e)
+		}
+		if !e.Time.Equal(clock.Now()) {
+			t.Fatalf("expected event time %v, got %v", clock.Now(), e.Time)
+		}
+	default:
+		t.Fatal("expected an event after the control loop ran")
+	}
+}
+
+func TestNamedLimiterReportsName(t *testing.T) {
+	clock := newFakeClock()
+	logger := &recordingLogger{}
+	limiter := NewAdaptivePerSecond(10, cfg, WithClock(clock), WithLogger(logger), WithName("payments"))
+	defer limiter.Stop()
+
+	events := limiter.Events()
+	clock.Advance(time.Second)
+
+	select {
+	case e := <-events:
+		if e.Name != "payments" {
+			t.Fatalf("expected the event to carry the name, got %q", e.Name)
+		}
+	default:
+		t.Fatal("expected an event after the control loop ran")
+	}
+	if got := limiter.Snapshot().Name; got != "payments" {
+		t.Fatalf("expected the snapshot to carry the name, got %q", got)
+	}
+
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	for _, e := range logger.entries {
+		if len(e.kv) < 2 || e.kv[0] != "limiter" || e.kv[1] != "payments" {
+			t.Fatalf("expected %q to be logged with the name, got %v", e.msg, e.kv)
+		}
+	}
+	if len(logger.entries) == 0 {
+		t.Fatal("expected the decision to be logged")
+	}
+}
+
+func TestEventsDropsWhenConsumerIsSlow(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(10, cfg, WithClock(clock))
+	defer limiter.Stop()
+
+	events := limiter.Events()
+
+	// Never read while the loop runs; it must keep making progress.
+	clock.Advance((eventBuffer + 10) * time.Second)
+
+	if got := len(events); got != eventBuffer {
+		t.Fatalf("expected a full buffer of %d events, got %d", eventBuffer, got)
+	}
+
+	e := <-events
+	if e.Decision != DecisionIncrease || e.OldLimit != 10 || e.NewLimit != 11 {
+		t.Fatalf("expected the oldest event to be kept, got %+v", e)
+	}
+}
+
+func TestEventsClosedOnStop(t *testing.T) {
+	limiter := NewAdaptivePerSecond(10, cfg, WithClock(newFakeClock()))
+	events := limiter.Events()
+
+	limiter.Stop()
+
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Fatal("expected no events before Stop")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the events channel to be closed after Stop")
+	}
+
+	if _, ok := <-limiter.Events(); ok {
+		t.Fatal("expected Events after Stop to return a closed channel")
+	}
+}
+
+func TestDecisionString(t *testing.T) {
+	for d, want := range map[Decision]string{
+		DecisionHold:     "hold",
+		DecisionIncrease: "increase",
+		DecisionDecrease: "decrease",
+		Decision(99):     "unknown",
+	} {
+		if got := d.String(); got != want {
+			t.Fatalf("expected %q, got %q", want, got)
+		}
+	}
+}
@@ -1,6 +1,11 @@
 package adaptiveratelimit
 
-import "sync"
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
 
 // EWMA implements an exponentially weighted moving average.
 //
@@ -12,6 +17,10 @@ type EWMA struct {
 	alpha float64
 	value float64
 	init  bool
+
+	// count is the number of updates since creation, Reset or restore.
+	// It is written under mu but may be read without it.
+	count atomic.Uint64
 }
 
 // NewEWMA creates a new EWMA with the given smoothing factor alpha.
@@ -25,16 +34,61 @@ func NewEWMA(alpha float64) *EWMA {
 
 // Update incorporates a new sample into the moving average.
 func (e *EWMA) Update(sample float64) {
+	e.UpdateWeighted(sample, 1)
+}
+
+// UpdateWeighted incorporates a sample counted weight times. The sample
+// is blended in with an effective smoothing factor of
+// 1 - (1-alpha)^weight, which for whole weights is equivalent to calling
+// Update weight times with the same sample. Non-positive weights are
+// ignored.
+func (e *EWMA) UpdateWeighted(sample, weight float64) {
+	if weight <= 0 {
+		return
+	}
+
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
+	e.update(sample, weight)
+}
+
+// update blends in a sample of positive weight.
+//
+// The caller must hold e.mu.
+func (e *EWMA) update(sample, weight float64) {
+	e.count.Add(1)
 	if !e.init {
 		e.value = sample
 		e.init = true
 		return
 	}
 
-	e.value = e.alpha*sample + (1-e.alpha)*e.value
+	alpha := e.alpha
+	if weight != 1 {
+		alpha = 1 - math.Pow(1-e.alpha, weight)
+	}
+	e.value = alpha*sample + (1-alpha)*e.value
+}
+
+// DecayTowards ages the average toward target as if target had been
+// sampled once for every second of dt, so that a value stops reflecting
+// old samples once they are no longer arriving. It has no effect before
+// the first sample or for non-positive dt.
+func (e *EWMA) DecayTowards(target float64, dt time.Duration) {
+	if dt <= 0 {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.init {
+		return
+	}
+
+	retain := math.Pow(1-e.alpha, dt.Seconds())
+	e.value = target + (e.value-target)*retain
 }
 
 // Value returns the current EWMA value.
@@ -43,3 +97,72 @@ func (e *EWMA) Value() float64 {
 	defer e.mu.Unlock()
 	return e.value
 }
+
+// Alpha returns the smoothing factor.
+func (e *EWMA) Alpha() float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.alpha
+}
+
+// SetAlpha changes the smoothing factor applied to later samples. The
+// current value is kept, so the average carries on from where it is
+// rather than starting over.
+func (e *EWMA) SetAlpha(alpha float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.alpha = alpha
+}
+
+// Count returns the number of samples incorporated by Update and
+// UpdateWeighted since the EWMA was created or last reset. A weighted
+// sample counts once. Count does not take the EWMA's lock.
+func (e *EWMA) Count() uint64 {
+	return e.count.Load()
+}
+
+// Initialized reports whether the EWMA holds a value, so that a Value of
+// zero from an EWMA that has seen no samples can be told apart from a
+// genuine zero average.
+func (e *EWMA) Initialized() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.init
+}
+
+// Reset discards all samples, returning the EWMA to its uninitialized
+// state. The next Update sets the value directly.
+func (e *EWMA) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.value = 0
+	e.init = false
+	e.count.Store(0)
+}
+
+// state returns the current value, or nil if no sample has been recorded.
+func (e *EWMA) state() *float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.init {
+		return nil
+	}
+	v := e.value
+	return &v
+}
+
+// restore sets the value from state, or resets the EWMA if it is nil.
+// The sample count restarts from zero either way.
+func (e *EWMA) restore(v *float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.count.Store(0)
+	if v == nil {
+		e.value, e.init = 0, false
+		return
+	}
+	e.value, e.init = *v, true
+}
@@ -1,6 +1,9 @@
 package adaptiveratelimit
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestEWMAConverges(t *testing.T) {
 	ewma := NewEWMA(0.5)
@@ -25,3 +28,100 @@ func TestEWMAReactsToChange(t *testing.T) {
 		t.Fatalf("expected EWMA to increase after spike, got %f", ewma.Value())
 	}
 }
+
+func TestEWMAReset(t *testing.T) {
+	ewma := NewEWMA(0.5)
+
+	ewma.Update(100)
+	ewma.Reset()
+
+	if ewma.Value() != 0 {
+		t.Fatalf("expected value to be zero after reset, got %f", ewma.Value())
+	}
+
+	ewma.Update(40)
+
+	if ewma.Value() != 40 {
+		t.Fatalf("expected first sample after reset to set the value, got %f", ewma.Value())
+	}
+}
+
+func TestEWMAUpdateWeightedMatchesRepeatedUpdates(t *testing.T) {
+	weighted := NewEWMA(0.3)
+	repeated := NewEWMA(0.3)
+
+	weighted.Update(100)
+	repeated.Update(100)
+
+	weighted.UpdateWeighted(400, 3)
+	for i := 0; i < 3; i++ {
+		repeated.Update(400)
+	}
+
+	if diff := weighted.Value() - repeated.Value(); diff > 1e-9 || diff < -1e-9 {
+		t.Fatalf("expected weight 3 to match three updates, got %f and %f", weighted.Value(), repeated.Value())
+	}
+}
+
+func TestEWMAUpdateWeightedIgnoresNonPositiveWeight(t *testing.T) {
+	ewma := NewEWMA(0.5)
+	ewma.Update(100)
+
+	ewma.UpdateWeighted(1000, 0)
+	ewma.UpdateWeighted(1000, -1)
+
+	if ewma.Value() != 100 {
+		t.Fatalf("expected non-positive weights to be ignored, got %f", ewma.Value())
+	}
+}
+
+func TestEWMADecayTowardsTrendsToTarget(t *testing.T) {
+	ewma := NewEWMA(0.5)
+	ewma.Update(400)
+
+	prev := ewma.Value()
+	for i := 0; i < 5; i++ {
+		ewma.DecayTowards(100, time.Second)
+		if v := ewma.Value(); v >= prev || v < 100 {
+			t.Fatalf("tick %d: expected value to move from %f toward 100, got %f", i, prev, v)
+		}
+		prev = ewma.Value()
+	}
+
+	if prev > 110 {
+		t.Fatalf("expected value near 100 after five ticks, got %f", prev)
+	}
+}
+
+func TestEWMADecayTowardsIgnoresEmptyAverage(t *testing.T) {
+	ewma := NewEWMA(0.5)
+	ewma.DecayTowards(100, time.Second)
+
+	ewma.Update(40)
+	if ewma.Value() != 40 {
+		t.Fatalf("expected decay before the first sample to be ignored, got %f", ewma.Value())
+	}
+}
+
+func TestEWMACountAndInitialized(t *testing.T) {
+	ewma := NewEWMA(0.5)
+	if ewma.Initialized() || ewma.Count() != 0 {
+		t.Fatalf("expected an empty EWMA, got count %d, initialized %v", ewma.Count(), ewma.Initialized())
+	}
+
+	ewma.Update(0)
+	if !ewma.Initialized() {
+		t.Fatal("expected a zero sample to initialize the EWMA")
+	}
+	ewma.Update(10)
+	ewma.UpdateWeighted(10, 3)
+	ewma.UpdateWeighted(10, 0)
+	if got := ewma.Count(); got != 3 {
+		t.Fatalf("expected one count per accepted update, got %d", got)
+	}
+
+	ewma.Reset()
+	if ewma.Initialized() || ewma.Count() != 0 {
+		t.Fatalf("expected Reset to clear the count, got count %d, initialized %v", ewma.Count(), ewma.Initialized())
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+	adapthttp "github.com/bhatpriyanka8/adaptiveratelimit/http"
+	"github.com/go-chi/chi/v5"
+)
+
+func main() {
+	cfg := adaptiveratelimit.AdaptiveConfig{
+		TargetLatency: 200 * time.Millisecond,
+		MaxErrorRate:  0.05,
+		IncreaseStep:  1,
+		DecreaseStep:  2,
+		MinLimit:      1,
+		MaxLimit:      100,
+		Cooldown:      2 * time.Second,
+	}
+
+	limiter := adaptiveratelimit.NewAdaptivePerSecond(10, cfg)
+	defer limiter.Stop()
+
+	r := chi.NewRouter()
+
+	// Public routes are not limited.
+	r.Get("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	// The http middleware already fits chi, so it can guard a route group.
+	r.Group(func(r chi.Router) {
+		r.Use(adapthttp.Middleware(limiter))
+		r.Get("/api", func(w http.ResponseWriter, _ *http.Request) {
+			time.Sleep(100 * time.Millisecond)
+			w.Write([]byte("ok"))
+		})
+	})
+
+	http.ListenAndServe(":8080", r)
+}
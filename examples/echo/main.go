@@ -0,0 +1,34 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+	adaptecho "github.com/bhatpriyanka8/adaptiveratelimit/echo"
+	"github.com/labstack/echo/v4"
+)
+
+func main() {
+	cfg := adaptiveratelimit.AdaptiveConfig{
+		TargetLatency: 200 * time.Millisecond,
+		MaxErrorRate:  0.05,
+		IncreaseStep:  1,
+		DecreaseStep:  2,
+		MinLimit:      1,
+		MaxLimit:      100,
+		Cooldown:      2 * time.Second,
+	}
+
+	limiter := adaptiveratelimit.NewAdaptivePerSecond(10, cfg)
+	defer limiter.Stop()
+
+	e := echo.New()
+	e.Use(adaptecho.Middleware(limiter))
+	e.GET("/", func(c echo.Context) error {
+		time.Sleep(100 * time.Millisecond)
+		return c.String(http.StatusOK, "ok")
+	})
+
+	e.Start(":8080")
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"time"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+	adaptfiber "github.com/bhatpriyanka8/adaptiveratelimit/fiber"
+	"github.com/gofiber/fiber/v2"
+)
+
+func main() {
+	cfg := adaptiveratelimit.AdaptiveConfig{
+		TargetLatency: 200 * time.Millisecond,
+		MaxErrorRate:  0.05,
+		IncreaseStep:  1,
+		DecreaseStep:  2,
+		MinLimit:      1,
+		MaxLimit:      100,
+		Cooldown:      2 * time.Second,
+	}
+
+	limiter := adaptiveratelimit.NewAdaptivePerSecond(10, cfg)
+	defer limiter.Stop()
+
+	app := fiber.New()
+	app.Use(adaptfiber.New(limiter))
+	app.Get("/", func(c *fiber.Ctx) error {
+		time.Sleep(100 * time.Millisecond)
+		return c.SendString("ok")
+	})
+
+	app.Listen(":8080")
+}
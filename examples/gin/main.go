@@ -0,0 +1,34 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+	adaptgin "github.com/bhatpriyanka8/adaptiveratelimit/gin"
+	"github.com/gin-gonic/gin"
+)
+
+func main() {
+	cfg := adaptiveratelimit.AdaptiveConfig{
+		TargetLatency: 200 * time.Millisecond,
+		MaxErrorRate:  0.05,
+		IncreaseStep:  1,
+		DecreaseStep:  2,
+		MinLimit:      1,
+		MaxLimit:      100,
+		Cooldown:      2 * time.Second,
+	}
+
+	limiter := adaptiveratelimit.NewAdaptivePerSecond(10, cfg)
+	defer limiter.Stop()
+
+	r := gin.Default()
+	r.Use(adaptgin.Middleware(limiter))
+	r.GET("/", func(c *gin.Context) {
+		time.Sleep(100 * time.Millisecond)
+		c.String(http.StatusOK, "ok")
+	})
+
+	r.Run(":8080")
+}
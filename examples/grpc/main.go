@@ -29,6 +29,9 @@ func main() {
 		grpc.UnaryInterceptor(
 			adaptgrpc.UnaryServerInterceptor(limiter),
 		),
+		grpc.StreamInterceptor(
+			adaptgrpc.StreamServerInterceptor(limiter),
+		),
 	)
 
 	listen, err := net.Listen("tcp", ":50051")
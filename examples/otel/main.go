@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+	adapthttp "github.com/bhatpriyanka8/adaptiveratelimit/http"
+	adaptotel "github.com/bhatpriyanka8/adaptiveratelimit/otel"
+)
+
+func main() {
+	cfg := adaptiveratelimit.AdaptiveConfig{
+		TargetLatency: 200 * time.Millisecond,
+		MaxErrorRate:  0.05,
+		IncreaseStep:  1,
+		DecreaseStep:  2,
+		MinLimit:      1,
+		MaxLimit:      100,
+		Cooldown:      2 * time.Second,
+	}
+
+	limiter := adaptiveratelimit.NewAdaptivePerSecond(10, cfg)
+	defer limiter.Stop()
+
+	exporter, err := stdoutmetric.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(
+		sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(10*time.Second)),
+	))
+	defer provider.Shutdown(context.Background())
+
+	if _, err := adaptotel.Register(provider.Meter("example"), limiter, adaptotel.WithName("api")); err != nil {
+		log.Fatal(err)
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("ok"))
+	})
+
+	http.ListenAndServe(":8080", adapthttp.Middleware(limiter)(handler))
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+	adapthttp "github.com/bhatpriyanka8/adaptiveratelimit/http"
+	adaptprom "github.com/bhatpriyanka8/adaptiveratelimit/prometheus"
+)
+
+func main() {
+	cfg := adaptiveratelimit.AdaptiveConfig{
+		TargetLatency: 200 * time.Millisecond,
+		MaxErrorRate:  0.05,
+		IncreaseStep:  1,
+		DecreaseStep:  2,
+		MinLimit:      1,
+		MaxLimit:      100,
+		Cooldown:      2 * time.Second,
+	}
+
+	limiter := adaptiveratelimit.NewAdaptivePerSecond(10, cfg)
+	defer limiter.Stop()
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(adaptprom.NewCollector(limiter))
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("ok"))
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle("/", adapthttp.Middleware(limiter)(handler))
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+
+	http.ListenAndServe(":8080", mux)
+}
@@ -0,0 +1,54 @@
+// Package fiber provides an adaptive rate limiting handler for the Fiber
+// web framework.
+package fiber
+
+import (
+	"errors"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+	"github.com/bhatpriyanka8/adaptiveratelimit/internal/limiting"
+	"github.com/bhatpriyanka8/adaptiveratelimit/internal/status"
+	"github.com/gofiber/fiber/v2"
+)
+
+// New returns a fiber.Handler that applies adaptive rate limiting to
+// incoming requests. Register it with app.Use.
+//
+// Requests that exceed the current limit are answered with status 429
+// (Too Many Requests) and a Retry-After header. Handler errors are
+// recorded using the status Fiber would respond with, so a *fiber.Error
+// below 500 is not counted as a failure while any other error is; see
+// WithErrorStatus.
+//
+// l is usually a *adaptiveratelimit.Limiter, but any Limiting will do,
+// such as a MultiLimiter or a fake from the mock package. Retry-After is
+// one second unless l reports a TimeToReset.
+func New(l adaptiveratelimit.Limiting, opts ...Option) fiber.Handler {
+	o := newOptions(opts)
+
+	return func(c *fiber.Ctx) error {
+		ok, done := limiting.AllowWithDone(l)
+		if !ok {
+			c.Set(fiber.HeaderRetryAfter, status.RetryAfter(limiting.TimeToReset(l)))
+			return c.SendStatus(fiber.StatusTooManyRequests)
+		}
+
+		err := c.Next()
+		done(status.Check(responseStatus(c, err), o.errorStatus))
+		return err
+	}
+}
+
+// responseStatus returns the status the request is answered with, given
+// the error returned by the handler chain.
+func responseStatus(c *fiber.Ctx, err error) int {
+	if err == nil {
+		return c.Response().StatusCode()
+	}
+
+	var fe *fiber.Error
+	if errors.As(err, &fe) {
+		return fe.Code
+	}
+	return fiber.StatusInternalServerError
+}
@@ -0,0 +1,89 @@
+package fiber
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+	"github.com/gofiber/fiber/v2"
+)
+
+var cfg = adaptiveratelimit.AdaptiveConfig{
+	TargetLatency: 200 * time.Millisecond,
+	MaxErrorRate:  0.05,
+	IncreaseStep:  1,
+	DecreaseStep:  2,
+	MinLimit:      1,
+	MaxLimit:      100,
+}
+
+func serve(t *testing.T, app *fiber.App) (int, string) {
+	t.Helper()
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode, resp.Header.Get(fiber.HeaderRetryAfter)
+}
+
+func TestNewRejectsOverLimit(t *testing.T) {
+	limiter := adaptiveratelimit.NewAdaptivePerSecond(1, cfg)
+	defer limiter.Stop()
+
+	app := fiber.New()
+	app.Use(New(limiter))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	tests := []struct {
+		name string
+		code int
+	}{
+		{"allowed", fiber.StatusOK},
+		{"rejected", fiber.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		if code, _ := serve(t, app); code != tt.code {
+			t.Fatalf("%s: expected status %d, got %d", tt.name, tt.code, code)
+		}
+	}
+
+	if _, retryAfter := serve(t, app); retryAfter == "" {
+		t.Fatal("expected Retry-After on rejection")
+	}
+}
+
+func TestNewRecordsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler fiber.Handler
+		opts    []Option
+		failed  bool
+	}{
+		{"ok", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }, nil, false},
+		{"written server error", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusBadGateway) }, nil, true},
+		{"fiber error below threshold", func(*fiber.Ctx) error { return fiber.ErrNotFound }, nil, false},
+		{"plain error", func(*fiber.Ctx) error { return errors.New("boom") }, nil, true},
+		{"custom threshold", func(*fiber.Ctx) error { return fiber.ErrNotFound }, []Option{WithErrorStatus(400)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := adaptiveratelimit.NewAdaptivePerSecond(10, cfg)
+			defer limiter.Stop()
+
+			app := fiber.New()
+			app.Use(New(limiter, tt.opts...))
+			app.Get("/", tt.handler)
+			serve(t, app)
+
+			if got := limiter.ErrorRate() > 0; got != tt.failed {
+				t.Fatalf("expected failure recorded = %v, got error rate %v", tt.failed, limiter.ErrorRate())
+			}
+		})
+	}
+}
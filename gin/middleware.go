@@ -0,0 +1,44 @@
+// Package gin provides adaptive rate limiting middleware for the Gin web
+// framework.
+package gin
+
+import (
+	"net/http"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+	"github.com/bhatpriyanka8/adaptiveratelimit/internal/limiting"
+	"github.com/bhatpriyanka8/adaptiveratelimit/internal/status"
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware returns a gin.HandlerFunc that applies adaptive rate
+// limiting to incoming requests.
+//
+// Requests that exceed the current limit are aborted with HTTP status 429
+// (Too Many Requests) and a Retry-After header. Responses with a status of
+// 500 or above, or handlers that attach an error to the context, are
+// recorded as errors; see WithErrorStatus.
+//
+// l is usually a *adaptiveratelimit.Limiter, but any Limiting will do,
+// such as a MultiLimiter or a fake from the mock package. Retry-After is
+// one second unless l reports a TimeToReset.
+func Middleware(l adaptiveratelimit.Limiting, opts ...Option) gin.HandlerFunc {
+	o := newOptions(opts)
+
+	return func(c *gin.Context) {
+		ok, done := limiting.AllowWithDone(l)
+		if !ok {
+			c.Header("Retry-After", status.RetryAfter(limiting.TimeToReset(l)))
+			c.AbortWithStatus(http.StatusTooManyRequests)
+			return
+		}
+
+		c.Next()
+
+		err := status.Check(c.Writer.Status(), o.errorStatus)
+		if err == nil && len(c.Errors) > 0 {
+			err = c.Errors.Last()
+		}
+		done(err)
+	}
+}
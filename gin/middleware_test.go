@@ -0,0 +1,90 @@
+package gin
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+var cfg = adaptiveratelimit.AdaptiveConfig{
+	TargetLatency: 200 * time.Millisecond,
+	MaxErrorRate:  0.05,
+	IncreaseStep:  1,
+	DecreaseStep:  2,
+	MinLimit:      1,
+	MaxLimit:      100,
+}
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func serve(r *gin.Engine) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	return rec
+}
+
+func TestMiddlewareRejectsOverLimit(t *testing.T) {
+	limiter := adaptiveratelimit.NewAdaptivePerSecond(1, cfg)
+	defer limiter.Stop()
+
+	r := gin.New()
+	r.Use(Middleware(limiter))
+	r.GET("/", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+	tests := []struct {
+		name string
+		code int
+	}{
+		{"allowed", http.StatusOK},
+		{"rejected", http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		rec := serve(r)
+		if rec.Code != tt.code {
+			t.Fatalf("%s: expected status %d, got %d", tt.name, tt.code, rec.Code)
+		}
+	}
+
+	if rec := serve(r); rec.Header().Get("Retry-After") == "" {
+		t.Fatal("expected Retry-After on rejection")
+	}
+}
+
+func TestMiddlewareRecordsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+		opts    []Option
+		failed  bool
+	}{
+		{"ok", func(c *gin.Context) { c.Status(http.StatusOK) }, nil, false},
+		{"client error", func(c *gin.Context) { c.Status(http.StatusNotFound) }, nil, false},
+		{"server error", func(c *gin.Context) { c.Status(http.StatusBadGateway) }, nil, true},
+		{"context error", func(c *gin.Context) { _ = c.Error(errors.New("boom")) }, nil, true},
+		{"custom threshold", func(c *gin.Context) { c.Status(http.StatusNotFound) }, []Option{WithErrorStatus(400)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := adaptiveratelimit.NewAdaptivePerSecond(10, cfg)
+			defer limiter.Stop()
+
+			r := gin.New()
+			r.Use(Middleware(limiter, tt.opts...))
+			r.GET("/", tt.handler)
+			serve(r)
+
+			if got := limiter.ErrorRate() > 0; got != tt.failed {
+				t.Fatalf("expected failure recorded = %v, got error rate %v", tt.failed, limiter.ErrorRate())
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package gin
+
+import "github.com/bhatpriyanka8/adaptiveratelimit/internal/status"
+
+// Option configures the behavior of Middleware.
+type Option func(*options)
+
+type options struct {
+	errorStatus int
+}
+
+func newOptions(opts []Option) options {
+	o := options{errorStatus: status.DefaultErrorStatus}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithErrorStatus sets the lowest response status code that is recorded
+// as an error for the limiter's adaptive loop. The default is 500, so any
+// 5xx response counts as a failure while 4xx responses do not.
+func WithErrorStatus(code int) Option {
+	return func(o *options) {
+		o.errorStatus = code
+	}
+}
@@ -0,0 +1,44 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryClientInterceptor returns a gRPC unary client interceptor that
+// throttles outbound RPCs with adaptive rate limiting, protecting a
+// fragile downstream.
+//
+// RPCs that exceed the current limit fail locally with ResourceExhausted,
+// without being sent; use WithRejection to change the code or message.
+// Sent RPCs record their round-trip latency, and count as errors when
+// their status code is one of the error codes; see WithErrorCodes.
+//
+// l may be any Limiting, such as a fake from the mock package.
+func UnaryClientInterceptor(l adaptiveratelimit.Limiting, opts ...Option) grpc.UnaryClientInterceptor {
+	o := newOptions(opts)
+
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply interface{},
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		callOpts ...grpc.CallOption,
+	) error {
+
+		if !l.Allow() {
+			return status.Error(o.rejectCode, o.rejectMessage)
+		}
+
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, callOpts...)
+		l.Record(time.Since(start), o.classify(err))
+
+		return err
+	}
+}
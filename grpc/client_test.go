@@ -0,0 +1,75 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// invokerReturning returns an invoker that fails with code (or succeeds
+// for codes.OK) and counts its calls.
+func invokerReturning(code codes.Code, calls *int) grpc.UnaryInvoker {
+	return func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+		*calls++
+		return status.Error(code, code.String())
+	}
+}
+
+func TestUnaryClientInterceptorRejectsLocally(t *testing.T) {
+	limiter := adaptiveratelimit.NewAdaptivePerSecond(1, cfg)
+	defer limiter.Stop()
+
+	intercept := UnaryClientInterceptor(limiter)
+
+	var calls int
+	invoker := invokerReturning(codes.OK, &calls)
+
+	if err := intercept(context.Background(), "/test.Service/Method", nil, nil, nil, invoker); err != nil {
+		t.Fatalf("expected first RPC to succeed, got %v", err)
+	}
+
+	err := intercept(context.Background(), "/test.Service/Method", nil, nil, nil, invoker)
+	if got := status.Code(err); got != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted, got %v", got)
+	}
+
+	if calls != 1 {
+		t.Fatalf("expected the rejected RPC not to be sent, got %d calls", calls)
+	}
+}
+
+func TestUnaryClientInterceptorClassifiesCodes(t *testing.T) {
+	tests := []struct {
+		name   string
+		code   codes.Code
+		opts   []Option
+		failed bool
+	}{
+		{"ok", codes.OK, nil, false},
+		{"invalid argument", codes.InvalidArgument, nil, false},
+		{"not found", codes.NotFound, nil, false},
+		{"unavailable", codes.Unavailable, nil, true},
+		{"deadline exceeded", codes.DeadlineExceeded, nil, true},
+		{"custom codes include", codes.ResourceExhausted, []Option{WithErrorCodes(codes.ResourceExhausted)}, true},
+		{"custom codes exclude", codes.Unavailable, []Option{WithErrorCodes(codes.ResourceExhausted)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := adaptiveratelimit.NewAdaptivePerSecond(10, cfg)
+			defer limiter.Stop()
+
+			var calls int
+			intercept := UnaryClientInterceptor(limiter, tt.opts...)
+			intercept(context.Background(), "/test.Service/Method", nil, nil, nil, invokerReturning(tt.code, &calls))
+
+			if got := limiter.ErrorRate() > 0; got != tt.failed {
+				t.Fatalf("expected failure recorded = %v, got error rate %v", tt.failed, limiter.ErrorRate())
+			}
+		})
+	}
+}
@@ -2,9 +2,9 @@ package grpc
 
 import (
 	"context"
-	"time"
 
 	"github.com/bhatpriyanka8/adaptiveratelimit"
+	"github.com/bhatpriyanka8/adaptiveratelimit/internal/limiting"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/status"
 )
@@ -13,8 +13,15 @@ import (
 // applies adaptive rate limiting to incoming RPCs.
 //
 // RPCs that exceed the current limit are rejected with a
-// ResourceExhausted error.
-func UnaryServerInterceptor(l *adaptiveratelimit.Limiter) grpc.UnaryServerInterceptor {
+// ResourceExhausted error; use WithRejection to change the code or
+// message. Handler errors are recorded as failures only if their status
+// code indicates a server fault; see WithErrorClassifier.
+//
+// l is usually a *adaptiveratelimit.Limiter, but any Limiting will do,
+// such as a fake from the mock package.
+func UnaryServerInterceptor(l adaptiveratelimit.Limiting, opts ...Option) grpc.UnaryServerInterceptor {
+	o := newOptions(opts)
+
 	return func(
 		ctx context.Context,
 		req interface{},
@@ -22,13 +29,13 @@ func UnaryServerInterceptor(l *adaptiveratelimit.Limiter) grpc.UnaryServerInterc
 		handler grpc.UnaryHandler,
 	) (interface{}, error) {
 
-		if !l.Allow() {
-			return nil, status.Error(429, "rate limited")
+		ok, done := limiting.AllowWithDone(l)
+		if !ok {
+			return nil, status.Error(o.rejectCode, o.rejectMessage)
 		}
 
-		start := time.Now()
 		resp, err := handler(ctx, req)
-		l.Record(time.Since(start), err)
+		done(o.classify(err))
 
 		return resp, err
 	}
@@ -0,0 +1,131 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+	"github.com/bhatpriyanka8/adaptiveratelimit/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var cfg = adaptiveratelimit.AdaptiveConfig{
+	TargetLatency: 200 * time.Millisecond,
+	MaxErrorRate:  0.05,
+	IncreaseStep:  1,
+	DecreaseStep:  2,
+	MinLimit:      1,
+	MaxLimit:      100,
+}
+
+func okHandler(context.Context, interface{}) (interface{}, error) {
+	return "ok", nil
+}
+
+func TestUnaryServerInterceptorRejectsWithResourceExhausted(t *testing.T) {
+	limiter := adaptiveratelimit.NewAdaptivePerSecond(1, cfg)
+	defer limiter.Stop()
+
+	intercept := UnaryServerInterceptor(limiter)
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}
+
+	if _, err := intercept(context.Background(), nil, info, okHandler); err != nil {
+		t.Fatalf("expected first RPC to succeed, got %v", err)
+	}
+
+	_, err := intercept(context.Background(), nil, info, okHandler)
+	if got := status.Code(err); got != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted, got %v", got)
+	}
+}
+
+func TestUnaryServerInterceptorAcceptsFakeLimiter(t *testing.T) {
+	limiter := mock.AllowThenReject(1)
+
+	intercept := UnaryServerInterceptor(limiter)
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}
+
+	if _, err := intercept(context.Background(), nil, info, okHandler); err != nil {
+		t.Fatalf("expected first RPC to succeed, got %v", err)
+	}
+	_, err := intercept(context.Background(), nil, info, okHandler)
+	if got := status.Code(err); got != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted, got %v", got)
+	}
+	if got := limiter.Outcomes(); len(got) != 1 || got[0].Err != nil {
+		t.Fatalf("expected the admitted RPC to be recorded once, got %+v", got)
+	}
+}
+
+func TestUnaryServerInterceptorCustomRejection(t *testing.T) {
+	limiter := adaptiveratelimit.NewAdaptivePerSecond(1, cfg)
+	defer limiter.Stop()
+
+	intercept := UnaryServerInterceptor(limiter, WithRejection(codes.Unavailable, "shedding load"))
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}
+
+	intercept(context.Background(), nil, info, okHandler)
+
+	_, err := intercept(context.Background(), nil, info, okHandler)
+	st, _ := status.FromError(err)
+	if st.Code() != codes.Unavailable || st.Message() != "shedding load" {
+		t.Fatalf("expected Unavailable \"shedding load\", got %v %q", st.Code(), st.Message())
+	}
+}
+
+func TestUnaryServerInterceptorClassifiesErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		code   codes.Code
+		opts   []Option
+		failed bool
+	}{
+		{"invalid argument", codes.InvalidArgument, nil, false},
+		{"not found", codes.NotFound, nil, false},
+		{"unavailable", codes.Unavailable, nil, true},
+		{"internal", codes.Internal, nil, true},
+		{"custom classifier", codes.InvalidArgument, []Option{WithErrorClassifier(func(c codes.Code) bool { return c != codes.OK })}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := adaptiveratelimit.NewAdaptivePerSecond(10, cfg)
+			defer limiter.Stop()
+
+			intercept := UnaryServerInterceptor(limiter, tt.opts...)
+			info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}
+			handler := func(context.Context, interface{}) (interface{}, error) {
+				return nil, status.Error(tt.code, "failed")
+			}
+
+			if _, err := intercept(context.Background(), nil, info, handler); status.Code(err) != tt.code {
+				t.Fatalf("expected handler error to be returned unchanged, got %v", err)
+			}
+
+			if got := limiter.ErrorRate() > 0; got != tt.failed {
+				t.Fatalf("expected failure recorded = %v, got error rate %v", tt.failed, limiter.ErrorRate())
+			}
+		})
+	}
+}
+
+func TestUnaryServerInterceptorNeverRejectsInShadowMode(t *testing.T) {
+	c := cfg
+	c.Shadow = true
+	limiter := adaptiveratelimit.NewAdaptivePerSecond(1, c)
+	defer limiter.Stop()
+
+	intercept := UnaryServerInterceptor(limiter)
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}
+	for i := 0; i < 3; i++ {
+		if _, err := intercept(context.Background(), nil, info, okHandler); err != nil {
+			t.Fatalf("RPC %d: expected shadow mode never to reject, got %v", i, err)
+		}
+	}
+	if got := limiter.Snapshot().ShadowRejected; got != 2 {
+		t.Fatalf("expected 2 would-be rejections, got %d", got)
+	}
+}
@@ -0,0 +1,104 @@
+package grpc
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Option configures the behavior of the interceptors.
+type Option func(*options)
+
+type options struct {
+	rejectCode        codes.Code
+	rejectMessage     string
+	perMessage        bool
+	perMessageLatency bool
+	isError           func(codes.Code) bool
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		rejectCode:    codes.ResourceExhausted,
+		rejectMessage: "rate limited",
+		isError:       isServerFault,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithRejection overrides the status code and message returned for
+// rejected RPCs. The default is codes.ResourceExhausted with the message
+// "rate limited".
+func WithRejection(code codes.Code, msg string) Option {
+	return func(o *options) {
+		o.rejectCode = code
+		o.rejectMessage = msg
+	}
+}
+
+// WithPerMessageLimit makes StreamServerInterceptor count every message
+// received on a stream against the limit, in addition to the stream open.
+// In concurrency mode each message holds a slot only until it has been
+// received. It has no effect on unary RPCs.
+func WithPerMessageLimit() Option {
+	return func(o *options) {
+		o.perMessage = true
+	}
+}
+
+// WithPerMessageLatency makes StreamServerInterceptor record the latency
+// of each message sent or received on a stream, rather than the duration
+// of the whole stream, which for long-lived streams says little about the
+// cost of serving them. RecvMsg latency includes the time spent waiting
+// for the client's next message. It has no effect on unary RPCs.
+func WithPerMessageLatency() Option {
+	return func(o *options) {
+		o.perMessageLatency = true
+	}
+}
+
+// WithErrorClassifier sets the predicate deciding which status codes the
+// interceptors record as errors for the limiter's adaptive loop. Codes for
+// which isError returns false, including OK, are recorded as successes.
+//
+// By default Unknown, DeadlineExceeded, Internal, Unavailable and DataLoss
+// count as errors, while caller mistakes such as InvalidArgument or
+// NotFound do not, so a flood of bad requests cannot collapse the limit.
+func WithErrorClassifier(isError func(codes.Code) bool) Option {
+	return func(o *options) {
+		o.isError = isError
+	}
+}
+
+// WithErrorCodes is shorthand for WithErrorClassifier with a predicate
+// that matches exactly the given codes.
+func WithErrorCodes(errorCodes ...codes.Code) Option {
+	set := make(map[codes.Code]bool, len(errorCodes))
+	for _, c := range errorCodes {
+		set[c] = true
+	}
+	return WithErrorClassifier(func(c codes.Code) bool { return set[c] })
+}
+
+// isServerFault reports whether c indicates that the server, rather than
+// the caller, failed: the codes that map to 5xx HTTP statuses, excluding
+// Unimplemented.
+func isServerFault(c codes.Code) bool {
+	switch c {
+	case codes.Unknown, codes.DeadlineExceeded, codes.Internal, codes.Unavailable, codes.DataLoss:
+		return true
+	default:
+		return false
+	}
+}
+
+// classify returns err if its status code counts as an error, and nil
+// otherwise.
+func (o *options) classify(err error) error {
+	if err != nil && o.isError(status.Code(err)) {
+		return err
+	}
+	return nil
+}
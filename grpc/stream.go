@@ -0,0 +1,140 @@
+package grpc
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+	"github.com/bhatpriyanka8/adaptiveratelimit/internal/limiting"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// StreamServerInterceptor returns a gRPC stream interceptor that applies
+// adaptive rate limiting to incoming streaming RPCs.
+//
+// By default only opening a stream counts against the limit: Allow is
+// called once when the stream starts, and streams that exceed the current
+// limit are rejected with ResourceExhausted. When the handler returns, the
+// total stream duration and final error are recorded, with the error
+// classified as for UnaryServerInterceptor.
+//
+// With WithPerMessageLimit, every message received from the client also
+// counts against the limit, and RecvMsg fails with the rejection status
+// once the limit is exceeded.
+//
+// With WithPerMessageLatency, the latency of every SendMsg and RecvMsg is
+// recorded instead of the whole-stream duration, and the final error is
+// recorded without a latency when the handler returns.
+//
+// l is usually a *adaptiveratelimit.Limiter, but any Limiting will do.
+// WithPerMessageLatency needs its RecordBatch and RecordResult methods;
+// with other implementations every message outcome is passed to Record,
+// and the stream's end is recorded as without the option.
+func StreamServerInterceptor(l adaptiveratelimit.Limiting, opts ...Option) grpc.StreamServerInterceptor {
+	o := newOptions(opts)
+
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		_ *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+
+		allow := limiting.AllowWithDone
+		if o.perMessageLatency {
+			allow = limiting.AllowWithResult
+		}
+		ok, done := allow(l)
+		if !ok {
+			return status.Error(o.rejectCode, o.rejectMessage)
+		}
+
+		if o.perMessage || o.perMessageLatency {
+			ss = &limitedStream{ServerStream: ss, limiter: l, opts: &o}
+		}
+
+		err := handler(srv, ss)
+		done(o.classify(err))
+
+		return err
+	}
+}
+
+// limitedStream charges each received message against the limiter and
+// times each message, as enabled by its options.
+type limitedStream struct {
+	grpc.ServerStream
+	limiter adaptiveratelimit.Limiting
+	opts    *options
+}
+
+// batchLimiter is implemented by limiters that record samples without
+// completing requests, such as *adaptiveratelimit.Limiter.
+type batchLimiter interface {
+	RecordBatch(samples []adaptiveratelimit.Sample)
+}
+
+// SendMsg records the send latency if per-message latency is enabled.
+func (s *limitedStream) SendMsg(m interface{}) error {
+	if !s.opts.perMessageLatency {
+		return s.ServerStream.SendMsg(m)
+	}
+
+	start := time.Now()
+	err := s.ServerStream.SendMsg(m)
+	s.record(time.Since(start), err)
+	return err
+}
+
+// RecvMsg rejects the message if the limiter is over its limit, and
+// records the receive latency if per-message latency is enabled.
+//
+// A message admitted under WithPerMessageLimit gives its unit back once
+// it has been received, so that in concurrency mode messages hold a slot
+// only while they are being received rather than leaking one each. Its
+// outcome is then recorded with the unit: with the receive latency under
+// WithPerMessageLatency, and as a result without latency otherwise. The
+// read that ends the stream with io.EOF gives its unit back as a success.
+func (s *limitedStream) RecvMsg(m interface{}) error {
+	done := func(error) {}
+	if s.opts.perMessage {
+		allow := limiting.AllowWithResult
+		if s.opts.perMessageLatency {
+			allow = limiting.AllowWithDone
+		}
+		var ok bool
+		if ok, done = allow(s.limiter); !ok {
+			return status.Error(s.opts.rejectCode, s.opts.rejectMessage)
+		}
+	}
+	if !s.opts.perMessage && !s.opts.perMessageLatency {
+		return s.ServerStream.RecvMsg(m)
+	}
+
+	start := time.Now()
+	err := s.ServerStream.RecvMsg(m)
+	switch {
+	case errors.Is(err, io.EOF):
+		// EOF marks the end of the client's messages, not a message.
+		done(nil)
+	case s.opts.perMessage:
+		done(s.opts.classify(err))
+	default:
+		s.record(time.Since(start), err)
+	}
+	return err
+}
+
+// record feeds one message's outcome to the limiter. It uses RecordBatch
+// where available so that messages never free the stream's in-flight
+// slot in concurrency mode.
+func (s *limitedStream) record(latency time.Duration, err error) {
+	err = s.opts.classify(err)
+	if bl, ok := s.limiter.(batchLimiter); ok {
+		bl.RecordBatch([]adaptiveratelimit.Sample{{Latency: latency, Err: err}})
+		return
+	}
+	s.limiter.Record(latency, err)
+}
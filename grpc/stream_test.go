@@ -0,0 +1,259 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// fakeStream is a minimal grpc.ServerStream for exercising interceptors.
+type fakeStream struct {
+	recv int
+}
+
+func (s *fakeStream) SetHeader(metadata.MD) error  { return nil }
+func (s *fakeStream) SendHeader(metadata.MD) error { return nil }
+func (s *fakeStream) SetTrailer(metadata.MD)       {}
+func (s *fakeStream) Context() context.Context     { return context.Background() }
+func (s *fakeStream) SendMsg(interface{}) error    { return nil }
+func (s *fakeStream) RecvMsg(interface{}) error {
+	s.recv++
+	return nil
+}
+
+var streamInfo = &grpc.StreamServerInfo{FullMethod: "/test.Service/Stream"}
+
+func TestStreamServerInterceptorRejectsOverLimit(t *testing.T) {
+	limiter := adaptiveratelimit.NewAdaptivePerSecond(1, cfg)
+	defer limiter.Stop()
+
+	intercept := StreamServerInterceptor(limiter)
+	handler := func(interface{}, grpc.ServerStream) error { return nil }
+
+	if err := intercept(nil, &fakeStream{}, streamInfo, handler); err != nil {
+		t.Fatalf("expected first stream to be accepted, got %v", err)
+	}
+
+	err := intercept(nil, &fakeStream{}, streamInfo, handler)
+	if got := status.Code(err); got != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted, got %v", got)
+	}
+}
+
+func TestStreamServerInterceptorRecordsFinalError(t *testing.T) {
+	limiter := adaptiveratelimit.NewAdaptivePerSecond(10, cfg)
+	defer limiter.Stop()
+
+	intercept := StreamServerInterceptor(limiter)
+	handler := func(interface{}, grpc.ServerStream) error { return errors.New("stream failed") }
+
+	intercept(nil, &fakeStream{}, streamInfo, handler)
+
+	if limiter.ErrorRate() <= 0 {
+		t.Fatal("expected stream error to be recorded")
+	}
+}
+
+func TestStreamServerInterceptorMessageCounting(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		wantRecv int
+		wantCode codes.Code
+	}{
+		{"stream open only", nil, 5, codes.OK},
+		{"per message", []Option{WithPerMessageLimit()}, 2, codes.ResourceExhausted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := adaptiveratelimit.NewAdaptivePerSecond(3, cfg)
+			defer limiter.Stop()
+
+			stream := &fakeStream{}
+			handler := func(_ interface{}, ss grpc.ServerStream) error {
+				for i := 0; i < 5; i++ {
+					if err := ss.RecvMsg(nil); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+
+			err := StreamServerInterceptor(limiter, tt.opts...)(nil, stream, streamInfo, handler)
+
+			if got := status.Code(err); got != tt.wantCode {
+				t.Fatalf("expected code %v, got %v", tt.wantCode, got)
+			}
+
+			if stream.recv != tt.wantRecv {
+				t.Fatalf("expected %d messages received, got %d", tt.wantRecv, stream.recv)
+			}
+		})
+	}
+}
+
+// slowStream is a fakeStream whose messages each take delay.
+type slowStream struct {
+	fakeStream
+	delay time.Duration
+	msgs  int
+}
+
+func (s *slowStream) SendMsg(interface{}) error {
+	time.Sleep(s.delay)
+	return nil
+}
+
+func (s *slowStream) RecvMsg(interface{}) error {
+	if s.recv == s.msgs {
+		return io.EOF
+	}
+	s.recv++
+	time.Sleep(s.delay)
+	return nil
+}
+
+func TestStreamServerInterceptorLatencyRecording(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantMin time.Duration
+		wantMax time.Duration
+	}{
+		{"whole stream", nil, 100 * time.Millisecond, time.Second},
+		{"per message", []Option{WithPerMessageLatency()}, 5 * time.Millisecond, 50 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := adaptiveratelimit.NewAdaptivePerSecond(10, cfg)
+			defer limiter.Stop()
+
+			stream := &slowStream{delay: 10 * time.Millisecond, msgs: 5}
+			handler := func(_ interface{}, ss grpc.ServerStream) error {
+				for {
+					if err := ss.RecvMsg(nil); err != nil {
+						if errors.Is(err, io.EOF) {
+							return nil
+						}
+						return err
+					}
+					if err := ss.SendMsg(nil); err != nil {
+						return err
+					}
+				}
+			}
+
+			if err := StreamServerInterceptor(limiter, tt.opts...)(nil, stream, streamInfo, handler); err != nil {
+				t.Fatalf("expected the stream to succeed, got %v", err)
+			}
+
+			if got := limiter.AverageLatency(); got < tt.wantMin || got > tt.wantMax {
+				t.Fatalf("expected recorded latency in [%v, %v], got %v", tt.wantMin, tt.wantMax, got)
+			}
+			if got := limiter.ErrorRate(); got != 0 {
+				t.Fatalf("expected EOF not to count as an error, got error rate %v", got)
+			}
+		})
+	}
+}
+
+func TestStreamServerInterceptorPerMessageLatencyReleasesSlotOnce(t *testing.T) {
+	limiter := adaptiveratelimit.NewAdaptiveConcurrency(1, cfg)
+	defer limiter.Stop()
+
+	intercept := StreamServerInterceptor(limiter, WithPerMessageLatency())
+	handler := func(_ interface{}, ss grpc.ServerStream) error {
+		for i := 0; i < 3; i++ {
+			if err := ss.SendMsg(nil); err != nil {
+				return err
+			}
+			if got := limiter.InFlight(); got != 1 {
+				t.Fatalf("expected messages not to free the stream's slot, got %d in flight", got)
+			}
+		}
+		return nil
+	}
+
+	if err := intercept(nil, &fakeStream{}, streamInfo, handler); err != nil {
+		t.Fatalf("expected the stream to succeed, got %v", err)
+	}
+	if got := limiter.InFlight(); got != 0 {
+		t.Fatalf("expected the slot to be released when the stream ends, got %d in flight", got)
+	}
+}
+
+func TestStreamServerInterceptorPerMessageLatencyReleasesHold(t *testing.T) {
+	c := cfg
+	c.Window = 10 * time.Millisecond
+	c.MaxHoldDuration = 20 * time.Millisecond
+	limiter := adaptiveratelimit.NewAdaptiveConcurrency(2, c)
+	defer limiter.Stop()
+
+	intercept := StreamServerInterceptor(limiter, WithPerMessageLatency())
+	handler := func(_ interface{}, ss grpc.ServerStream) error {
+		return ss.SendMsg(nil)
+	}
+	if err := intercept(nil, &fakeStream{}, streamInfo, handler); err != nil {
+		t.Fatalf("expected the stream to succeed, got %v", err)
+	}
+
+	if !limiter.Allow() {
+		t.Fatal("expected a slot to be free after the stream ended")
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	if got := limiter.InFlight(); got != 1 {
+		t.Fatalf("expected the finished stream not to be reclaimed, got %d in flight", got)
+	}
+	if got := limiter.ErrorRate(); got != 0 {
+		t.Fatalf("expected no expired hold to be recorded, got error rate %v", got)
+	}
+}
+
+func TestStreamServerInterceptorPerMessageLimitReleasesSlots(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"per message limit", []Option{WithPerMessageLimit()}},
+		{"with per message latency", []Option{WithPerMessageLimit(), WithPerMessageLatency()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := adaptiveratelimit.NewAdaptiveConcurrency(3, cfg)
+			defer limiter.Stop()
+
+			stream := &slowStream{msgs: 5}
+			handler := func(_ interface{}, ss grpc.ServerStream) error {
+				for {
+					if err := ss.RecvMsg(nil); errors.Is(err, io.EOF) {
+						return nil
+					} else if err != nil {
+						return err
+					}
+				}
+			}
+
+			if err := StreamServerInterceptor(limiter, tt.opts...)(nil, stream, streamInfo, handler); err != nil {
+				t.Fatalf("expected every message to fit beside the stream, got %v", err)
+			}
+			if stream.recv != 5 {
+				t.Fatalf("expected 5 messages received, got %d", stream.recv)
+			}
+			if got := limiter.Remaining(); got != 3 {
+				t.Fatalf("expected every slot to be free after the stream, got %d remaining", got)
+			}
+		})
+	}
+}
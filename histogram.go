@@ -0,0 +1,74 @@
+package adaptiveratelimit
+
+import (
+	"math"
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+// Bucket is one bucket of a latency histogram.
+type Bucket struct {
+	// UpperBound is the inclusive upper bound of the bucket. The last
+	// bucket, which counts latencies above every configured bound, has
+	// an UpperBound of math.MaxInt64.
+	UpperBound time.Duration
+
+	// Count is the number of samples in this bucket alone; counts are not
+	// cumulative.
+	Count uint64
+}
+
+// latencyHistogram counts recorded latencies into fixed buckets. It is
+// safe for concurrent use without locking.
+type latencyHistogram struct {
+	bounds []time.Duration
+	counts []atomic.Uint64
+}
+
+// newLatencyHistogram returns a histogram with the given upper bounds,
+// sorted and deduplicated, plus an overflow bucket.
+func newLatencyHistogram(bounds []time.Duration) *latencyHistogram {
+	bounds = slices.Clone(bounds)
+	slices.Sort(bounds)
+	bounds = slices.Compact(bounds)
+
+	return &latencyHistogram{
+		bounds: bounds,
+		counts: make([]atomic.Uint64, len(bounds)+1),
+	}
+}
+
+// observe counts one sample of latency d.
+func (h *latencyHistogram) observe(d time.Duration) {
+	i, _ := slices.BinarySearch(h.bounds, d)
+	h.counts[i].Add(1)
+}
+
+// snapshot returns the current bucket counts.
+func (h *latencyHistogram) snapshot() []Bucket {
+	buckets := make([]Bucket, len(h.counts))
+	for i := range h.counts {
+		bound := time.Duration(math.MaxInt64)
+		if i < len(h.bounds) {
+			bound = h.bounds[i]
+		}
+		buckets[i] = Bucket{UpperBound: bound, Count: h.counts[i].Load()}
+	}
+	return buckets
+}
+
+// HistogramSnapshot returns the number of recorded latencies in each
+// bucket configured with WithLatencyHistogram, in increasing order of
+// UpperBound, or nil if no histogram was configured.
+//
+// Buckets are read one at a time without the limiter's lock, so a
+// snapshot taken while samples are being recorded may be off by the
+// samples in flight. Weighted samples are counted once. Like the other
+// lifetime counters, the counts are kept across Reset.
+func (l *Limiter) HistogramSnapshot() []Bucket {
+	if l.histogram == nil {
+		return nil
+	}
+	return l.histogram.snapshot()
+}
@@ -0,0 +1,53 @@
+package adaptiveratelimit
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestHistogramSamplesLandInBuckets(t *testing.T) {
+	limiter := NewAdaptivePerSecond(10, cfg, WithClock(newFakeClock()),
+		WithLatencyHistogram(100*time.Millisecond, 10*time.Millisecond, 1*time.Second))
+	defer limiter.Stop()
+
+	for _, d := range []time.Duration{
+		5 * time.Millisecond,
+		10 * time.Millisecond,
+		50 * time.Millisecond,
+		500 * time.Millisecond,
+		900 * time.Millisecond,
+		2 * time.Second,
+	} {
+		limiter.Record(d, nil)
+	}
+	limiter.RecordBatch([]Sample{{Latency: 20 * time.Millisecond}})
+
+	want := []Bucket{
+		{UpperBound: 10 * time.Millisecond, Count: 2},
+		{UpperBound: 100 * time.Millisecond, Count: 2},
+		{UpperBound: time.Second, Count: 2},
+		{UpperBound: time.Duration(math.MaxInt64), Count: 1},
+	}
+
+	got := limiter.HistogramSnapshot()
+	if len(got) != len(want) {
+		t.Fatalf("expected %d buckets, got %v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("bucket %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestHistogramDisabledByDefault(t *testing.T) {
+	limiter := NewAdaptivePerSecond(10, cfg, WithClock(newFakeClock()))
+	defer limiter.Stop()
+
+	limiter.Record(time.Millisecond, nil)
+
+	if got := limiter.HistogramSnapshot(); got != nil {
+		t.Fatalf("expected no histogram, got %v", got)
+	}
+}
@@ -0,0 +1,163 @@
+package adaptiveratelimit
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOnLimitChangeFiresOnDecrease(t *testing.T) {
+	type change struct {
+		old, new int
+		reason   string
+	}
+
+	var (
+		mu      sync.Mutex
+		changes []change
+	)
+
+	c := cfg
+	c.OnLimitChange = func(old, new int, reason string) {
+		mu.Lock()
+		changes = append(changes, change{old, new, reason})
+		mu.Unlock()
+	}
+
+	limiter := NewAdaptivePerSecond(10, c)
+	defer limiter.Stop()
+
+	for i := 0; i < 20; i++ {
+		limiter.Record(500*time.Millisecond, nil)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(changes) == 0 {
+		t.Fatal("expected OnLimitChange to fire")
+	}
+
+	got := changes[0]
+	if got.old != 10 || got.new != 8 || got.reason != ReasonHighLatency {
+		t.Fatalf("expected change 10 -> 8 (%s), got %d -> %d (%s)",
+			ReasonHighLatency, got.old, got.new, got.reason)
+	}
+}
+
+func TestOnRejectFiresWhenRejected(t *testing.T) {
+	var rejects int
+
+	c := cfg
+	c.OnReject = func() { rejects++ }
+
+	limiter := NewAdaptivePerSecond(1, c)
+	defer limiter.Stop()
+
+	limiter.Allow()
+	limiter.Allow()
+	limiter.AllowN(5)
+
+	if rejects != 2 {
+		t.Fatalf("expected OnReject to fire twice, got %d", rejects)
+	}
+}
+
+func TestUpdateConfigReplacesRejectHooks(t *testing.T) {
+	var first, second int
+
+	c := cfg
+	c.OnReject = func() { first++ }
+
+	limiter := NewAdaptivePerSecond(1, c)
+	defer limiter.Stop()
+
+	limiter.Allow()
+	limiter.Allow()
+
+	c.OnReject = func() { second++ }
+	if err := limiter.UpdateConfig(c); err != nil {
+		t.Fatalf("UpdateConfig: %v", err)
+	}
+	limiter.Allow()
+
+	c.OnReject = nil
+	c.Shadow = true
+	if err := limiter.UpdateConfig(c); err != nil {
+		t.Fatalf("UpdateConfig: %v", err)
+	}
+	if !limiter.Allow() {
+		t.Fatal("expected shadow mode to admit a saturated request")
+	}
+
+	if first != 1 || second != 1 {
+		t.Fatalf("expected each OnReject to fire once, got %d and %d", first, second)
+	}
+}
+
+func TestCallbacksMayReenterLimiter(t *testing.T) {
+	done := make(chan struct{})
+
+	var limiter *Limiter
+	c := cfg
+	c.OnLimitChange = func(int, int, string) {
+		_ = limiter.CurrentLimit()
+		select {
+		case <-done:
+		default:
+			close(done)
+		}
+	}
+	c.OnReject = func() { _ = limiter.CurrentLimit() }
+
+	limiter = NewAdaptivePerSecond(1, c)
+	defer limiter.Stop()
+
+	limiter.Allow()
+	limiter.Allow()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected OnLimitChange to fire without deadlocking")
+	}
+}
+
+func TestOnSaturationReportsHeavyRejection(t *testing.T) {
+	var (
+		limiter   *Limiter
+		fractions []float64
+	)
+	c := cfg
+	c.MinSamples = 1000 // keep the limit fixed
+	c.SaturationThreshold = 0.5
+	c.OnSaturation = func(f float64) {
+		// Calling back into the limiter must not deadlock.
+		_ = limiter.Snapshot()
+		fractions = append(fractions, f)
+	}
+
+	clock := newFakeClock()
+	limiter = NewAdaptivePerSecond(2, c, WithClock(clock))
+	defer limiter.Stop()
+
+	for range 10 {
+		limiter.Allow()
+	}
+	clock.Advance(time.Second)
+	if len(fractions) != 1 || fractions[0] != 0.8 {
+		t.Fatalf("expected one call with 8 of 10 rejected, got %v", fractions)
+	}
+
+	// One rejection in three stays below the threshold.
+	for range 3 {
+		limiter.Allow()
+	}
+	clock.Advance(time.Second)
+	clock.Advance(time.Second) // an idle window
+	if len(fractions) != 1 {
+		t.Fatalf("expected no call below the threshold, got %v", fractions)
+	}
+}
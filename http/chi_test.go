@@ -0,0 +1,43 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestMiddlewareInChiRouteGroup(t *testing.T) {
+	limiter := adaptiveratelimit.NewAdaptivePerSecond(1, cfg)
+	defer limiter.Stop()
+
+	r := chi.NewRouter()
+	r.Get("/public", okHandler)
+	r.Group(func(r chi.Router) {
+		r.Use(Middleware(limiter))
+		r.Get("/api", okHandler)
+	})
+
+	get := func(path string) int {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+
+	tests := []struct {
+		path string
+		code int
+	}{
+		{"/api", http.StatusOK},
+		{"/api", http.StatusTooManyRequests},
+		{"/public", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		if code := get(tt.path); code != tt.code {
+			t.Fatalf("GET %s: expected status %d, got %d", tt.path, tt.code, code)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package http
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+)
+
+// MiddlewareByIP returns an HTTP middleware that admits each request
+// through the limiter of its client IP in keyed, so that a single abusive
+// client exhausts only its own budget. Each limiter adapts to the latency
+// and errors of its own client's requests and behaves as with Middleware,
+// and opts apply to all of them.
+//
+// The caller owns keyed and must stop it once the middleware is no longer
+// used. Build it with adaptiveratelimit.NewKeyedPerSecond, or
+// NewKeyedLimiter for other limiter kinds, with an idle timeout so that
+// the limiters of clients that stop sending requests are evicted and
+// memory is bounded by the number of recently active clients; a client
+// returning after eviction starts over at the initial limit.
+// WithGlobalLimit additionally applies one limiter shared by every
+// client, so that many well-behaved clients together still cannot exceed
+// the total budget.
+//
+// The client IP is the host of r.RemoteAddr. The X-Forwarded-For and
+// X-Real-IP headers are ignored unless the request came from a proxy
+// listed with WithTrustedProxies; see ClientIP. Trusting them for any
+// other peer would let every client pick its own key, and with it a
+// fresh budget, by sending a forged header.
+func MiddlewareByIP(keyed *adaptiveratelimit.KeyedLimiter, opts ...Option) func(http.Handler) http.Handler {
+	o := newOptions(opts)
+	clientIP := ClientIP(o.trustedProxies...)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limiter := keyed.Get(clientIP(r))
+			var l adaptiveratelimit.Limiting = limiter
+			if o.global != nil {
+				l = adaptiveratelimit.NewMultiLimiter(limiter, o.global)
+			}
+			serveLimited(l, &o, next, w, r)
+		})
+	}
+}
+
+// ClientIP returns a function that extracts the IP address of the client
+// that sent a request, for use as a rate limiting key.
+//
+// It is the host of r.RemoteAddr unless that peer is one of the trusted
+// proxies, given as CIDR prefixes such as 10.0.0.0/8 or, for a single
+// address, 192.0.2.1/32. For a trusted peer the client is the rightmost
+// address in X-Forwarded-For that is not itself a trusted proxy, which is
+// the last hop no trusted proxy can have forged; addresses further left
+// are supplied by the client and never used. If the request carries no
+// X-Forwarded-For, a valid X-Real-IP is used instead. IPv4-mapped IPv6
+// addresses are reported as IPv4. A RemoteAddr that cannot be parsed is
+// returned as is.
+//
+// With no trusted proxies, the forwarding headers are never consulted.
+func ClientIP(trusted ...netip.Prefix) func(*http.Request) string {
+	trusted = append([]netip.Prefix(nil), trusted...)
+	isTrusted := func(addr netip.Addr) bool {
+		for _, p := range trusted {
+			if p.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(r *http.Request) string {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		peer, err := netip.ParseAddr(host)
+		if err != nil {
+			return host
+		}
+		peer = peer.Unmap()
+		if !isTrusted(peer) {
+			return peer.String()
+		}
+
+		if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+			hops := strings.Split(strings.Join(forwarded, ","), ",")
+			client := peer
+			for i := len(hops) - 1; i >= 0 && isTrusted(client); i-- {
+				addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+				if err != nil {
+					break
+				}
+				client = addr.Unmap()
+			}
+			return client.String()
+		}
+		if addr, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+			return addr.Unmap().String()
+		}
+		return peer.String()
+	}
+}
@@ -0,0 +1,169 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+)
+
+func TestClientIP(t *testing.T) {
+	proxies := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     http.Header
+		trusted    []netip.Prefix
+		want       string
+	}{
+		{"remote address", "192.0.2.1:1234", nil, nil, "192.0.2.1"},
+		{"ipv6 remote address", "[2001:db8::1]:1234", nil, nil, "2001:db8::1"},
+		{"ipv4-mapped remote address", "[::ffff:192.0.2.1]:1234", nil, nil, "192.0.2.1"},
+		{"unparsable remote address", "pipe", nil, nil, "pipe"},
+		{"untrusted forwarded for", "192.0.2.1:1234", http.Header{"X-Forwarded-For": {"203.0.113.9"}}, nil, "192.0.2.1"},
+		{"untrusted real ip", "192.0.2.1:1234", http.Header{"X-Real-Ip": {"203.0.113.9"}}, proxies, "192.0.2.1"},
+		{"trusted forwarded for", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"203.0.113.9"}}, proxies, "203.0.113.9"},
+		{"spoofed forwarded for", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"198.51.100.7, 203.0.113.9"}}, proxies, "203.0.113.9"},
+		{"proxy chain", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"203.0.113.9, 10.0.0.2"}}, proxies, "203.0.113.9"},
+		{"repeated headers", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"203.0.113.9", "10.0.0.2"}}, proxies, "203.0.113.9"},
+		{"invalid hop", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"203.0.113.9, bogus, 10.0.0.2"}}, proxies, "10.0.0.2"},
+		{"trusted real ip", "10.0.0.1:1234", http.Header{"X-Real-Ip": {"203.0.113.9"}}, proxies, "203.0.113.9"},
+		{"invalid real ip", "10.0.0.1:1234", http.Header{"X-Real-Ip": {"bogus"}}, proxies, "10.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = tt.remoteAddr
+		for name, values := range tt.header {
+			r.Header[name] = values
+		}
+		if got := ClientIP(tt.trusted...)(r); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func serveFrom(h http.Handler, remoteAddr string) int {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = remoteAddr
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	return rec.Code
+}
+
+// newKeyed returns a per-IP KeyedLimiter stopped when t ends.
+func newKeyed(t *testing.T, initial int, c adaptiveratelimit.AdaptiveConfig) *adaptiveratelimit.KeyedLimiter {
+	t.Helper()
+
+	keyed := adaptiveratelimit.NewKeyedPerSecond(initial, c, time.Minute)
+	t.Cleanup(keyed.Stop)
+	return keyed
+}
+
+func TestMiddlewareByIPLimitsClientsIndependently(t *testing.T) {
+	h := MiddlewareByIP(newKeyed(t, 1, cfg))(okHandler)
+
+	if code := serveFrom(h, "192.0.2.1:1234"); code != http.StatusOK {
+		t.Fatalf("expected the first client's request to pass, got %d", code)
+	}
+	if code := serveFrom(h, "192.0.2.1:5678"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected the first client's second request to be limited, got %d", code)
+	}
+	if code := serveFrom(h, "192.0.2.2:1234"); code != http.StatusOK {
+		t.Fatalf("expected another client to have its own budget, got %d", code)
+	}
+}
+
+func TestMiddlewareByIPAdaptsIndependently(t *testing.T) {
+	c := cfg
+	c.AdjustInterval = 20 * time.Millisecond
+	keyed := newKeyed(t, 10, c)
+
+	h := MiddlewareByIP(keyed)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.RemoteAddr == "192.0.2.1:1234" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+
+	serveFrom(h, "192.0.2.1:1234")
+	serveFrom(h, "192.0.2.2:1234")
+
+	failing, healthy := keyed.Get("192.0.2.1"), keyed.Get("192.0.2.2")
+	deadline := time.Now().Add(time.Second)
+	for failing.CurrentLimit() >= 10 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if got := failing.CurrentLimit(); got >= 10 {
+		t.Fatalf("expected the failing client's limiter to back off, got %d", got)
+	}
+	if got := healthy.CurrentLimit(); got < 10 {
+		t.Fatalf("expected the healthy client's limiter not to back off, got %d", got)
+	}
+}
+
+func TestMiddlewareByIPTrustsOnlyConfiguredProxies(t *testing.T) {
+	h := MiddlewareByIP(newKeyed(t, 1, cfg), WithTrustedProxies(netip.MustParsePrefix("10.0.0.0/8")))(okHandler)
+
+	serveForwarded := func(remoteAddr, forwardedFor string) int {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = remoteAddr
+		r.Header.Set("X-Forwarded-For", forwardedFor)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		return rec.Code
+	}
+
+	if code := serveForwarded("10.0.0.1:1234", "203.0.113.9"); code != http.StatusOK {
+		t.Fatalf("expected the forwarded client's first request to pass, got %d", code)
+	}
+	if code := serveForwarded("10.0.0.2:1234", "203.0.113.9"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected the same client behind another proxy to share its budget, got %d", code)
+	}
+	if code := serveForwarded("192.0.2.1:1234", "198.51.100.1"); code != http.StatusOK {
+		t.Fatalf("expected the direct client's first request to pass, got %d", code)
+	}
+	if code := serveForwarded("192.0.2.1:1234", "198.51.100.2"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected a forged header not to buy a fresh budget, got %d", code)
+	}
+}
+
+func TestMiddlewareByIPAppliesGlobalLimit(t *testing.T) {
+	global := adaptiveratelimit.NewAdaptivePerSecond(2, cfg)
+	defer global.Stop()
+
+	h := MiddlewareByIP(newKeyed(t, 5, cfg), WithGlobalLimit(global))(okHandler)
+
+	for _, addr := range []string{"192.0.2.1:1234", "192.0.2.2:1234"} {
+		if code := serveFrom(h, addr); code != http.StatusOK {
+			t.Fatalf("expected %s to pass under the global limit, got %d", addr, code)
+		}
+	}
+	if code := serveFrom(h, "192.0.2.3:1234"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected the global limit to reject a third client, got %d", code)
+	}
+}
+
+func TestMiddlewareByIPStopsWithKeyedLimiter(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	keyed := adaptiveratelimit.NewKeyedPerSecond(1, cfg, time.Minute)
+	h := MiddlewareByIP(keyed)(okHandler)
+	for _, addr := range []string{"192.0.2.1:1234", "192.0.2.2:1234"} {
+		serveFrom(h, addr)
+	}
+	keyed.Stop()
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := runtime.NumGoroutine(); got > before {
+		t.Fatalf("expected stopping the KeyedLimiter to stop every goroutine, %d left of %d", got, before)
+	}
+}
@@ -1,32 +1,200 @@
 package http
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/bhatpriyanka8/adaptiveratelimit"
+	"github.com/bhatpriyanka8/adaptiveratelimit/internal/limiting"
+	"github.com/bhatpriyanka8/adaptiveratelimit/internal/status"
 )
 
 // Middleware returns an HTTP middleware that applies adaptive
 // rate limiting to incoming requests.
 //
 // Requests that exceed the current limit are rejected with
-// HTTP status 429 (Too Many Requests).
-func Middleware(l *adaptiveratelimit.Limiter) func(http.Handler) http.Handler {
+// HTTP status 429 (Too Many Requests) and a Retry-After header giving
+// the number of seconds until the limiter's window resets (at least 1).
+//
+// Responses with a status of 500 or above are recorded as errors so that
+// MaxErrorRate drives backoff; see WithErrorStatus. Handlers that never
+// call WriteHeader are treated as 200 OK. A handler that panics is
+// recorded as an error with the latency up to the panic, and the panic is
+// then propagated unless WithPanicRecovery is used. WithMaxRecordLatency
+// and WithHandlerTimeout keep hung handlers from skewing the averages.
+//
+// Behavior can be customized with options such as WithRateLimitHeaders.
+//
+// l is usually a *adaptiveratelimit.Limiter, but any Limiting will do,
+// such as a fake from the mock package. Retry-After, the X-RateLimit-*
+// headers and WithPriority use the limiter's TimeToReset, Remaining and
+// AllowPriority methods if it has them; otherwise Retry-After is one
+// second, the remaining and reset headers are omitted, and every request
+// is admitted with Allow.
+func Middleware(l adaptiveratelimit.Limiting, opts ...Option) func(http.Handler) http.Handler {
+	o := newOptions(opts)
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if !l.Allow() {
-				http.Error(w, "rate limited", http.StatusTooManyRequests)
+			serveLimited(l, &o, next, w, r)
+		})
+	}
+}
+
+// MiddlewareFunc returns an HTTP middleware that picks the limiter for
+// each request with selector, for example from a tenant that earlier
+// middleware resolved and stored in the request context. Combined with
+// adaptiveratelimit.KeyedLimiter's Get, this gives per-tenant limiting
+// driven by authentication context. Each selected limiter behaves as with
+// Middleware, and opts apply to all of them.
+//
+// The selected limiters are usually *adaptiveratelimit.Limiter, but any
+// comparable Limiting will do, such as a *adaptiveratelimit.MultiLimiter
+// pairing a tenant limit with a global one, or a fake from the mock
+// package; a selector returning adaptiveratelimit.Limiting can mix them.
+//
+// If selector returns nil the request passes through unlimited. A
+// selector that returns the same limiter for every request is equivalent
+// to Middleware.
+func MiddlewareFunc[L interface {
+	comparable
+	adaptiveratelimit.Limiting
+}](selector func(*http.Request) L, opts ...Option) func(http.Handler) http.Handler {
+	o := newOptions(opts)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var none L
+			l := selector(r)
+			if l == none {
+				next.ServeHTTP(w, r)
 				return
 			}
+			serveLimited(l, &o, next, w, r)
+		})
+	}
+}
 
-			start := time.Now()
-			err := func() error {
-				next.ServeHTTP(w, r)
-				return nil
-			}()
+// serveLimited serves r through next under l, as described for
+// Middleware.
+func serveLimited(l adaptiveratelimit.Limiting, o *options, next http.Handler, w http.ResponseWriter, r *http.Request) {
+	if o.skip != nil && o.skip(r) {
+		next.ServeHTTP(w, r)
+		return
+	}
 
-			l.Record(time.Since(start), err)
-		})
+	ok, done := allow(l, r, o)
+	if o.rateLimitHeaders {
+		setRateLimitHeaders(w.Header(), l)
+	}
+	if !ok {
+		w.Header().Set("Retry-After", status.RetryAfter(limiting.TimeToReset(l)))
+		http.Error(w, "rate limited", http.StatusTooManyRequests)
+		return
+	}
+
+	rec := newStatusRecorder(w)
+	defer func() {
+		p := recover()
+		if p == nil {
+			return
+		}
+		done(panicError{value: p})
+		if !o.recoverPanics || p == http.ErrAbortHandler {
+			panic(p)
+		}
+		if !rec.wroteHeader {
+			http.Error(rec, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+	}()
+
+	if o.handlerTimeout > 0 {
+		ctx, cancel := context.WithTimeout(r.Context(), o.handlerTimeout)
+		defer cancel()
+		r = r.WithContext(ctx)
+	}
+
+	next.ServeHTTP(rec, r)
+
+	if o.handlerTimeout > 0 && errors.Is(r.Context().Err(), context.DeadlineExceeded) {
+		done(context.DeadlineExceeded)
+		return
+	}
+	done(status.Check(rec.status, o.errorStatus))
+}
+
+// windowLimiter is implemented by limiters that report their window,
+// such as *adaptiveratelimit.Limiter.
+type windowLimiter interface {
+	Remaining() int
+	TimeToReset() time.Duration
+}
+
+// priorityLimiter is implemented by limiters that admit by priority,
+// such as *adaptiveratelimit.Limiter.
+type priorityLimiter interface {
+	AllowPriority(p adaptiveratelimit.Priority) bool
+}
+
+// priorityDoneLimiter is implemented by limiters that pair admission by
+// priority with recording, such as *adaptiveratelimit.Limiter.
+type priorityDoneLimiter interface {
+	AllowPriorityWithDone(p adaptiveratelimit.Priority) (ok bool, done func(err error))
+}
+
+// setRateLimitHeaders writes the X-RateLimit-* headers for l. The
+// remaining and reset headers are omitted unless l reports its window.
+func setRateLimitHeaders(h http.Header, l adaptiveratelimit.Limiting) {
+	h.Set("X-RateLimit-Limit", strconv.Itoa(l.CurrentLimit()))
+
+	wl, ok := l.(windowLimiter)
+	if !ok {
+		return
+	}
+	reset := time.Now().Add(wl.TimeToReset())
+	h.Set("X-RateLimit-Remaining", strconv.Itoa(wl.Remaining()))
+	h.Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+}
+
+// allow admits r, honoring the priority classifier if one is configured,
+// and returns a callback that records the request's outcome.
+//
+// The limiter's own AllowWithDone or AllowPriorityWithDone is used if it
+// has one, so that the request's slot is watched for MaxHoldDuration as
+// in the other adapters. With WithMaxRecordLatency the latency must be
+// capped before it is recorded, so the request is admitted with Allow or
+// AllowPriority and recorded with Record instead.
+func allow(l adaptiveratelimit.Limiting, r *http.Request, o *options) (bool, func(error)) {
+	pl, isPriority := l.(priorityLimiter)
+	isPriority = isPriority && o.priority != nil
+	if o.maxRecordLatency <= 0 {
+		if dl, isDone := l.(priorityDoneLimiter); isDone && isPriority {
+			return dl.AllowPriorityWithDone(o.priority(r))
+		}
+		if !isPriority {
+			return limiting.AllowWithDone(l)
+		}
+	}
+
+	var ok bool
+	if isPriority {
+		ok = pl.AllowPriority(o.priority(r))
+	} else {
+		ok = l.Allow()
+	}
+	if !ok {
+		return false, func(error) {}
+	}
+
+	start := time.Now()
+	return true, func(err error) {
+		latency := time.Since(start)
+		if o.maxRecordLatency > 0 {
+			latency = min(latency, o.maxRecordLatency)
+		}
+		l.Record(latency, err)
 	}
 }
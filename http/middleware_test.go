@@ -0,0 +1,508 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+	"github.com/bhatpriyanka8/adaptiveratelimit/mock"
+)
+
+var cfg = adaptiveratelimit.AdaptiveConfig{
+	TargetLatency: 200 * time.Millisecond,
+	MaxErrorRate:  0.05,
+	IncreaseStep:  1,
+	DecreaseStep:  2,
+	MinLimit:      1,
+	MaxLimit:      100,
+}
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+	w.Write([]byte("ok"))
+})
+
+func serve(h http.Handler) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	return rec
+}
+
+func TestMiddlewareSetsRetryAfterOnReject(t *testing.T) {
+	limiter := adaptiveratelimit.NewAdaptivePerSecond(1, cfg)
+	defer limiter.Stop()
+
+	h := Middleware(limiter)(okHandler)
+
+	if rec := serve(h); rec.Code != http.StatusOK {
+		t.Fatalf("expected first request to succeed, got %d", rec.Code)
+	}
+
+	rec := serve(h)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rec.Code)
+	}
+
+	secs, err := strconv.Atoi(rec.Header().Get("Retry-After"))
+	if err != nil {
+		t.Fatalf("expected numeric Retry-After, got %q", rec.Header().Get("Retry-After"))
+	}
+
+	if secs < 1 {
+		t.Fatalf("expected Retry-After of at least 1 second, got %d", secs)
+	}
+}
+
+func TestMiddlewareRateLimitHeaders(t *testing.T) {
+	limiter := adaptiveratelimit.NewAdaptivePerSecond(2, cfg)
+	defer limiter.Stop()
+
+	h := Middleware(limiter, WithRateLimitHeaders())(okHandler)
+
+	tests := []struct {
+		name      string
+		code      int
+		remaining string
+	}{
+		{"first allowed", http.StatusOK, "1"},
+		{"second allowed", http.StatusOK, "0"},
+		{"rejected", http.StatusTooManyRequests, "0"},
+	}
+
+	for _, tt := range tests {
+		rec := serve(h)
+		if rec.Code != tt.code {
+			t.Fatalf("%s: expected status %d, got %d", tt.name, tt.code, rec.Code)
+		}
+
+		if got := rec.Header().Get("X-RateLimit-Limit"); got != "2" {
+			t.Fatalf("%s: expected X-RateLimit-Limit 2, got %q", tt.name, got)
+		}
+
+		if got := rec.Header().Get("X-RateLimit-Remaining"); got != tt.remaining {
+			t.Fatalf("%s: expected X-RateLimit-Remaining %s, got %q", tt.name, tt.remaining, got)
+		}
+
+		reset, err := strconv.ParseInt(rec.Header().Get("X-RateLimit-Reset"), 10, 64)
+		if err != nil {
+			t.Fatalf("%s: expected numeric X-RateLimit-Reset, got %q", tt.name, rec.Header().Get("X-RateLimit-Reset"))
+		}
+
+		if now := time.Now().Unix(); reset < now || reset > now+1 {
+			t.Fatalf("%s: expected X-RateLimit-Reset within the next second, got %d (now %d)", tt.name, reset, now)
+		}
+	}
+}
+
+func TestMiddlewareOmitsRateLimitHeadersByDefault(t *testing.T) {
+	limiter := adaptiveratelimit.NewAdaptivePerSecond(2, cfg)
+	defer limiter.Stop()
+
+	rec := serve(Middleware(limiter)(okHandler))
+	if rec.Header().Get("X-RateLimit-Limit") != "" {
+		t.Fatal("expected no rate limit headers without the option")
+	}
+}
+
+func statusHandler(code int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(code)
+	})
+}
+
+func TestMiddlewareRecordsServerErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.Handler
+		opts    []Option
+		wantErr bool
+	}{
+		{"implicit 200", okHandler, nil, false},
+		{"404 is not an error", statusHandler(http.StatusNotFound), nil, false},
+		{"500 is an error", statusHandler(http.StatusInternalServerError), nil, true},
+		{"503 is an error", statusHandler(http.StatusServiceUnavailable), nil, true},
+		{"custom threshold", statusHandler(http.StatusTooManyRequests), []Option{WithErrorStatus(400)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := adaptiveratelimit.NewAdaptivePerSecond(10, cfg)
+			defer limiter.Stop()
+
+			serve(Middleware(limiter, tt.opts...)(tt.handler))
+
+			if got := limiter.ErrorRate() > 0; got != tt.wantErr {
+				t.Fatalf("expected error recorded = %v, got error rate %f", tt.wantErr, limiter.ErrorRate())
+			}
+		})
+	}
+}
+
+func TestMiddlewarePreservesFlusher(t *testing.T) {
+	limiter := adaptiveratelimit.NewAdaptivePerSecond(10, cfg)
+	defer limiter.Stop()
+
+	var flushed bool
+	h := Middleware(limiter)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		f, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("expected wrapped writer to implement http.Flusher")
+		}
+		f.Flush()
+		flushed = true
+	}))
+
+	rec := serve(h)
+	if !flushed || !rec.Flushed {
+		t.Fatal("expected flush to reach the underlying writer")
+	}
+}
+
+func TestMiddlewareWithPriorityShedsLowPriority(t *testing.T) {
+	limiter := adaptiveratelimit.NewAdaptivePerSecond(10, cfg)
+	defer limiter.Stop()
+
+	h := Middleware(limiter, WithPriority(PriorityFromHeader("X-Priority")))(okHandler)
+
+	request := func(priority string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Priority", priority)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	for i := 0; i < 6; i++ {
+		if code := request("low"); code != http.StatusOK {
+			t.Fatalf("expected low priority request %d to be allowed, got %d", i+1, code)
+		}
+	}
+
+	if code := request("LOW"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected low priority to be shed at 60%% capacity, got %d", code)
+	}
+
+	if code := request("high"); code != http.StatusOK {
+		t.Fatalf("expected high priority to still be admitted, got %d", code)
+	}
+}
+
+func TestMiddlewareReclaimsHeldSlot(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"allow", nil},
+		{"with priority", []Option{WithPriority(func(*http.Request) adaptiveratelimit.Priority {
+			return adaptiveratelimit.PriorityHigh
+		})}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := cfg
+			c.Window = 10 * time.Millisecond
+			c.MaxHoldDuration = 20 * time.Millisecond
+			limiter := adaptiveratelimit.NewAdaptiveConcurrency(1, c)
+			defer limiter.Stop()
+
+			started, unblock := make(chan struct{}), make(chan struct{})
+			finished := make(chan struct{})
+			h := Middleware(limiter, tt.opts...)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				close(started)
+				<-unblock
+			}))
+			go func() {
+				defer close(finished)
+				serve(h)
+			}()
+			<-started
+
+			deadline := time.Now().Add(time.Second)
+			for limiter.InFlight() != 0 && time.Now().Before(deadline) {
+				time.Sleep(5 * time.Millisecond)
+			}
+			if got := limiter.InFlight(); got != 0 {
+				t.Fatalf("expected the hung request's slot to be reclaimed, got %d in flight", got)
+			}
+
+			if !limiter.Allow() {
+				t.Fatal("expected the reclaimed slot to be available")
+			}
+			close(unblock)
+			<-finished
+			if got := limiter.InFlight(); got != 1 {
+				t.Fatalf("expected the late release to be a no-op, got %d in flight", got)
+			}
+		})
+	}
+}
+
+func TestPriorityFromHeader(t *testing.T) {
+	classify := PriorityFromHeader("X-Priority")
+
+	tests := map[string]adaptiveratelimit.Priority{
+		"low":     adaptiveratelimit.PriorityLow,
+		" High ":  adaptiveratelimit.PriorityHigh,
+		"normal":  adaptiveratelimit.PriorityNormal,
+		"":        adaptiveratelimit.PriorityNormal,
+		"urgent!": adaptiveratelimit.PriorityNormal,
+	}
+
+	for value, want := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Priority", value)
+		if got := classify(req); got != want {
+			t.Fatalf("header %q: expected %v, got %v", value, want, got)
+		}
+	}
+}
+
+func TestMiddlewareRecordsPanicAsError(t *testing.T) {
+	limiter := adaptiveratelimit.NewAdaptivePerSecond(10, cfg)
+	defer limiter.Stop()
+
+	h := Middleware(limiter)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		panic("boom")
+	}))
+
+	func() {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Fatalf("expected the panic to propagate, got %v", p)
+			}
+		}()
+		serve(h)
+	}()
+
+	if limiter.ErrorRate() == 0 {
+		t.Fatal("expected the panic to raise the error rate")
+	}
+
+	if limiter.AverageLatency() < 20*time.Millisecond {
+		t.Fatalf("expected latency up to the panic to be recorded, got %v", limiter.AverageLatency())
+	}
+}
+
+func TestMiddlewarePanicRecovery(t *testing.T) {
+	limiter := adaptiveratelimit.NewAdaptivePerSecond(10, cfg)
+	defer limiter.Stop()
+
+	h := Middleware(limiter, WithPanicRecovery())(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	}))
+
+	rec := serve(h)
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", rec.Code)
+	}
+
+	if limiter.ErrorRate() == 0 {
+		t.Fatal("expected the recovered panic to raise the error rate")
+	}
+}
+
+func TestMiddlewareClampsRecordedLatency(t *testing.T) {
+	limiter := adaptiveratelimit.NewAdaptivePerSecond(10, cfg)
+	defer limiter.Stop()
+
+	slow := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+	})
+	serve(Middleware(limiter, WithMaxRecordLatency(5*time.Millisecond))(slow))
+
+	if got := limiter.AverageLatency(); got != 5*time.Millisecond {
+		t.Fatalf("expected latency clamped to 5ms, got %v", got)
+	}
+}
+
+func TestMiddlewareHandlerTimeoutRecordsError(t *testing.T) {
+	limiter := adaptiveratelimit.NewAdaptivePerSecond(10, cfg)
+	defer limiter.Stop()
+
+	hung := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	serve(Middleware(limiter, WithHandlerTimeout(10*time.Millisecond))(hung))
+
+	if got := limiter.ErrorRate(); got != 1 {
+		t.Fatalf("expected the timed out request to be recorded as an error, got error rate %f", got)
+	}
+}
+
+func TestMiddlewareNeverRejectsInShadowMode(t *testing.T) {
+	c := cfg
+	c.Shadow = true
+	limiter := adaptiveratelimit.NewAdaptivePerSecond(1, c)
+	defer limiter.Stop()
+
+	h := Middleware(limiter)(okHandler)
+	for i := 0; i < 3; i++ {
+		if rec := serve(h); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: expected shadow mode never to return 429, got %d", i, rec.Code)
+		}
+	}
+	if got := limiter.Snapshot().ShadowRejected; got != 2 {
+		t.Fatalf("expected 2 would-be rejections, got %d", got)
+	}
+}
+
+type tenantKey struct{}
+
+func TestMiddlewareFuncRoutesTenantsToLimiters(t *testing.T) {
+	acme := adaptiveratelimit.NewAdaptivePerSecond(1, cfg)
+	defer acme.Stop()
+	globex := adaptiveratelimit.NewAdaptivePerSecond(2, cfg)
+	defer globex.Stop()
+	tenants := map[string]*adaptiveratelimit.Limiter{"acme": acme, "globex": globex}
+
+	limited := MiddlewareFunc(func(r *http.Request) *adaptiveratelimit.Limiter {
+		tenant, _ := r.Context().Value(tenantKey{}).(string)
+		return tenants[tenant]
+	})(okHandler)
+
+	// The context value stands in for earlier middleware that resolves
+	// the tenant.
+	serveTenant := func(tenant string) int {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r = r.WithContext(context.WithValue(r.Context(), tenantKey{}, tenant))
+		rec := httptest.NewRecorder()
+		limited.ServeHTTP(rec, r)
+		return rec.Code
+	}
+
+	if code := serveTenant("acme"); code != http.StatusOK {
+		t.Fatalf("expected acme's first request to pass, got %d", code)
+	}
+	if code := serveTenant("acme"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected acme's second request to be limited, got %d", code)
+	}
+	for i := 0; i < 2; i++ {
+		if code := serveTenant("globex"); code != http.StatusOK {
+			t.Fatalf("globex %d: expected acme's limit not to apply, got %d", i, code)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if code := serveTenant("unknown"); code != http.StatusOK {
+			t.Fatalf("expected a nil limiter to skip limiting, got %d", code)
+		}
+	}
+}
+
+func TestMiddlewareFuncAcceptsAnyLimiting(t *testing.T) {
+	rejecting := mock.AlwaysReject()
+	limited := MiddlewareFunc(func(r *http.Request) adaptiveratelimit.Limiting {
+		if r.URL.Path == "/free" {
+			return nil
+		}
+		return rejecting
+	})(okHandler)
+
+	serve := func(path string) int {
+		rec := httptest.NewRecorder()
+		limited.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+
+	if code := serve("/limited"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected the selected mock to reject, got %d", code)
+	}
+	if code := serve("/free"); code != http.StatusOK {
+		t.Fatalf("expected a nil Limiting to skip limiting, got %d", code)
+	}
+}
+
+// countingLimiter decorates a Limiting, counting the outcomes it records.
+type countingLimiter struct {
+	adaptiveratelimit.Limiting
+	recorded int
+}
+
+func (c *countingLimiter) Record(latency time.Duration, err error) {
+	c.recorded++
+	c.Limiting.Record(latency, err)
+}
+
+func TestMiddlewareAcceptsCustomLimiter(t *testing.T) {
+	limiter := adaptiveratelimit.NewAdaptivePerSecond(1, cfg)
+	defer limiter.Stop()
+	wrapped := &countingLimiter{Limiting: limiter}
+
+	h := Middleware(wrapped, WithRateLimitHeaders())(okHandler)
+
+	rec := serve(h)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected first request to succeed, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("X-RateLimit-Limit"); got != "1" {
+		t.Fatalf("expected the limit header from CurrentLimit, got %q", got)
+	}
+	if rec.Header().Get("X-RateLimit-Remaining") != "" {
+		t.Fatal("expected no remaining header from a limiter that does not report its window")
+	}
+	if wrapped.recorded != 1 {
+		t.Fatalf("expected the decorator to see the outcome, got %d records", wrapped.recorded)
+	}
+
+	rec = serve(h)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "1" {
+		t.Fatalf("expected 429 with Retry-After 1, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+}
+
+func TestMiddlewareAcceptsMultiLimiter(t *testing.T) {
+	global := adaptiveratelimit.NewAdaptivePerSecond(5, cfg)
+	defer global.Stop()
+	tenant := adaptiveratelimit.NewAdaptivePerSecond(1, cfg)
+	defer tenant.Stop()
+
+	h := Middleware(adaptiveratelimit.NewMultiLimiter(global, tenant), WithRateLimitHeaders())(okHandler)
+
+	rec := serve(h)
+	if rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Limit") != "1" {
+		t.Fatalf("expected success under the smallest limit, got %d %q", rec.Code, rec.Header().Get("X-RateLimit-Limit"))
+	}
+	if rec := serve(h); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected the tenant limit to reject, got %d", rec.Code)
+	}
+	if got := global.Remaining(); got != 4 {
+		t.Fatalf("expected the rejected request to be handed back, got %d remaining", got)
+	}
+}
+
+func TestMiddlewareSkipBypassesLimiter(t *testing.T) {
+	limiter := adaptiveratelimit.NewAdaptivePerSecond(1, cfg)
+	defer limiter.Stop()
+
+	healthz := func(r *http.Request) bool { return r.URL.Path == "/healthz" }
+	h := Middleware(limiter, WithSkip(healthz), WithRateLimitHeaders())(okHandler)
+
+	if rec := serve(h); rec.Code != http.StatusOK {
+		t.Fatalf("expected the first request to succeed, got %d", rec.Code)
+	}
+	for i := range 5 {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("health check %d: expected 200 even with the budget spent, got %d", i+1, rec.Code)
+		}
+		if rec.Header().Get("X-RateLimit-Limit") != "" {
+			t.Fatalf("health check %d: expected no rate limit headers", i+1)
+		}
+	}
+
+	s := limiter.Snapshot()
+	if s.CountThisWindow != 1 || s.AllowedTotal != 1 || s.RejectedTotal != 0 {
+		t.Fatalf("expected health checks not to be counted, got %+v", s)
+	}
+	if s.LatencySamples != 1 {
+		t.Fatalf("expected health checks not to be recorded, got %d samples", s.LatencySamples)
+	}
+	if rec := serve(h); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected other requests to stay limited, got %d", rec.Code)
+	}
+}
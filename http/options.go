@@ -0,0 +1,163 @@
+package http
+
+import (
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+	"github.com/bhatpriyanka8/adaptiveratelimit/internal/status"
+)
+
+// Option configures the behavior of Middleware.
+type Option func(*options)
+
+type options struct {
+	rateLimitHeaders bool
+	errorStatus      int
+	recoverPanics    bool
+	maxRecordLatency time.Duration
+	handlerTimeout   time.Duration
+	priority         func(*http.Request) adaptiveratelimit.Priority
+	skip             func(*http.Request) bool
+
+	// trustedProxies and global configure MiddlewareByIP.
+	trustedProxies []netip.Prefix
+	global         *adaptiveratelimit.Limiter
+}
+
+func newOptions(opts []Option) options {
+	o := options{errorStatus: status.DefaultErrorStatus}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithRateLimitHeaders makes Middleware emit informational rate limit
+// headers on both allowed and rejected responses:
+//
+//   - X-RateLimit-Limit: the limiter's current limit
+//   - X-RateLimit-Remaining: capacity left in the current window
+//   - X-RateLimit-Reset: Unix time (seconds) of the next window reset
+//
+// Headers are disabled by default.
+func WithRateLimitHeaders() Option {
+	return func(o *options) {
+		o.rateLimitHeaders = true
+	}
+}
+
+// WithErrorStatus sets the lowest response status code that is recorded
+// as an error for the limiter's adaptive loop. The default is 500, so any
+// 5xx response counts as a failure while 4xx responses do not.
+func WithErrorStatus(code int) Option {
+	return func(o *options) {
+		o.errorStatus = code
+	}
+}
+
+// WithPanicRecovery makes Middleware recover from handler panics and
+// respond with 500 Internal Server Error (if the handler has not already
+// written a status) instead of re-panicking. Either way the panic is
+// recorded as an error. http.ErrAbortHandler is always re-panicked, since
+// it is the conventional way to abort a response.
+func WithPanicRecovery() Option {
+	return func(o *options) {
+		o.recoverPanics = true
+	}
+}
+
+// WithMaxRecordLatency caps the latency Middleware records for a request
+// at d, so that a single stuck request cannot dominate the limiter's
+// latency average. A request that takes longer still counts, as a
+// request of exactly d. The cap also hides how slow such requests really
+// were, so it should sit well above TargetLatency. Zero, the default,
+// disables the cap.
+//
+// Since the limiter no longer measures the latency itself, requests are
+// then admitted with Allow rather than AllowWithDone, so their slots are
+// not watched for AdaptiveConfig.MaxHoldDuration.
+func WithMaxRecordLatency(d time.Duration) Option {
+	return func(o *options) {
+		o.maxRecordLatency = d
+	}
+}
+
+// WithHandlerTimeout gives each admitted request a context that expires
+// after d, and records a request whose context deadline passed while the
+// handler ran as an error, even if the handler then wrote a successful
+// status. Unlike http.TimeoutHandler it does not write a response or stop
+// the handler: it only helps handlers that honor their request context
+// return promptly, so that a hung downstream is reported to the limiter
+// instead of never being recorded at all. Zero, the default, disables the
+// timeout.
+func WithHandlerTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.handlerTimeout = d
+	}
+}
+
+// WithPriority makes Middleware admit requests with Limiter.AllowPriority,
+// using fn to classify each request, so that low priority traffic is shed
+// before high priority traffic as the limiter saturates.
+func WithPriority(fn func(*http.Request) adaptiveratelimit.Priority) Option {
+	return func(o *options) {
+		o.priority = fn
+	}
+}
+
+// PriorityFromHeader returns a classifier for WithPriority that reads the
+// priority from the named request header. The values "low", "normal" and
+// "high" are recognized case-insensitively; anything else, including a
+// missing header, is treated as normal priority.
+func PriorityFromHeader(name string) func(*http.Request) adaptiveratelimit.Priority {
+	return func(r *http.Request) adaptiveratelimit.Priority {
+		switch strings.ToLower(strings.TrimSpace(r.Header.Get(name))) {
+		case "low":
+			return adaptiveratelimit.PriorityLow
+		case "high":
+			return adaptiveratelimit.PriorityHigh
+		default:
+			return adaptiveratelimit.PriorityNormal
+		}
+	}
+}
+
+// WithSkip makes Middleware pass requests for which fn returns true
+// straight to the handler, without calling Allow or Record, so that
+// health checks, metrics scrapes and internal endpoints neither consume
+// the rate budget nor are ever rejected. Skipped requests do not affect
+// adaptation: their latency and errors are not recorded, and no rate
+// limit headers are set on their responses.
+func WithSkip(fn func(*http.Request) bool) Option {
+	return func(o *options) {
+		o.skip = fn
+	}
+}
+
+// WithTrustedProxies lists the proxies, as CIDR prefixes, whose
+// X-Forwarded-For and X-Real-IP headers MiddlewareByIP believes when
+// working out a request's client IP; see ClientIP. List only proxies that
+// overwrite or append to these headers, such as your own load balancers:
+// a prefix covering clients lets them spoof their key. By default no
+// proxy is trusted and the headers are ignored. Other middleware ignores
+// this option.
+func WithTrustedProxies(prefixes ...netip.Prefix) Option {
+	return func(o *options) {
+		o.trustedProxies = append(o.trustedProxies, prefixes...)
+	}
+}
+
+// WithGlobalLimit makes MiddlewareByIP also admit every request through
+// l, shared by all clients, so that a request passes only if both its
+// client's limiter and l allow it; see adaptiveratelimit.MultiLimiter.
+// Outcomes are recorded with both, and capacity taken from the client's
+// limiter is handed back when l rejects. The caller owns l and must stop
+// it. Other middleware ignores this option.
+func WithGlobalLimit(l *adaptiveratelimit.Limiter) Option {
+	return func(o *options) {
+		o.global = l
+	}
+}
@@ -0,0 +1,85 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+)
+
+// MiddlewareByPath returns an HTTP middleware that applies a separate
+// limiter to each request path, so that an expensive endpoint adapts
+// independently of a cheap one. Each limiter behaves as with Middleware,
+// and opts apply to all of them.
+//
+// Keys of limiters are matched against r.URL.Path. A key is an exact
+// path unless it ends in "/", in which case it also matches every path
+// below it; an exact match wins, then the longest such prefix, as with
+// http.ServeMux. Requests matching no key use fallback, or pass through
+// unlimited if fallback is nil.
+//
+// Limiters are usually *adaptiveratelimit.Limiter, but any comparable
+// Limiting will do, such as a *adaptiveratelimit.MultiLimiter or a fake
+// from the mock package; with a map of adaptiveratelimit.Limiting they
+// can be mixed. A nil fallback, or a nil Limiting, means none.
+//
+// The map is copied, so changing it afterwards has no effect. The
+// returned middleware is safe for concurrent use.
+func MiddlewareByPath[L interface {
+	comparable
+	adaptiveratelimit.Limiting
+}](limiters map[string]L, fallback L, opts ...Option) func(http.Handler) http.Handler {
+	var none L
+	byPath := make(map[string]L, len(limiters))
+	var prefixes []string
+	for path, l := range limiters {
+		byPath[path] = l
+		if strings.HasSuffix(path, "/") {
+			prefixes = append(prefixes, path)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		handlers := make(map[L]http.Handler, len(byPath)+1)
+		for _, l := range byPath {
+			if _, ok := handlers[l]; !ok && l != none {
+				handlers[l] = Middleware(l, opts...)(next)
+			}
+		}
+
+		unmatched := next
+		if fallback != none {
+			unmatched = Middleware(fallback, opts...)(next)
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if l, ok := matchPath(byPath, prefixes, r.URL.Path); ok {
+				handlers[l].ServeHTTP(w, r)
+				return
+			}
+			unmatched.ServeHTTP(w, r)
+		})
+	}
+}
+
+// matchPath returns the limiter for path: an exact match, else the one
+// with the longest matching prefix. It reports false if no key matches,
+// or if the matching limiter is nil.
+func matchPath[L comparable](byPath map[string]L, prefixes []string, path string) (L, bool) {
+	var none L
+	if l, ok := byPath[path]; ok {
+		return l, l != none
+	}
+
+	var best string
+	for _, p := range prefixes {
+		if len(p) > len(best) && strings.HasPrefix(path, p) {
+			best = p
+		}
+	}
+	if best == "" {
+		return none, false
+	}
+	l := byPath[best]
+	return l, l != none
+}
@@ -0,0 +1,144 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+	"github.com/bhatpriyanka8/adaptiveratelimit/mock"
+)
+
+func servePath(h http.Handler, path string) int {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec.Code
+}
+
+func TestMiddlewareByPathLimitsPathsIndependently(t *testing.T) {
+	export := adaptiveratelimit.NewAdaptivePerSecond(1, cfg)
+	defer export.Stop()
+	health := adaptiveratelimit.NewAdaptivePerSecond(3, cfg)
+	defer health.Stop()
+
+	h := MiddlewareByPath(map[string]*adaptiveratelimit.Limiter{
+		"/export": export,
+		"/health": health,
+	}, nil)(okHandler)
+
+	if code := servePath(h, "/export"); code != http.StatusOK {
+		t.Fatalf("expected first export to pass, got %d", code)
+	}
+	if code := servePath(h, "/export"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected second export to be limited, got %d", code)
+	}
+	for i := 0; i < 3; i++ {
+		if code := servePath(h, "/health"); code != http.StatusOK {
+			t.Fatalf("health %d: expected the export limit not to apply, got %d", i, code)
+		}
+	}
+	if code := servePath(h, "/other"); code != http.StatusOK {
+		t.Fatalf("expected unmatched path without fallback to pass, got %d", code)
+	}
+}
+
+func TestMiddlewareByPathAdaptsIndependently(t *testing.T) {
+	slowCfg := cfg
+	slowCfg.AdjustInterval = 20 * time.Millisecond
+
+	export := adaptiveratelimit.NewAdaptivePerSecond(10, slowCfg)
+	defer export.Stop()
+	health := adaptiveratelimit.NewAdaptivePerSecond(10, slowCfg)
+	defer health.Stop()
+
+	h := MiddlewareByPath(map[string]*adaptiveratelimit.Limiter{
+		"/export": export,
+		"/health": health,
+	}, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/export" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+
+	servePath(h, "/export")
+	servePath(h, "/health")
+
+	deadline := time.Now().Add(time.Second)
+	for export.CurrentLimit() >= 10 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if got := export.CurrentLimit(); got >= 10 {
+		t.Fatalf("expected failing export limiter to back off, got %d", got)
+	}
+	if got := health.CurrentLimit(); got < 10 {
+		t.Fatalf("expected healthy limiter not to back off, got %d", got)
+	}
+}
+
+func TestMiddlewareByPathMatching(t *testing.T) {
+	exact := adaptiveratelimit.NewAdaptivePerSecond(1, cfg)
+	defer exact.Stop()
+	api := adaptiveratelimit.NewAdaptivePerSecond(1, cfg)
+	defer api.Stop()
+	v2 := adaptiveratelimit.NewAdaptivePerSecond(1, cfg)
+	defer v2.Stop()
+	fallback := adaptiveratelimit.NewAdaptivePerSecond(1, cfg)
+	defer fallback.Stop()
+
+	byPath := map[string]*adaptiveratelimit.Limiter{
+		"/api/v2/status": exact,
+		"/api/":          api,
+		"/api/v2/":       v2,
+	}
+	prefixes := []string{"/api/", "/api/v2/"}
+
+	tests := []struct {
+		path string
+		want *adaptiveratelimit.Limiter
+	}{
+		{"/api/v2/status", exact},
+		{"/api/v2/users", v2},
+		{"/api/v1/users", api},
+		{"/api", nil},
+		{"/export", nil},
+	}
+
+	for _, tt := range tests {
+		if got, _ := matchPath(byPath, prefixes, tt.path); got != tt.want {
+			t.Errorf("%s: matched the wrong limiter", tt.path)
+		}
+	}
+
+	h := MiddlewareByPath(byPath, fallback)(okHandler)
+	servePath(h, "/export")
+	if code := servePath(h, "/other"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected unmatched paths to share the fallback limiter, got %d", code)
+	}
+}
+
+func TestMiddlewareByPathAcceptsAnyLimiting(t *testing.T) {
+	export := adaptiveratelimit.NewAdaptivePerSecond(5, cfg)
+	defer export.Stop()
+	global := adaptiveratelimit.NewAdaptivePerSecond(1, cfg)
+	defer global.Stop()
+
+	h := MiddlewareByPath(map[string]adaptiveratelimit.Limiting{
+		"/export": adaptiveratelimit.NewMultiLimiter(export, global),
+		"/health": mock.AlwaysReject(),
+	}, adaptiveratelimit.Limiting(mock.AlwaysAllow()))(okHandler)
+
+	if code := servePath(h, "/export"); code != http.StatusOK {
+		t.Fatalf("expected first export to pass, got %d", code)
+	}
+	if code := servePath(h, "/export"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected the global limit to apply to exports, got %d", code)
+	}
+	if code := servePath(h, "/health"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected the mock to reject health checks, got %d", code)
+	}
+	if code := servePath(h, "/other"); code != http.StatusOK {
+		t.Fatalf("expected the fallback mock to admit other paths, got %d", code)
+	}
+}
@@ -0,0 +1,58 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+)
+
+// statsResponse is the JSON document served by StatsHandler. Its field
+// names are part of the API and must not change.
+type statsResponse struct {
+	Name                       string  `json:"name,omitempty"`
+	CurrentLimit               int     `json:"current_limit"`
+	AverageLatencySeconds      float64 `json:"average_latency_seconds"`
+	ErrorRate                  float64 `json:"error_rate"`
+	CountThisWindow            int     `json:"count_this_window"`
+	AllowedTotal               uint64  `json:"allowed_total"`
+	RejectedTotal              uint64  `json:"rejected_total"`
+	TimeToResetSeconds         float64 `json:"time_to_reset_seconds"`
+	TimeSinceAdjustmentSeconds float64 `json:"time_since_adjustment_seconds"`
+}
+
+// StatsHandler returns an http.Handler that serves a JSON snapshot of l's
+// live state, for quick operational inspection without a metrics backend.
+//
+// The response is a single object with these stable fields:
+//
+//   - name: the limiter's name, omitted if it is unnamed
+//   - current_limit: the current limit, in requests per window
+//   - average_latency_seconds: the smoothed average latency
+//   - error_rate: the smoothed error rate, between 0 and 1
+//   - count_this_window: units admitted in the current window
+//   - allowed_total, rejected_total: lifetime admission counters
+//   - time_to_reset_seconds: time until the current window resets
+//   - time_since_adjustment_seconds: time since the last adjustment, or 0
+//
+// The handler only reads a Snapshot, so it is cheap to poll frequently.
+func StatsHandler(l *adaptiveratelimit.Limiter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		s := l.Snapshot()
+		resp := statsResponse{
+			Name:                       s.Name,
+			CurrentLimit:               s.CurrentLimit,
+			AverageLatencySeconds:      s.AverageLatency.Seconds(),
+			ErrorRate:                  s.ErrorRate,
+			CountThisWindow:            s.CountThisWindow,
+			AllowedTotal:               s.AllowedTotal,
+			RejectedTotal:              s.RejectedTotal,
+			TimeToResetSeconds:         s.TimeToReset.Seconds(),
+			TimeSinceAdjustmentSeconds: s.TimeSinceAdjustment.Seconds(),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+}
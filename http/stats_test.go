@@ -0,0 +1,58 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+)
+
+func TestStatsHandlerServesJSON(t *testing.T) {
+	limiter := adaptiveratelimit.NewAdaptivePerSecond(2, cfg)
+	defer limiter.Stop()
+
+	limiter.Allow()
+	limiter.Allow()
+	limiter.Allow()
+	limiter.Record(100*time.Millisecond, nil)
+
+	rec := serve(StatsHandler(limiter))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("expected application/json, got %q", ct)
+	}
+
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("expected valid JSON, got %v: %s", err, rec.Body.String())
+	}
+
+	want := map[string]any{
+		"current_limit":           2.0,
+		"average_latency_seconds": 0.1,
+		"error_rate":              0.0,
+		"count_this_window":       2.0,
+		"allowed_total":           2.0,
+		"rejected_total":          1.0,
+	}
+	for key, v := range want {
+		if body[key] != v {
+			t.Fatalf("expected %s = %v, got %v", key, v, body[key])
+		}
+	}
+
+	for _, key := range []string{"time_to_reset_seconds", "time_since_adjustment_seconds"} {
+		if _, ok := body[key].(float64); !ok {
+			t.Fatalf("expected numeric %s, got %v", key, body[key])
+		}
+	}
+
+	if len(body) != 8 {
+		t.Fatalf("expected exactly 8 fields, got %d: %v", len(body), body)
+	}
+}
@@ -0,0 +1,47 @@
+package adaptiveratelimit
+
+// activity returns a value that changes whenever a request is admitted,
+// rejected or recorded, so the control goroutine can tell whether the
+// limiter has been used since it last looked.
+func (l *Limiter) activity() uint64 {
+	return l.allowedTotal.Load() + l.rejectedTotal.Load() + uint64(l.samples.Load())
+}
+
+// enterIdle switches the limiter to idle ticking if no one is waiting for
+// capacity. It reports whether it did.
+func (l *Limiter) enterIdle() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.waiters.Len() > 0 {
+		return false
+	}
+	l.idle.Store(true)
+	return true
+}
+
+// wake leaves idle ticking on the first use after an idle period. It
+// starts a fresh window first if the current one is overdue, since the
+// idle loop resets windows only every IdleTimeout, and then signals the
+// control goroutine to resume its regular schedule.
+func (l *Limiter) wake() {
+	if !l.idle.Load() {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.idle.Load() {
+		return
+	}
+	l.idle.Store(false)
+	now := l.clock.Now()
+	if l.windowsElapsed(now) >= 1 {
+		l.resetWindow(now)
+	}
+	select {
+	case l.wakeCh <- struct{}{}:
+	default:
+	}
+}
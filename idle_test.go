@@ -0,0 +1,70 @@
+package adaptiveratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// tickerPeriod returns the current period of the fake clock's only
+// ticker.
+func tickerPeriod(t *testing.T, clock *fakeClock) time.Duration {
+	t.Helper()
+
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	if len(clock.tickers) != 1 {
+		t.Fatalf("expected a single ticker, got %d", len(clock.tickers))
+	}
+	return clock.tickers[0].period
+}
+
+func TestIdleTimeoutSlowsTicker(t *testing.T) {
+	c := cfg
+	c.IdleTimeout = 10 * time.Second
+	c.MinSamples = 1000 // keep the limit fixed
+
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(2, c, WithClock(clock))
+	defer limiter.Stop()
+
+	limiter.Allow()
+	clock.Advance(5 * time.Second)
+	if got := tickerPeriod(t, clock); got != time.Second {
+		t.Fatalf("expected regular ticks before the idle timeout, got %v", got)
+	}
+
+	clock.Advance(10 * time.Second)
+	if got := tickerPeriod(t, clock); got != c.IdleTimeout {
+		t.Fatalf("expected ticks every IdleTimeout once idle, got %v", got)
+	}
+
+	// The first request after idling gets a fresh window and restores
+	// the regular schedule.
+	clock.Advance(3 * time.Second)
+	if !limiter.AllowN(2) {
+		t.Fatal("expected the first request after idle to see a full window")
+	}
+	// The wakeup reaches the control goroutine asynchronously.
+	deadline := time.Now().Add(time.Second)
+	for tickerPeriod(t, clock) != time.Second {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected activity to restore regular ticks, got %v", tickerPeriod(t, clock))
+		}
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(time.Second)
+	if got := limiter.Remaining(); got != 2 {
+		t.Fatalf("expected windows to reset on schedule again, got %d remaining", got)
+	}
+}
+
+func TestIdleTimeoutDisabledByDefault(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(2, cfg, WithClock(clock))
+	defer limiter.Stop()
+
+	clock.Advance(time.Minute)
+	if got := tickerPeriod(t, clock); got != time.Second {
+		t.Fatalf("expected regular ticks without IdleTimeout, got %v", got)
+	}
+}
@@ -0,0 +1,87 @@
+// Package limiting holds the fallbacks shared by the framework adapters
+// for limiters that implement only adaptiveratelimit.Limiting.
+package limiting
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+)
+
+// doneLimiter is implemented by limiters that pair admission with
+// recording themselves, such as *adaptiveratelimit.Limiter.
+type doneLimiter interface {
+	AllowWithDone() (ok bool, done func(err error))
+}
+
+// resetLimiter is implemented by limiters that report when their window
+// resets, such as *adaptiveratelimit.Limiter.
+type resetLimiter interface {
+	TimeToReset() time.Duration
+}
+
+// AllowWithDone admits one request on l and returns a callback that
+// records its outcome once, using l's own AllowWithDone if it has one.
+// If ok is false, done is a no-op.
+func AllowWithDone(l adaptiveratelimit.Limiting) (ok bool, done func(err error)) {
+	if dl, isDone := l.(doneLimiter); isDone {
+		return dl.AllowWithDone()
+	}
+	if !l.Allow() {
+		return false, func(error) {}
+	}
+
+	start := time.Now()
+	var once sync.Once
+	return true, func(err error) {
+		once.Do(func() {
+			l.Record(time.Since(start), err)
+		})
+	}
+}
+
+// resultLimiter is implemented by limiters that record outcomes without
+// a latency, such as *adaptiveratelimit.Limiter.
+type resultLimiter interface {
+	RecordResult(err error)
+}
+
+// resultDoneLimiter is implemented by limiters that pair admission with
+// recording an outcome without a latency, such as
+// *adaptiveratelimit.Limiter.
+type resultDoneLimiter interface {
+	AllowWithResult() (ok bool, done func(err error))
+}
+
+// AllowWithResult is like AllowWithDone, but done records only the error
+// with RecordResult, using l's own AllowWithResult if it has one. If l
+// cannot record without a latency, it behaves exactly like AllowWithDone.
+func AllowWithResult(l adaptiveratelimit.Limiting) (ok bool, done func(err error)) {
+	if dl, isDone := l.(resultDoneLimiter); isDone {
+		return dl.AllowWithResult()
+	}
+	rl, isResult := l.(resultLimiter)
+	if !isResult {
+		return AllowWithDone(l)
+	}
+	if !l.Allow() {
+		return false, func(error) {}
+	}
+
+	var once sync.Once
+	return true, func(err error) {
+		once.Do(func() {
+			rl.RecordResult(err)
+		})
+	}
+}
+
+// TimeToReset returns l's time until its window resets, or zero if l
+// does not report it.
+func TimeToReset(l adaptiveratelimit.Limiting) time.Duration {
+	if rl, ok := l.(resetLimiter); ok {
+		return rl.TimeToReset()
+	}
+	return 0
+}
@@ -0,0 +1,42 @@
+// Package status holds the response classification shared by the HTTP
+// framework adapters.
+package status
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// DefaultErrorStatus is the lowest response status recorded as an error
+// unless an adapter is configured otherwise.
+const DefaultErrorStatus = 500
+
+// Error reports a response status treated as a failure.
+type Error struct {
+	Code int
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("http status %d", e.Code)
+}
+
+// Check returns an Error for code if it is at least threshold, and nil
+// otherwise.
+func Check(code, threshold int) error {
+	if code >= threshold {
+		return Error{Code: code}
+	}
+	return nil
+}
+
+// RetryAfter formats d as a Retry-After value in whole seconds, rounding
+// up and never returning less than one second.
+func RetryAfter(d time.Duration) string {
+	secs := int(math.Ceil(d.Seconds()))
+	if secs < 1 {
+		secs = 1
+	}
+	return strconv.Itoa(secs)
+}
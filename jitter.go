@@ -0,0 +1,60 @@
+package adaptiveratelimit
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// tickSchedule spaces a loop's ticks interval apart on average while
+// offsetting each one randomly, so that many limiters built from the same
+// config do not reset and adjust in lockstep.
+//
+// Offsets are taken around a fixed grid of nominal tick times rather than
+// added to the previous tick, so the phase never drifts: each tick lands
+// within jitter*interval/2 of its nominal time, and consecutive ticks are
+// between interval*(1-jitter) and interval*(1+jitter) apart.
+type tickSchedule struct {
+	interval time.Duration
+	jitter   float64
+
+	// nominal is the unjittered time of the latest tick, and next is
+	// when the following one is due.
+	nominal time.Time
+	next    time.Time
+}
+
+// newTickSchedule starts a schedule at start, with its first tick due at
+// next.
+func newTickSchedule(start time.Time, interval time.Duration, jitter float64) *tickSchedule {
+	s := &tickSchedule{interval: interval, jitter: jitter, nominal: start}
+	s.next = start.Add(interval + s.offset())
+	return s
+}
+
+// offset returns a random offset from a nominal tick time.
+func (s *tickSchedule) offset() time.Duration {
+	if s.jitter <= 0 {
+		return 0
+	}
+	return time.Duration((rand.Float64()*2 - 1) * s.jitter / 2 * float64(s.interval))
+}
+
+// due reports whether the next tick has arrived at now.
+func (s *tickSchedule) due(now time.Time) bool {
+	return !now.Before(s.next)
+}
+
+// tick advances the schedule past a tick handled at now and sets next.
+// interval and jitter are the current configuration, and a change to
+// either starts a new grid at now.
+func (s *tickSchedule) tick(now time.Time, interval time.Duration, jitter float64) {
+	changed := interval != s.interval || jitter != s.jitter
+	s.interval, s.jitter = interval, jitter
+
+	if changed || now.Sub(s.nominal) > 2*interval {
+		s.nominal = now
+	} else {
+		s.nominal = s.nominal.Add(interval)
+	}
+	s.next = s.nominal.Add(interval + s.offset())
+}
@@ -0,0 +1,83 @@
+package adaptiveratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTickScheduleJitterStaysWithinBound(t *testing.T) {
+	const (
+		interval = time.Second
+		jitter   = 0.2
+		ticks    = 200
+	)
+
+	start := time.Unix(1_000_000, 0)
+	sched := newTickSchedule(start, interval, jitter)
+
+	now := sched.next
+	delays := []time.Duration{now.Sub(start)}
+	for i := 0; i < ticks; i++ {
+		sched.tick(now, interval, jitter)
+		delays = append(delays, sched.next.Sub(now))
+		now = sched.next
+	}
+
+	lo := time.Duration(float64(interval) * (1 - jitter))
+	hi := time.Duration(float64(interval) * (1 + jitter))
+	distinct := map[time.Duration]bool{}
+	for i, d := range delays[1:] {
+		if d < lo || d > hi {
+			t.Fatalf("tick %d: interval %v outside [%v, %v]", i, d, lo, hi)
+		}
+		distinct[d] = true
+	}
+	if len(distinct) < 2 {
+		t.Fatal("expected jitter to vary the interval")
+	}
+
+	// The last tick is still within Jitter/2 of its nominal time.
+	nominal := start.Add(time.Duration(ticks+1) * interval)
+	if drift := now.Sub(nominal); drift < -interval/10 || drift > interval/10 {
+		t.Fatalf("expected no drift from the nominal schedule, got %v", drift)
+	}
+}
+
+func TestTickScheduleWithoutJitterKeepsGrid(t *testing.T) {
+	start := time.Unix(1_000_000, 0)
+	sched := newTickSchedule(start, time.Second, 0)
+	if got := sched.next.Sub(start); got != time.Second {
+		t.Fatalf("expected an unjittered first tick, got %v", got)
+	}
+
+	// A tick handled late still keeps the next one on the grid.
+	sched.tick(start.Add(1100*time.Millisecond), time.Second, 0)
+	if got := sched.next.Sub(start); got != 2*time.Second {
+		t.Fatalf("expected the next tick on the grid, got %v", got)
+	}
+
+	now := start.Add(2 * time.Second)
+	sched.tick(now, 2*time.Second, 0)
+	if got := sched.next.Sub(now); got != 2*time.Second {
+		t.Fatalf("expected a changed interval to start a new grid, got %v", got)
+	}
+}
+
+func TestJitteredLimiterStillResetsWindows(t *testing.T) {
+	c := cfg
+	c.Jitter = 0.5
+	c.MinSamples = 1000 // keep the limit fixed
+
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(2, c, WithClock(clock))
+	defer limiter.Stop()
+
+	for i := 0; i < 5; i++ {
+		for limiter.Allow() {
+		}
+		clock.Advance(1250 * time.Millisecond)
+		if got := limiter.Remaining(); got == 0 {
+			t.Fatalf("window %d: expected a reset within 1.25s", i)
+		}
+	}
+}
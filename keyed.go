@@ -0,0 +1,149 @@
+package adaptiveratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// KeyedLimiter maintains an independent adaptive Limiter per key, such as
+// an API key, tenant or client IP.
+//
+// Limiters are created lazily on first use and are evicted, and stopped,
+// once they have gone unused for the idle timeout, so memory stays bounded
+// by the number of recently active keys.
+//
+// KeyedLimiter is safe for concurrent use. Lookups are serialized by a
+// single mutex, while admission and feedback for a key are handled by that
+// key's own Limiter. A key evicted while a request is in flight simply
+// starts over with a fresh limiter on its next use.
+type KeyedLimiter struct {
+	// unexported fields
+	mu          sync.Mutex
+	limiters    map[string]*keyedEntry
+	newLimiter  func(key string) *Limiter
+	idleTimeout time.Duration
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+type keyedEntry struct {
+	limiter  *Limiter
+	lastUsed time.Time
+}
+
+// NewKeyedLimiter creates a KeyedLimiter that builds per-key limiters with
+// newLimiter and evicts keys that have been idle for idleTimeout.
+//
+// A non-positive idleTimeout disables eviction. The returned KeyedLimiter
+// starts a background sweeper and should be stopped by calling Stop.
+func NewKeyedLimiter(newLimiter func(key string) *Limiter, idleTimeout time.Duration) *KeyedLimiter {
+	k := &KeyedLimiter{
+		limiters:    make(map[string]*keyedEntry),
+		newLimiter:  newLimiter,
+		idleTimeout: idleTimeout,
+		stopCh:      make(chan struct{}),
+	}
+	if idleTimeout > 0 {
+		k.startSweepLoop()
+	}
+	return k
+}
+
+// NewKeyedPerSecond creates a KeyedLimiter whose per-key limiters are
+// created with NewAdaptivePerSecond(limit, cfg).
+func NewKeyedPerSecond(limit int, cfg AdaptiveConfig, idleTimeout time.Duration) *KeyedLimiter {
+	return NewKeyedLimiter(func(string) *Limiter {
+		return NewAdaptivePerSecond(limit, cfg)
+	}, idleTimeout)
+}
+
+// Allow reports whether a request for key is allowed under that key's
+// current limit, creating the key's limiter if needed.
+func (k *KeyedLimiter) Allow(key string) bool {
+	return k.Get(key).Allow()
+}
+
+// Record records the outcome of a completed request for key.
+//
+// Record is a no-op for keys that have no limiter, for example because
+// they were evicted.
+func (k *KeyedLimiter) Record(key string, latency time.Duration, err error) {
+	k.mu.Lock()
+	e, ok := k.limiters[key]
+	if ok {
+		e.lastUsed = time.Now()
+	}
+	k.mu.Unlock()
+
+	if ok {
+		e.limiter.Record(latency, err)
+	}
+}
+
+// Get returns the limiter for key, creating it if needed.
+func (k *KeyedLimiter) Get(key string) *Limiter {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := time.Now()
+	e, ok := k.limiters[key]
+	if !ok {
+		e = &keyedEntry{limiter: k.newLimiter(key)}
+		k.limiters[key] = e
+	}
+	e.lastUsed = now
+	return e.limiter
+}
+
+// Len returns the number of keys currently tracked.
+func (k *KeyedLimiter) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return len(k.limiters)
+}
+
+// Stop stops the sweeper and every per-key limiter.
+//
+// It is safe to call Stop multiple times.
+func (k *KeyedLimiter) Stop() {
+	k.stopOnce.Do(func() {
+		close(k.stopCh)
+
+		k.mu.Lock()
+		defer k.mu.Unlock()
+		for key, e := range k.limiters {
+			e.limiter.Stop()
+			delete(k.limiters, key)
+		}
+	})
+}
+
+func (k *KeyedLimiter) startSweepLoop() {
+	ticker := time.NewTicker(max(k.idleTimeout/2, time.Millisecond))
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				k.sweep(time.Now())
+			case <-k.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// sweep evicts and stops limiters idle since before now-idleTimeout.
+func (k *KeyedLimiter) sweep(now time.Time) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	for key, e := range k.limiters {
+		if now.Sub(e.lastUsed) >= k.idleTimeout {
+			e.limiter.Stop()
+			delete(k.limiters, key)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package adaptiveratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeyedLimiterKeysAreIndependent(t *testing.T) {
+	keyed := NewKeyedPerSecond(1, cfg, time.Minute)
+	defer keyed.Stop()
+
+	if !keyed.Allow("a") {
+		t.Fatal("expected first request for a to be allowed")
+	}
+
+	if keyed.Allow("a") {
+		t.Fatal("expected second request for a to be rejected")
+	}
+
+	if !keyed.Allow("b") {
+		t.Fatal("expected b to have its own budget")
+	}
+}
+
+func TestKeyedLimiterKeysAdaptIndependently(t *testing.T) {
+	keyed := NewKeyedPerSecond(10, cfg, time.Minute)
+	defer keyed.Stop()
+
+	keyed.Allow("slow")
+	keyed.Allow("fast")
+
+	for i := 0; i < 20; i++ {
+		keyed.Record("slow", 500*time.Millisecond, nil)
+		keyed.Record("fast", 10*time.Millisecond, nil)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+
+	if got := keyed.Get("slow").CurrentLimit(); got >= 10 {
+		t.Fatalf("expected slow key to back off, got limit %d", got)
+	}
+
+	if got := keyed.Get("fast").CurrentLimit(); got < 10 {
+		t.Fatalf("expected fast key not to back off, got limit %d", got)
+	}
+}
+
+func TestKeyedLimiterEvictsIdleKeys(t *testing.T) {
+	keyed := NewKeyedPerSecond(10, cfg, 50*time.Millisecond)
+	defer keyed.Stop()
+
+	evicted := keyed.Get("idle")
+	keyed.Allow("idle")
+
+	if keyed.Len() != 1 {
+		t.Fatalf("expected 1 key, got %d", keyed.Len())
+	}
+
+	time.Sleep(200 * time.Millisecond)
+
+	if keyed.Len() != 0 {
+		t.Fatalf("expected idle key to be evicted, got %d keys", keyed.Len())
+	}
+
+	select {
+	case <-evicted.stopCh:
+	default:
+		t.Fatal("expected evicted limiter to be stopped")
+	}
+
+	keyed.Record("idle", time.Millisecond, nil)
+	if keyed.Len() != 0 {
+		t.Fatal("expected Record not to recreate evicted keys")
+	}
+}
@@ -0,0 +1,109 @@
+package adaptiveratelimit
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrBucketFull is returned by Wait and Reserve on a leaky bucket limiter
+// whose bucket has no room for another request.
+var ErrBucketFull = errors.New("adaptiveratelimit: leaky bucket full")
+
+// NewAdaptiveLeakyBucket creates an adaptive limiter that shapes traffic
+// with a leaky bucket of the given capacity.
+//
+// Every admitted request enters the bucket, which drains at rate requests
+// per second (or per cfg.Window, if set), one request at a time. Allow
+// admits a request while the bucket has room and rejects it once the
+// bucket is full, so at most capacity requests are admitted back-to-back
+// and everything beyond that is spaced at the drain rate. Wait goes
+// further and shapes rather than merely caps: it queues the caller in the
+// bucket and returns only when its turn to drain comes, so callers of Wait
+// proceed strictly one drain interval apart. If the bucket is full, Wait
+// fails immediately with ErrBucketFull.
+//
+// The adaptive control loop adjusts the drain rate between MinLimit and
+// MaxLimit; capacity stays fixed. In this mode CurrentLimit reports the
+// drain rate in requests per window.
+//
+// Invalid input is clamped as described for NewAdaptivePerSecond, and a
+// capacity below one is raised to one.
+func NewAdaptiveLeakyBucket(rate int, capacity int, cfg AdaptiveConfig, opts ...Option) *Limiter {
+	cfg = cfg.sanitize(rate)
+	capacity = max(capacity, 1)
+
+	return newLimiter(cfg.clampLimit(rate), cfg, func(l *Limiter) {
+		l.mode = modeLeakyBucket
+		l.burst = capacity
+	}, opts)
+}
+
+// QueueDepth returns the number of requests currently in the leaky
+// bucket, including callers of Wait still waiting for their turn. It
+// returns zero for limiters in other modes.
+func (l *Limiter) QueueDepth() int {
+	if l.mode != modeLeakyBucket {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.bucketDepth(l.clock.Now())
+}
+
+// drainInterval returns the time the bucket takes to drain one request at
+// the current limit. It is zero if the limit is zero, in which case the
+// bucket never drains.
+//
+// The caller must hold l.mu.
+func (l *Limiter) drainInterval() time.Duration {
+	limit := l.limit()
+	if limit <= 0 {
+		return 0
+	}
+	return l.cfg.window() / time.Duration(limit)
+}
+
+// bucketDepth returns the number of requests in the bucket at now.
+//
+// The caller must hold l.mu.
+func (l *Limiter) bucketDepth(now time.Time) int {
+	backlog := l.drainedAt.Sub(now)
+	if backlog <= 0 {
+		return 0
+	}
+	interval := l.drainInterval()
+	if interval <= 0 {
+		return l.burst
+	}
+	return int((backlog + interval - 1) / interval)
+}
+
+// leakyAdmit adds n requests to the bucket if they fit.
+//
+// The caller must hold l.mu.
+func (l *Limiter) leakyAdmit(n int, now time.Time) bool {
+	interval := l.drainInterval()
+	if interval <= 0 || l.bucketDepth(now)+n > l.burst {
+		return false
+	}
+
+	l.drainedAt = later(l.drainedAt, now).Add(time.Duration(n) * interval)
+	return true
+}
+
+// later returns the later of a and b.
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// earlier returns the earlier of a and b.
+func earlier(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
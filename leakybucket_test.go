@@ -0,0 +1,139 @@
+package adaptiveratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLeakyBucketSmoothsBurst(t *testing.T) {
+	c := cfg
+	c.IncreaseStep = 0
+
+	clock := newFakeClock()
+	limiter := NewAdaptiveLeakyBucket(10, 2, c, WithClock(clock))
+	defer limiter.Stop()
+
+	// A burst of 10 admits only the bucket's capacity.
+	admitted := 0
+	for i := 0; i < 10; i++ {
+		if limiter.Allow() {
+			admitted++
+		}
+	}
+	if admitted != 2 {
+		t.Fatalf("expected the burst to be capped at capacity 2, got %d", admitted)
+	}
+	if got := limiter.QueueDepth(); got != 2 {
+		t.Fatalf("expected a full bucket of 2, got %d", got)
+	}
+
+	// After that, the bucket drains one request every 100ms.
+	for i := 0; i < 5; i++ {
+		clock.Advance(100 * time.Millisecond)
+
+		if !limiter.Allow() {
+			t.Fatalf("tick %d: expected one request to fit after draining", i)
+		}
+		if limiter.Allow() {
+			t.Fatalf("tick %d: expected only one request per drain interval", i)
+		}
+	}
+}
+
+func TestLeakyBucketRemainingAndTimeToReset(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewAdaptiveLeakyBucket(10, 3, cfg, WithClock(clock))
+	defer limiter.Stop()
+
+	if got := limiter.Remaining(); got != 3 {
+		t.Fatalf("expected an empty bucket with room for 3, got %d", got)
+	}
+
+	limiter.AllowN(3)
+
+	if got := limiter.Remaining(); got != 0 {
+		t.Fatalf("expected no room left, got %d", got)
+	}
+	if got := limiter.TimeToReset(); got != 100*time.Millisecond {
+		t.Fatalf("expected room in 100ms, got %v", got)
+	}
+
+	clock.Advance(100 * time.Millisecond)
+
+	if got := limiter.Remaining(); got != 1 {
+		t.Fatalf("expected room for 1 after one drain interval, got %d", got)
+	}
+	if got := limiter.TimeToReset(); got != 0 {
+		t.Fatalf("expected no wait with room in the bucket, got %v", got)
+	}
+}
+
+func TestLeakyBucketWaitShapes(t *testing.T) {
+	c := cfg
+	c.IncreaseStep = 0
+
+	// 20 per second drains one request every 50ms.
+	limiter := NewAdaptiveLeakyBucket(20, 3, c)
+	defer limiter.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	var returned []time.Duration
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(ctx); err != nil {
+			t.Fatalf("Wait %d: %v", i, err)
+		}
+		returned = append(returned, time.Since(start))
+	}
+
+	for i := 1; i < len(returned); i++ {
+		if gap := returned[i] - returned[i-1]; gap < 40*time.Millisecond {
+			t.Fatalf("expected Wait callers to be spaced by the drain interval, got %v between %d and %d", gap, i-1, i)
+		}
+	}
+}
+
+func TestLeakyBucketWaitRejectsWhenFull(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewAdaptiveLeakyBucket(10, 2, cfg, WithClock(clock))
+	defer limiter.Stop()
+
+	limiter.AllowN(2)
+
+	if err := limiter.Wait(context.Background()); !errors.Is(err, ErrBucketFull) {
+		t.Fatalf("expected ErrBucketFull, got %v", err)
+	}
+}
+
+func TestLeakyBucketWaitCancelReturnsSlot(t *testing.T) {
+	limiter := NewAdaptiveLeakyBucket(1, 2, cfg)
+	defer limiter.Stop()
+
+	limiter.Allow()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := limiter.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+
+	if got := limiter.QueueDepth(); got != 1 {
+		t.Fatalf("expected the cancelled waiter to leave the bucket, got depth %d", got)
+	}
+}
+
+func TestQueueDepthZeroInOtherModes(t *testing.T) {
+	limiter := NewAdaptivePerSecond(10, cfg, WithClock(newFakeClock()))
+	defer limiter.Stop()
+
+	limiter.AllowN(5)
+
+	if got := limiter.QueueDepth(); got != 0 {
+		t.Fatalf("expected zero queue depth outside leaky bucket mode, got %d", got)
+	}
+}
@@ -10,7 +10,12 @@
 package adaptiveratelimit
 
 import (
+	"container/list"
+	"errors"
+	"math"
+	"math/rand/v2"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -25,6 +30,16 @@ type AdaptiveConfig struct {
 	// Sustained latency above this value will cause the limiter to reduce capacity.
 	TargetLatency time.Duration
 
+	// HighWatermark and LowWatermark form a band around TargetLatency in
+	// which the limit is held steady, so latency hovering at the target
+	// does not make the limit sawtooth. The limit is lowered only above
+	// TargetLatency * (1 + HighWatermark) and raised only below
+	// TargetLatency * (1 - LowWatermark). Both are fractions in [0, 1);
+	// zero means 0.05. The band does not apply to StrategyGradient, nor
+	// to the error rate.
+	HighWatermark float64
+	LowWatermark  float64
+
 	// MaxErrorRate is the maximum acceptable error rate (0.0–1.0).
 	// Sustained error rates above this threshold will cause backoff.
 	MaxErrorRate float64
@@ -37,17 +52,330 @@ type AdaptiveConfig struct {
 	// system is under stress.
 	DecreaseStep int
 
-	// MinLimit is the lower bound on the allowed rate.
+	// MinLimit is the lower bound on the allowed rate. The limit never
+	// drops below one, whatever MinLimit and DecreaseStep are, since a
+	// limiter admitting nothing would record no outcomes to recover
+	// from; zero therefore behaves like one. Shedding all load is left to
+	// the circuit breaker; see BreakerDuration.
 	MinLimit int
 
-	// MaxLimit is the upper bound on the allowed rate.
+	// MaxLimit is the upper bound on the allowed rate, unless the
+	// ceiling ramp is enabled with AbsoluteMaxLimit.
 	MaxLimit int
 
+	// AbsoluteMaxLimit, if above MaxLimit, enables the ceiling ramp,
+	// which lets the limiter discover headroom beyond MaxLimit: once the
+	// limit has sat at its ceiling with healthy signals for
+	// CeilingProbeAfter, the ceiling itself is raised by CeilingStep, up
+	// to AbsoluteMaxLimit, and the limit may climb into the new room.
+	// Every decrease, whether for latency, errors or a signal, lowers the
+	// ceiling by CeilingStep again, but never below MaxLimit. Stats
+	// reports the ceiling in force as Ceiling. Zero disables the ramp, so
+	// MaxLimit is a hard cap.
+	AbsoluteMaxLimit int
+
+	// CeilingProbeAfter is how long the limit must stay healthy at its
+	// ceiling before the ceiling ramp raises it. Zero means one minute.
+	CeilingProbeAfter time.Duration
+
+	// CeilingStep is how much the ceiling ramp moves the ceiling at a
+	// time. Zero means IncreaseStep, or 1 if that is zero.
+	CeilingStep int
+
+	// GuaranteedRate is a minimum throughput the limiter must always
+	// admit, such as a contractual SLA, in requests per window. It is a
+	// hard floor the control loop never goes below, even under sustained
+	// errors or high latency, and takes precedence over a lower MinLimit.
+	// It also takes precedence over the circuit breaker: while it is set
+	// the breaker never opens. Zero means no guarantee beyond MinLimit.
+	GuaranteedRate int
+
 	// Cooldown specifies the minimum duration between consecutive
-	// limit adjustments. This helps prevent oscillation.
+	// limit adjustments. This helps prevent oscillation. It is the
+	// default for IncreaseCooldown and DecreaseCooldown. Zero lets the
+	// limit move on every evaluation of the control loop.
 	Cooldown time.Duration
+
+	// IncreaseCooldown is the minimum time after any adjustment before
+	// the limit may be raised, so recovery after a backoff is
+	// conservative. Zero means Cooldown.
+	IncreaseCooldown time.Duration
+
+	// DecreaseCooldown is the minimum time after a decrease before the
+	// limit may be lowered again. A recent increase never delays a
+	// decrease. Zero means Cooldown.
+	DecreaseCooldown time.Duration
+
+	// Warmup is a period after construction (or Reset) during which
+	// latency and error samples are recorded but the limit is not
+	// adjusted, so cold caches or uninitialized averages cannot cause
+	// premature backoff. The first adjustment after warmup is not
+	// delayed by Cooldown.
+	Warmup time.Duration
+
+	// MinSamples is the number of samples that must be recorded since
+	// the last adjustment before the control loop acts. Until then the
+	// limit is held, so a single outlier on a low-traffic service cannot
+	// collapse capacity. Zero disables the guard.
+	MinSamples int
+
+	// IdleDecay makes the control loop age the latency and error
+	// averages toward zero on every evaluation in which no sample was
+	// recorded, as described for EWMA.DecayTowards, so a limiter that
+	// backed off during a spike can recover during a quiet period. Such
+	// evaluations are not held back by MinSamples. Latency percentiles
+	// do not decay.
+	IdleDecay bool
+
+	// MinUtilization is the fraction of the current limit, in [0, 1),
+	// that must have been used since the last adjustment for the loop to
+	// raise the limit. Below it, healthy signals hold the limit instead,
+	// keeping it near actual demand so that a sudden burst cannot be
+	// admitted all at once. Usage is the busiest window's count in window
+	// modes, and is sampled when the loop runs in the other modes. Zero
+	// disables the check.
+	MinUtilization float64
+
+	// BreakerDuration turns the limiter into a last-resort circuit
+	// breaker: once the error rate has stayed above MaxErrorRate with the
+	// limit pinned at MinLimit for this long, the breaker opens and every
+	// request is rejected. After BreakerOpenDuration it becomes half-open
+	// and admits up to ProbeCount probe requests every ProbeInterval. An
+	// outcome recorded while half-open resolves an outstanding probe:
+	// ProbeCount probes in a row recorded without error and within the
+	// latency watermark close the breaker, while any other outcome opens
+	// it again. Wait callers queue while the breaker is not closed,
+	// except on a leaky bucket, where Wait returns ErrBreakerOpen. Zero
+	// disables the breaker, as does a non-zero GuaranteedRate.
+	BreakerDuration time.Duration
+
+	// BreakerOpenDuration is how long the breaker stays open before it
+	// becomes half-open. Zero means BreakerDuration.
+	BreakerOpenDuration time.Duration
+
+	// ProbeCount is the number of probes admitted per ProbeInterval while
+	// the breaker is half-open, and the number of healthy probes needed
+	// to close it. Zero means one.
+	ProbeCount int
+
+	// ProbeInterval is how often a new round of probes may start while
+	// the breaker is half-open, so probes whose outcomes are never
+	// recorded cannot keep it shut. Zero means BreakerOpenDuration.
+	ProbeInterval time.Duration
+
+	// OnProbe, if non-nil, is called with the outcome of every half-open
+	// probe. It runs on the recording goroutine without holding the
+	// limiter's lock.
+	OnProbe func(ProbeResult)
+
+	// DeadlineSlack is the minimum time a request's context must have
+	// left before its deadline for AllowCtx to admit it. Zero only
+	// rejects contexts that are already done.
+	DeadlineSlack time.Duration
+
+	// LatencyAlpha is the smoothing factor of the latency EWMA, in
+	// (0, 1]. Higher values react faster to change. Zero means 0.3.
+	// UpdateConfig keeps the current value; use SetLatencyAlpha to
+	// change it at runtime.
+	LatencyAlpha float64
+
+	// ErrorAlpha is the smoothing factor of the error rate EWMA, in
+	// (0, 1]. Higher values react faster to change. Zero means 0.2.
+	// UpdateConfig keeps the current value; use SetErrorAlpha to change
+	// it at runtime.
+	ErrorAlpha float64
+
+	// SampleRate is the fraction of outcomes passed to Record,
+	// RecordWeighted, RecordClassified and RecordResult that are fed to
+	// the latency and error averages, in (0, 1]. Outcomes are chosen at
+	// random, and each chosen one is weighted by 1/SampleRate, so the
+	// averages converge on the same values and decay at the same rate per
+	// request as with full recording. Sampling saves the locking of every
+	// average, percentile and histogram on the outcomes it skips, at the
+	// cost of noisier averages: the lower the rate, the more a single
+	// sampled outlier moves them, so keep SampleRate times the request
+	// rate well above the number of samples the averages need to settle.
+	// Skipped outcomes still count towards MinSamples, end half-open
+	// probes and free in-flight slots. RecordBatch is never sampled. Zero
+	// means 1, recording every outcome.
+	SampleRate float64
+
+	// Strategy selects how the limit is adjusted. The default,
+	// StrategyLinear, uses fixed IncreaseStep and DecreaseStep; see
+	// Strategy for the alternatives.
+	Strategy Strategy
+
+	// DecreaseFactor is the multiplier applied to the limit on each
+	// backoff when Strategy is StrategyAIMD, in (0, 1). Zero means 0.5.
+	// The limit always drops by at least one.
+	DecreaseFactor float64
+
+	// LatencyWeight and ErrorWeight set how much latency and the error
+	// rate each count towards the health score of StrategyWeighted, and
+	// so which signal dominates its decisions; a weight of zero ignores
+	// that signal. Only their ratio matters. Both zero weighs the signals
+	// equally. Stats reports the score as HealthScore whatever the
+	// Strategy.
+	LatencyWeight float64
+	ErrorWeight   float64
+
+	// UsePercentile makes the control loop compare a latency percentile,
+	// rather than the average, against TargetLatency, so that tail
+	// regressions trigger backoff. It is fixed at construction.
+	UsePercentile bool
+
+	// LatencyPercentile is the percentile compared against TargetLatency
+	// when UsePercentile is set, in (0, 1). Zero means 0.95. It is fixed
+	// at construction.
+	LatencyPercentile float64
+
+	// PriorityThresholds sets the fraction of capacity available to each
+	// priority in AllowPriority. Zero fields use the defaults.
+	PriorityThresholds PriorityThresholds
+
+	// Window is the duration of the admission window over which the
+	// limit applies. The limit is expressed in requests per Window.
+	// Zero means one second.
+	Window time.Duration
+
+	// AdjustInterval is how often the control loop evaluates latency and
+	// error signals. Zero means one second. Cooldown still applies on
+	// top of this interval.
+	AdjustInterval time.Duration
+
+	// Jitter randomizes when windows reset and when the control loop
+	// runs, so that many instances sharing a config and a downstream do
+	// not back off in waves. It is a fraction of Window and
+	// AdjustInterval in [0, 1): each tick lands within Jitter/2 of an
+	// interval of its unjittered time, so the average interval is
+	// unchanged and the ticks never drift. Zero disables jitter.
+	Jitter float64
+
+	// Decide, if non-nil, replaces the built-in comparison of latency
+	// and error rate against their thresholds with a custom policy; see
+	// Action. Strategy and the watermarks are then ignored, while the
+	// cooldowns, Warmup, MinSamples and MinUtilization still apply.
+	//
+	// It is called on the control loop goroutine while the limiter's
+	// lock is held, so it must not call back into the limiter.
+	Decide func(stats Stats) Action
+
+	// Controller, if non-nil, replaces the built-in adjustment step with
+	// a custom algorithm that computes the next limit directly; see
+	// Controller. It is ignored when Decide is set, and nil means
+	// StepController. Unless it is a StepController, Strategy, the
+	// watermarks and SignalThresholds are then ignored, while the
+	// cooldowns, Warmup, MinSamples and MinUtilization still apply, and
+	// the result is clamped to [MinLimit, MaxLimit].
+	//
+	// It is called on the control loop goroutine while the limiter's
+	// lock is held, so it must not call back into the limiter.
+	Controller Controller
+
+	// IdleTimeout, if positive, slows the control goroutine down once no
+	// request has been admitted, rejected or recorded for this long: it
+	// then wakes only every IdleTimeout, instead of every Window and
+	// AdjustInterval, until the next use, which restores the regular
+	// schedule and starts a fresh window if the current one is overdue.
+	// This cuts timer wakeups for mostly idle limiters, such as the
+	// per-key limiters of a KeyedLimiter. The loop never idles while
+	// callers of Wait are queued. Zero disables idle detection.
+	IdleTimeout time.Duration
+
+	// MaxWaiters bounds the number of callers that Wait and Reserve may
+	// queue for capacity; once it is reached they fail immediately with
+	// ErrWaiterQueueFull, so sustained overload cannot park an unbounded
+	// number of goroutines. It does not apply to leaky bucket limiters,
+	// whose bucket capacity bounds the queue. Zero means no bound.
+	MaxWaiters int
+
+	// MaxHoldDuration, if positive, bounds how long a concurrency slot
+	// taken with Acquire, AllowWithDone, AllowWithResult or
+	// AllowPriorityWithDone may be held. A slot whose release has not
+	// been called by then is reclaimed the next time the window resets,
+	// recorded as an error so that the leak informs adaptation, and
+	// reported to the logger as a warning; a late release is then a
+	// no-op. It guards against handlers that never release slowly
+	// starving the limiter. It has no effect in the rate modes or on
+	// slots taken with Allow. Zero disables the watchdog.
+	MaxHoldDuration time.Duration
+
+	// SignalThresholds maps the names of signals recorded with
+	// RecordSignal to the value above which the control loop lowers the
+	// limit, as it does for high latency. Signals without a threshold,
+	// and thresholds for signals never recorded, have no effect. It is
+	// ignored when Decide is set; policies read Stats.Signals instead.
+	SignalThresholds map[string]float64
+
+	// OnLimitChange, if non-nil, is called whenever the control loop
+	// changes the current limit, or when UpdateConfig clamps it. reason
+	// is one of the Reason constants. It is not passed the limiter's
+	// name; see WithName.
+	//
+	// It runs on the control loop goroutine without holding the
+	// limiter's lock, so it may safely call back into the limiter, but
+	// it should return quickly.
+	OnLimitChange func(old, new int, reason string)
+
+	// Shadow runs the limiter as a dry run, for tuning a config against
+	// production traffic before enforcing it: requests that would be
+	// rejected are admitted instead and counted in Stats.ShadowRejected,
+	// without firing OnReject. Admitted requests consume capacity as
+	// usual and the control loop keeps adapting. It applies to Allow and
+	// its variants, so the HTTP and gRPC wrappers never reject while it
+	// is set; Wait and Reserve still block.
+	Shadow bool
+
+	// OnReject, if non-nil, is called whenever Allow or AllowN rejects a
+	// request. It runs on the caller's goroutine without holding the
+	// limiter's lock and should be cheap. Like OnLimitChange, it is not
+	// passed the limiter's name.
+	OnReject func()
+
+	// OnSaturation, if non-nil, is called at the end of every window in
+	// which the fraction of Allow and AllowN decisions that rejected the
+	// request exceeded SaturationThreshold, with that fraction, so that
+	// applications can alert or scale up when a significant share of
+	// traffic is being shed. It runs on the control loop goroutine
+	// without holding the limiter's lock. Like OnLimitChange, it is not
+	// passed the limiter's name.
+	OnSaturation func(rejectedFraction float64)
+
+	// SaturationThreshold is the rejected fraction, in [0, 1), above
+	// which OnSaturation is called. Zero calls it for any window with a
+	// rejection.
+	SaturationThreshold float64
 }
 
+// Reasons reported to AdaptiveConfig.OnLimitChange.
+const (
+	// ReasonHighLatency means the limit was lowered because average
+	// latency exceeded TargetLatency.
+	ReasonHighLatency = "high_latency"
+
+	// ReasonHighErrorRate means the limit was lowered because the error
+	// rate exceeded MaxErrorRate.
+	ReasonHighErrorRate = "high_error_rate"
+
+	// ReasonHealthy means the limit was raised because both signals were
+	// within their thresholds.
+	ReasonHealthy = "healthy"
+
+	// ReasonReconfigured means the limit was clamped into new bounds by
+	// UpdateConfig.
+	ReasonReconfigured = "reconfigured"
+
+	// ReasonLowUtilization means the signals were healthy but the limit
+	// was held because too little of it was in use; see MinUtilization.
+	// It is only reported in Event, since the limit does not change.
+	ReasonLowUtilization = "low_utilization"
+
+	// ReasonWithinBand means the limit was held because latency was
+	// between the low and high watermarks around TargetLatency. It is
+	// only reported in Event, since the limit does not change.
+	ReasonWithinBand = "within_band"
+)
+
 // Limiter is an adaptive rate limiter that adjusts its throughput
 // based on observed latency and error signals.
 //
@@ -59,38 +387,237 @@ type AdaptiveConfig struct {
 type Limiter struct {
 	// unexported fields
 	mu             sync.Mutex
+	clock          Clock
 	baseLimit      int
-	currentLimit   int
-	count          int
 	lastReset      time.Time
 	lastAdjustment time.Time
 
+	// lastIncrease and lastDecrease are when the control loop last
+	// decided to raise or lower the limit, for the per-direction
+	// cooldowns.
+	lastIncrease time.Time
+	lastDecrease time.Time
+
+	// raisedCeiling is the ceiling set by the ceiling ramp, or zero if it
+	// has not raised one, and ceilingSince is when the limit was first
+	// seen healthy at the current ceiling.
+	raisedCeiling int
+	ceilingSince  time.Time
+
+	// signals holds the latest value of each signal recorded with
+	// RecordSignal.
+	signals map[string]float64
+
+	// overrideUntil is when the override set by OverrideLimit expires. It
+	// is the zero time if no override is active.
+	overrideUntil time.Time
+
+	// currentLimit and count are atomic so the fixed-window and
+	// concurrency modes can admit requests without taking mu. They are
+	// only ever stored while mu is held; see casAdmit.
+	currentLimit atomic.Int64
+	count        atomic.Int64
+
+	// shards, if non-nil, replaces count in fixed-window mode; see
+	// WithShardedCount.
+	shards []countShard
+
+	// disabled is set by SetEnabled(false) to admit every request.
+	disabled atomic.Bool
+
+	// idle is set while the control goroutine ticks only every
+	// IdleTimeout, and wakeCh tells it to resume its regular schedule.
+	idle   atomic.Bool
+	wakeCh chan struct{}
+
+	// draining is set by GracefulStop to reject every request. drainCh
+	// is closed at the same time to wake queued waiters, and idleCh, if
+	// non-nil, is closed once no requests are in flight.
+	draining atomic.Bool
+	drainCh  chan struct{}
+	idleCh   chan struct{}
+
+	// breaker holds the BreakerState, readable without mu. pinnedSince is
+	// when the limit was last seen pinned at MinLimit with a high error
+	// rate, breakerOpenedAt is when the breaker last opened, and probes
+	// tracks the current round of half-open probes.
+	breaker         atomic.Int32
+	pinnedSince     time.Time
+	breakerOpenedAt time.Time
+	probes          probeRound
+
+	// samples counts Record calls since the last adjustment, for
+	// MinSamples. It is updated outside mu.
+	samples atomic.Int64
+
+	// startedAt marks the beginning of the warmup period.
+	startedAt time.Time
+
 	latencyEWMA *EWMA
 	errorEWMA   *EWMA
 
+	// latencyQuantiles holds streaming percentile estimators, fed with
+	// the same microsecond samples as latencyEWMA. It is nil unless
+	// cfg.UsePercentile is set, and immutable after construction.
+	latencyQuantiles []*Quantile
+
+	// histogram buckets recorded latencies for export. It is nil unless
+	// WithLatencyHistogram is used.
+	histogram *latencyHistogram
+
 	cfg AdaptiveConfig
 
-	stopCh chan struct{}
+	// mode selects the admission algorithm used by Allow.
+	mode admissionMode
+
+	// token bucket state, used when mode is modeTokenBucket.
+	tokens     float64
+	burst      int
+	lastRefill time.Time
+
+	// windowPeak is the largest window count seen since the last
+	// evaluation, for MinUtilization.
+	windowPeak int
+
+	// drainedAt is when the leaky bucket will be empty, used when mode is
+	// modeLeakyBucket. The bucket's capacity is stored in burst.
+	drainedAt time.Time
+
+	// prevCount is the previous window's count, used when mode is
+	// modeSlidingWindow.
+	prevCount int
+
+	// allowedTotal and rejectedTotal count admission decisions made by
+	// AllowN over the limiter's lifetime. They are updated outside mu.
+	allowedTotal  atomic.Uint64
+	rejectedTotal atomic.Uint64
+
+	// shadowRejectedTotal counts requests admitted in Shadow mode that
+	// would otherwise have been rejected.
+	shadowRejectedTotal atomic.Uint64
+
+	// shadow, onReject and sampleRate mirror cfg.Shadow, cfg.OnReject
+	// and cfg.sampleRate() so that rejections and Record need not take
+	// mu; see publishConfig. sampleRate holds the bits of a float64.
+	shadow     atomic.Bool
+	onReject   atomic.Pointer[func()]
+	sampleRate atomic.Uint64
+
+	// windowAllowed and windowRejected are allowedTotal and
+	// rejectedTotal as of the last window reset, for OnSaturation.
+	windowAllowed  uint64
+	windowRejected uint64
+
+	// lastRejected is rejectedTotal as of the previous evaluation, and
+	// saturatedTicks counts consecutive evaluations that saw rejections.
+	lastRejected   uint64
+	saturatedTicks int
+
+	// logger receives control loop events. It is nil unless set with
+	// WithLogger.
+	logger Logger
+
+	// name is set by WithName and immutable after construction.
+	name string
+
+	// events is the channel returned by Events, created on first use and
+	// closed when the adaptive loop stops.
+	events       chan Event
+	eventsClosed bool
+
+	// waiters holds the FIFO queue of goroutines parked in Wait.
+	waiters *list.List
+
+	// peakWaiters is the longest waiters has been since creation or
+	// Reset, and waitEWMA smooths the microseconds queued callers spent
+	// waiting before being granted capacity.
+	peakWaiters int
+	waitEWMA    *EWMA
+
+	// holds tracks the slots watched for MaxHoldDuration, oldest first.
+	holds *list.List
+
+	// stopped is set by Stop; see Stop for what it disables.
+	stopped  atomic.Bool
+	stopCh   chan struct{}
+	stopOnce sync.Once
 }
 
 // NewAdaptivePerSecond creates a new adaptive rate limiter that
 // starts at the given initial rate (requests per second) and
 // adjusts over time using the provided configuration.
 //
+// If cfg.Window is set, the rate is instead requests per Window.
+//
+// NewAdaptivePerSecond does not fail on invalid input. Instead it clamps
+// safely: negative steps and bounds are treated as zero, a non-positive
+// MaxLimit falls back to the initial limit, a MinLimit above MaxLimit
+// raises MaxLimit to match, and the initial limit is clamped into
+// [MinLimit, MaxLimit]. Use NewAdaptivePerSecondWithError to reject
+// invalid configurations instead.
+//
 // The returned Limiter starts a background control loop and should
 // be stopped by calling Stop when no longer needed.
-func NewAdaptivePerSecond(limit int, cfg AdaptiveConfig) *Limiter {
+func NewAdaptivePerSecond(limit int, cfg AdaptiveConfig, opts ...Option) *Limiter {
+	cfg = cfg.sanitize(limit)
+	return newLimiter(cfg.clampLimit(limit), cfg, nil, opts)
+}
+
+// NewAdaptivePerMinute is like NewAdaptivePerSecond but uses a one-minute
+// window, so limit and the configured bounds are requests per minute.
+// It overrides cfg.Window.
+func NewAdaptivePerMinute(limit int, cfg AdaptiveConfig, opts ...Option) *Limiter {
+	cfg.Window = time.Minute
+	return NewAdaptivePerSecond(limit, cfg, opts...)
+}
+
+// NewAdaptivePerSecondWithError is like NewAdaptivePerSecond but
+// validates cfg first, returning an error wrapping ErrInvalidConfig if
+// it is unusable. The initial limit is clamped into [MinLimit, MaxLimit].
+func NewAdaptivePerSecondWithError(limit int, cfg AdaptiveConfig, opts ...Option) (*Limiter, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return newLimiter(cfg.clampLimit(limit), cfg, nil, opts), nil
+}
+
+// newLimiter constructs a limiter, lets setup configure its admission
+// mode, and then starts the control goroutine.
+func newLimiter(limit int, cfg AdaptiveConfig, setup func(*Limiter), opts []Option) *Limiter {
+	o := newOptions(opts)
+
 	limiter := &Limiter{
-		baseLimit:    limit,
-		currentLimit: limit,
-		lastReset:    time.Now(),
-		cfg:          cfg,
-		latencyEWMA:  NewEWMA(0.3),
-		errorEWMA:    NewEWMA(0.2),
-		stopCh:       make(chan struct{}),
-	}
-	limiter.startResetLoop()
-	limiter.startAdaptiveLoop()
+		clock:       o.clock,
+		logger:      o.logger,
+		name:        o.name,
+		baseLimit:   limit,
+		lastReset:   o.clock.Now(),
+		startedAt:   o.clock.Now(),
+		cfg:         cfg,
+		latencyEWMA: NewEWMA(cfg.latencyAlpha()),
+		errorEWMA:   NewEWMA(cfg.errorAlpha()),
+		waitEWMA:    NewEWMA(cfg.latencyAlpha()),
+		waiters:     list.New(),
+		holds:       list.New(),
+		stopCh:      make(chan struct{}),
+		drainCh:     make(chan struct{}),
+		wakeCh:      make(chan struct{}, 1),
+	}
+	limiter.setLimit(limit)
+	if cfg.UsePercentile {
+		limiter.latencyQuantiles = newLatencyQuantiles(cfg.latencyPercentile())
+	}
+	if o.histogram != nil {
+		limiter.histogram = newLatencyHistogram(o.histogram)
+	}
+	if setup != nil {
+		setup(limiter)
+	}
+	if o.shards > 0 && limiter.mode == modeFixedWindow {
+		limiter.shards = make([]countShard, o.shards)
+	}
+	limiter.publishConfig()
+	limiter.startLoop()
 	return limiter
 }
 
@@ -101,78 +628,511 @@ func NewAdaptivePerSecond(limit int, cfg AdaptiveConfig) *Limiter {
 //
 // Allow is safe to call concurrently and is designed to be lightweight.
 func (l *Limiter) Allow() bool {
-	l.mu.Lock()
-	defer l.mu.Unlock()
+	return l.AllowN(1)
+}
+
+// AllowWithDone is like Allow but also returns a completion callback that
+// couples admission with Record.
+//
+// The done callback captures the admission time; calling it records the
+// elapsed latency and the given error in one step. Only the first call
+// has any effect. If ok is false, done is a no-op, so callers may invoke
+// it unconditionally.
+//
+// Allow and Record remain available for callers that track latency
+// themselves.
+func (l *Limiter) AllowWithDone() (ok bool, done func(err error)) {
+	return l.allowWithDone(l.Allow(), func(start time.Time, err error) {
+		l.Record(l.clock.Now().Sub(start), err)
+	})
+}
+
+// AllowWithResult is like AllowWithDone, but done records only the error,
+// with RecordResult, for callers that record latencies themselves, such
+// as per message of a stream. The slot is watched for MaxHoldDuration
+// and released by done as with AllowWithDone.
+func (l *Limiter) AllowWithResult() (ok bool, done func(err error)) {
+	return l.allowWithDone(l.Allow(), func(_ time.Time, err error) {
+		l.RecordResult(err)
+	})
+}
 
-	if l.count >= l.currentLimit {
-		return false
+// allowWithDone implements AllowWithDone and its variants for a request
+// that admitted reports the outcome of. The first call of done stops
+// watching the slot and, unless it was reclaimed, passes the admission
+// time and error to record.
+func (l *Limiter) allowWithDone(admitted bool, record func(start time.Time, err error)) (ok bool, done func(err error)) {
+	if !admitted {
+		return false, func(error) {}
 	}
 
-	l.count++
-	return true
+	start := l.clock.Now()
+	h := l.watchHold(start)
+	var once sync.Once
+	return true, func(err error) {
+		once.Do(func() {
+			if l.releaseHold(h) {
+				record(start, err)
+			}
+		})
+	}
+}
+
+// AllowN reports whether a request costing n units is allowed under the
+// current rate limit, and if so consumes n units of capacity.
+//
+// Admission is all-or-nothing: if the request does not fit in the
+// remaining capacity (count+n would exceed the current limit, or fewer
+// than n tokens are available in token bucket mode), AllowN returns false
+// and consumes nothing. In particular, a request with n greater than the
+// current limit (or the burst size) is never admitted.
+//
+// A request must cost at least one unit; AllowN returns false for n <= 0.
+func (l *Limiter) AllowN(n int) bool {
+	_, ok := l.takeN(n)
+	return ok
+}
+
+// takeN is AllowN, also reporting what an admitted request took.
+func (l *Limiter) takeN(n int) (grant, bool) {
+	if n <= 0 {
+		return grant{}, false
+	}
+	return l.allowN(n, 1)
 }
 
-func (l *Limiter) startResetLoop() {
-	ticker := time.NewTicker(time.Second)
+// grant is what allowN took to admit a request, so that it can be handed
+// back exactly.
+type grant struct {
+	// units is the capacity consumed. It is zero for requests admitted
+	// while disabled or in Shadow mode, which consume nothing.
+	units int
 
+	// probe is set if the request is a half-open breaker probe.
+	probe bool
+
+	// shadow is set if the request was admitted only by Shadow mode.
+	shadow bool
+}
+
+// allowN admits n units if they fit within fraction of the current
+// capacity, updating counters and firing OnReject.
+func (l *Limiter) allowN(n int, fraction float64) (grant, bool) {
+	if l.draining.Load() || l.stopped.Load() {
+		return grant{}, false
+	}
+	l.wake()
+	if l.disabled.Load() {
+		l.allowedTotal.Add(1)
+		return grant{}, true
+	}
+
+	breakerOK, probe := true, false
+	if l.breaker.Load() != int32(BreakerClosed) {
+		breakerOK, probe = l.admitProbe()
+	}
+
+	var ok bool
+	switch {
+	case !breakerOK:
+		// The breaker is open, or a half-open probe is outstanding.
+	case l.overLimit(n):
+		// Saturated: the request would be rejected under mu as well.
+	case fraction >= 1 && l.lockFree():
+		ok = l.casAdmit(n)
+	default:
+		l.mu.Lock()
+		now := l.clock.Now()
+		ok = (fraction >= 1 || l.withinFraction(n, fraction, now)) && l.admit(n, now)
+		l.mu.Unlock()
+	}
+	if probe && !ok {
+		l.cancelProbe()
+	}
+
+	if ok {
+		l.allowedTotal.Add(1)
+		return grant{units: n, probe: probe}, true
+	}
+
+	if l.shadow.Load() {
+		l.shadowRejectedTotal.Add(1)
+		l.allowedTotal.Add(1)
+		return grant{shadow: true}, true
+	}
+
+	l.rejectedTotal.Add(1)
+	if onReject := l.onReject.Load(); onReject != nil {
+		(*onReject)()
+	}
+	return grant{}, false
+}
+
+// publishConfig publishes the fields of cfg that allowN and record read
+// without l.mu.
+//
+// The caller must hold l.mu, or own l exclusively.
+func (l *Limiter) publishConfig() {
+	l.shadow.Store(l.cfg.Shadow)
+	if fn := l.cfg.OnReject; fn != nil {
+		l.onReject.Store(&fn)
+	} else {
+		l.onReject.Store(nil)
+	}
+	l.sampleRate.Store(math.Float64bits(l.cfg.sampleRate()))
+}
+
+// startLoop starts the control goroutine, which resets the admission
+// window every Window and runs the adaptive control loop every
+// AdjustInterval. Both run off a single ticker, reset to whichever is due
+// first; when both are due at once the window is reset first. After
+// IdleTimeout without activity the ticker slows to IdleTimeout until the
+// next use; see AdaptiveConfig.IdleTimeout.
+func (l *Limiter) startLoop() {
+	now := l.clock.Now()
+	reset := newTickSchedule(now, l.cfg.window(), l.cfg.Jitter)
+	adapt := newTickSchedule(now, l.cfg.adjustInterval(), l.cfg.Jitter)
+	ticker := l.clock.NewTicker(max(earlier(reset.next, adapt.next).Sub(now), 1))
+
+	lastActivity, activeAt := l.activity(), now
 	go func() {
 		defer ticker.Stop()
 		for {
 			select {
-			case <-ticker.C:
+			case <-ticker.C():
+				now := l.clock.Now()
+				if reset.due(now) {
+					l.resetTick(now, reset)
+				}
+				if adapt.due(now) {
+					l.adaptiveTick(now, adapt)
+				}
+
+				if a := l.activity(); a != lastActivity {
+					lastActivity, activeAt = a, now
+				}
+				if timeout := l.idleTimeout(); timeout > 0 && now.Sub(activeAt) >= timeout && l.enterIdle() {
+					// Re-check, in case a request slipped in
+					// before the idle flag was set.
+					if l.activity() == lastActivity {
+						ticker.Reset(timeout)
+						continue
+					}
+					l.wake()
+				}
+				ticker.Reset(max(earlier(reset.next, adapt.next).Sub(now), 1))
+
+			case <-l.wakeCh:
+				now := l.clock.Now()
+				reset = newTickSchedule(now, reset.interval, reset.jitter)
+				adapt = newTickSchedule(now, adapt.interval, adapt.jitter)
+				lastActivity, activeAt = l.activity(), now
+				ticker.Reset(max(earlier(reset.next, adapt.next).Sub(now), 1))
+
+			case <-l.stopCh:
 				l.mu.Lock()
-				l.count = 0
-				l.lastReset = time.Now()
+				l.closeEvents()
 				l.mu.Unlock()
-			case <-l.stopCh:
 				return
 			}
 		}
 	}()
 }
 
-func (l *Limiter) startAdaptiveLoop() {
-	ticker := time.NewTicker(time.Second)
+// idleTimeout returns the configured IdleTimeout.
+func (l *Limiter) idleTimeout() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.cfg.IdleTimeout
+}
+
+// resetTick starts a new admission window, grants queued waiters,
+// reclaims slots held past MaxHoldDuration and reports saturation of the
+// window that ended.
+func (l *Limiter) resetTick(now time.Time, sched *tickSchedule) {
+	l.mu.Lock()
+	l.resetWindow(now)
+	l.grantWaiters()
+	sched.tick(now, l.cfg.window(), l.cfg.Jitter)
+	expired := l.expireHolds(now)
+	onSaturation := l.cfg.OnSaturation
+	fraction, saturated := l.windowSaturation()
+	l.mu.Unlock()
 
-	go func() {
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ticker.C:
-				l.mu.Lock()
+	l.reclaimHolds(expired, now)
+	if saturated && onSaturation != nil {
+		onSaturation(fraction)
+	}
+}
 
-				now := time.Now()
-				if now.Sub(l.lastAdjustment) < l.cfg.Cooldown {
-					l.mu.Unlock()
-					continue
-				}
+// windowSaturation returns the fraction of admission decisions since the
+// last call that rejected the request, and whether it exceeded
+// SaturationThreshold.
+//
+// The caller must hold l.mu.
+func (l *Limiter) windowSaturation() (float64, bool) {
+	allowedTotal, rejectedTotal := l.allowedTotal.Load(), l.rejectedTotal.Load()
+	allowed := allowedTotal - l.windowAllowed
+	rejected := rejectedTotal - l.windowRejected
+	l.windowAllowed, l.windowRejected = allowedTotal, rejectedTotal
 
-				avgLatency := time.Duration(l.latencyEWMA.Value()) * time.Millisecond
-				errorRate := l.errorEWMA.Value()
+	if rejected == 0 {
+		return 0, false
+	}
+	fraction := float64(rejected) / float64(allowed+rejected)
+	return fraction, fraction > l.cfg.SaturationThreshold
+}
 
-				if avgLatency > l.cfg.TargetLatency || errorRate > l.cfg.MaxErrorRate {
-					l.decreaseLimit()
-				} else {
-					l.increaseLimit()
-				}
+// adaptiveTick runs one evaluation of the adaptive control loop.
+func (l *Limiter) adaptiveTick(now time.Time, sched *tickSchedule) {
+	l.mu.Lock()
 
-				l.lastAdjustment = now
-				l.mu.Unlock()
+	interval := sched.interval
+	sched.tick(now, l.cfg.adjustInterval(), l.cfg.Jitter)
 
-			case <-l.stopCh:
-				return
-			}
+	idle := l.cfg.IdleDecay && l.samples.Load() == 0
+	if idle {
+		l.latencyEWMA.DecayTowards(0, interval)
+		l.errorEWMA.DecayTowards(0, interval)
+	}
+	if now.Sub(l.startedAt) < l.cfg.Warmup || l.overridden(now) {
+		l.mu.Unlock()
+		return
+	}
+	l.stepBreaker(now)
+	if !idle && l.samples.Load() < int64(l.cfg.MinSamples) {
+		l.mu.Unlock()
+		return
+	}
+
+	d, ok := l.adjust(now, false)
+	if !ok {
+		l.mu.Unlock()
+		return
+	}
+	l.emit(d.event(now))
+	onLimitChange := l.cfg.OnLimitChange
+	l.mu.Unlock()
+
+	l.report(d, onLimitChange)
+}
+
+// decision describes one evaluation of the control loop.
+type decision struct {
+	latency   time.Duration
+	errorRate float64
+	oldLimit  int
+	newLimit  int
+	reason    string
+
+	// pinned is set when the limit has just been driven down to
+	// MinLimit.
+	pinned bool
+
+	// saturated is set when requests have been rejected on
+	// saturationTicks consecutive evaluations.
+	saturated bool
+
+	// oldCeiling and newCeiling are the ceiling before and after the
+	// ceiling ramp ran.
+	oldCeiling int
+	newCeiling int
+}
+
+// saturationTicks is the number of consecutive evaluations with rejections
+// after which the limiter reports sustained saturation.
+const saturationTicks = 3
+
+// adjust evaluates the latency and error signals and moves the current
+// limit accordingly. It reports false, leaving the limiter untouched, if
+// the cooldown for the direction the limit would move has not elapsed,
+// unless force is set.
+//
+// The caller must hold l.mu.
+func (l *Limiter) adjust(now time.Time, force bool) (decision, bool) {
+	avgLatency := l.averageLatency()
+	if l.cfg.UsePercentile {
+		avgLatency = l.latencyQuantile(l.cfg.latencyPercentile())
+	}
+	errorRate := l.errorEWMA.Value()
+	oldLimit := l.limit()
+
+	c := l.loopConfig()
+	var next int
+	var dir Decision
+	var reason string
+	switch {
+	case c.Decide != nil:
+		action := c.Decide(l.snapshot())
+		dir, reason = action.Decision, action.reason()
+		if dir != DecisionIncrease && dir != DecisionDecrease {
+			dir = DecisionHold
+		} else {
+			next = c.apply(oldLimit, action)
 		}
-	}()
+	default:
+		var ctrl Controller = StepController{}
+		if c.Controller != nil {
+			ctrl = c.Controller
+		}
+		if s, ok := ctrl.(stepper); ok {
+			next, dir, reason = s.nextStep(oldLimit, l.snapshot(), c)
+		} else {
+			raw := ctrl.Next(oldLimit, l.snapshot(), c)
+			next, dir, reason = c.clampLimit(raw), direction(oldLimit, raw), ReasonController
+		}
+	}
+	decrease, hold := dir == DecisionDecrease, dir == DecisionHold
+
+	if decrease {
+		if !force && now.Sub(l.lastDecrease) < l.cfg.decreaseCooldown() {
+			return decision{}, false
+		}
+		l.lastDecrease = now
+	} else {
+		last := l.lastIncrease
+		if l.lastDecrease.After(last) {
+			last = l.lastDecrease
+		}
+		if !force && now.Sub(last) < l.cfg.increaseCooldown() {
+			return decision{}, false
+		}
+		if !hold && l.utilization(now) < l.cfg.MinUtilization {
+			hold, reason = true, ReasonLowUtilization
+		}
+		if !hold {
+			l.lastIncrease = now
+		}
+	}
+	l.windowPeak = 0
+
+	if !hold {
+		l.setLimit(next)
+	}
+	newLimit := l.limit()
+	oldCeiling, newCeiling := l.rampCeiling(now, dir, hold, newLimit)
+	l.samples.Store(0)
+
+	rejected := l.rejectedTotal.Load()
+	if rejected > l.lastRejected {
+		l.saturatedTicks++
+	} else {
+		l.saturatedTicks = 0
+	}
+	l.lastRejected = rejected
+
+	l.lastAdjustment = now
+	l.grantWaiters()
+
+	return decision{
+		latency:   avgLatency,
+		errorRate: errorRate,
+		oldLimit:  oldLimit,
+		newLimit:  newLimit,
+		reason:    reason,
+		pinned:    newLimit == l.cfg.floor() && oldLimit != newLimit,
+		saturated: l.saturatedTicks == saturationTicks,
+
+		oldCeiling: oldCeiling,
+		newCeiling: newCeiling,
+	}, true
 }
 
+// report runs the side effects of a decision that must happen outside
+// l.mu: the OnLimitChange callback and logging.
+func (l *Limiter) report(d decision, onLimitChange func(int, int, string)) {
+	if onLimitChange != nil && d.newLimit != d.oldLimit {
+		onLimitChange(d.oldLimit, d.newLimit, d.reason)
+	}
+	l.logDecision(d)
+}
+
+// ForceAdjust runs one pass of the control loop immediately, using the
+// current latency and error averages, and returns the resulting limit.
+// It ignores Warmup, MinSamples and the cooldowns, but otherwise behaves
+// like a scheduled evaluation: it emits an Event, calls OnLimitChange
+// and logs the decision. It lets tests drive the limiter without
+// waiting for the loop.
+func (l *Limiter) ForceAdjust() int {
+	l.mu.Lock()
+	now := l.clock.Now()
+	d, _ := l.adjust(now, true)
+	l.emit(d.event(now))
+	onLimitChange := l.cfg.OnLimitChange
+	l.mu.Unlock()
+
+	l.report(d, onLimitChange)
+	return d.newLimit
+}
+
+// ErrStopped is returned by Wait and Reserve once Stop has been called.
+var ErrStopped = errors.New("adaptiveratelimit: limiter is stopped")
+
 // Stop terminates the limiter's background control loop and releases
 // associated resources.
 //
-// Stop should be called when the limiter is no longer needed.
+// Stop should be called when the limiter is no longer needed; a stopped
+// limiter cannot be restarted. Without the loop its windows would never
+// reset and its limit never adapt, so a stopped limiter fails closed:
+// from the first call on, Allow and its variants return false without
+// counting a rejection or firing OnReject, and Wait and Reserve return
+// ErrStopped, including for callers already queued. Record and its
+// variants become no-ops, so late outcomes of requests admitted before
+// Stop are discarded cheaply. After GracefulStop, Wait and Reserve keep
+// returning ErrDraining. Accessors such as Stats keep reporting the state
+// at the time of the call.
+//
 // It is safe to call Stop multiple times.
 func (l *Limiter) Stop() {
-	close(l.stopCh)
+	l.stopOnce.Do(func() {
+		l.stopped.Store(true)
+		close(l.stopCh)
+	})
+}
+
+// Reset returns the limiter to its initial state without stopping it.
+//
+// The window count is cleared, the current limit returns to the initial
+// limit (clamped into the configured bounds), the cooldown and any
+// override are cleared, the warmup period restarts, the circuit breaker
+// closes and both latency and error averages are discarded. Lifetime
+// counters such as Stats.AllowedTotal are preserved, and the control
+// goroutine keeps running.
+//
+// In concurrency mode Reset also forgets in-flight requests, so callers
+// should only reset a concurrency limiter while it is idle.
+func (l *Limiter) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	l.swapCount()
+	l.prevCount = 0
+	l.windowPeak = 0
+	l.tokens = float64(l.burst)
+	l.lastRefill = now
+	l.drainedAt = time.Time{}
+	l.lastReset = now
+	l.setLimit(l.cfg.clampLimit(l.baseLimit))
+	l.lastAdjustment = time.Time{}
+	l.lastIncrease = time.Time{}
+	l.lastDecrease = time.Time{}
+	l.overrideUntil = time.Time{}
+	l.raisedCeiling = 0
+	l.ceilingSince = time.Time{}
+	l.startedAt = now
+	l.samples.Store(0)
+	l.latencyEWMA.Reset()
+	l.errorEWMA.Reset()
+	l.waitEWMA.Reset()
+	l.peakWaiters = l.waiters.Len()
+	for _, q := range l.latencyQuantiles {
+		q.Reset()
+	}
+	l.breaker.Store(int32(BreakerClosed))
+	l.pinnedSince = time.Time{}
+	l.probes = probeRound{}
+	l.grantWaiters()
 }
 
 // Record records the outcome of a completed request.
@@ -182,51 +1142,292 @@ func (l *Limiter) Stop() {
 // to the error rate.
 //
 // Callers should invoke Record once per request after processing completes.
+// In concurrency mode, Record also frees the request's in-flight slot.
 func (l *Limiter) Record(latency time.Duration, err error) {
-	l.latencyEWMA.Update(float64(latency.Milliseconds()))
+	l.RecordWeighted(latency, err, 1)
+}
 
+// RecordWeighted records the outcome of a completed request whose cost is
+// weight times that of a typical request, so that an expensive batch
+// operation moves the latency and error averages more than a cheap
+// health check.
+//
+// A sample of weight w has the influence of w unit samples recorded in a
+// row: it is blended into each average with an effective smoothing factor
+// of 1 - (1-alpha)^w, where alpha is LatencyAlpha or ErrorAlpha. Weights
+// below 1 therefore have less influence than Record. Latency percentiles,
+// when enabled, count each sample once regardless of weight.
+//
+// A non-positive weight leaves the averages untouched, but still frees
+// the request's in-flight slot in concurrency mode.
+func (l *Limiter) RecordWeighted(latency time.Duration, err error, weight float64) {
+	severity := 0.0
 	if err != nil {
-		l.errorEWMA.Update(1)
+		severity = 1
+	}
+	l.record(latency, severity, weight, err)
+}
+
+// errSoftFailure stands in for the error of an outcome recorded with
+// RecordClassified with a positive severity.
+var errSoftFailure = errors.New("adaptiveratelimit: classified failure")
+
+// RecordClassified records the outcome of a completed request whose
+// failure is graded by severity, between 0 (success) and 1 (hard
+// failure), so that soft failures raise the error rate less than hard
+// ones. Severity is fed to the error average in place of the 0 or 1 that
+// Record uses, and values outside [0, 1] are clamped.
+//
+// As a guide, record a request that succeeded after a retry or returned
+// a degraded response at around 0.1 to 0.3, a client-visible timeout
+// that a retry is likely to absorb at around 0.5, and server errors such
+// as an HTTP 500 or a gRPC Unavailable at 1. Caller mistakes, such as
+// HTTP 4xx statuses, should usually count as successes.
+//
+// Any positive severity counts as a failure for a half-open probe. In
+// concurrency mode, RecordClassified frees the request's in-flight slot
+// like Record.
+func (l *Limiter) RecordClassified(latency time.Duration, severity float64) {
+	var err error
+	if severity > 0 {
+		err = errSoftFailure
 	} else {
-		l.errorEWMA.Update(0)
+		severity = 0
 	}
+	l.record(latency, min(severity, 1), 1, err)
 }
 
-func (l *Limiter) increaseLimit() {
-	l.currentLimit += l.cfg.IncreaseStep
-	if l.currentLimit > l.cfg.MaxLimit {
-		l.currentLimit = l.cfg.MaxLimit
+// record implements RecordWeighted and RecordClassified, feeding
+// severity to the error average.
+func (l *Limiter) record(latency time.Duration, severity, weight float64, err error) {
+	if l.stopped.Load() {
+		return
+	}
+	l.wake()
+	if weight > 0 {
+		l.samples.Add(1)
 	}
+	if scale, ok := l.sampled(); ok && weight > 0 {
+		weight *= scale
+		us := micros(latency)
+		l.latencyEWMA.UpdateWeighted(us, weight)
+		for _, q := range l.latencyQuantiles {
+			q.Update(us)
+		}
+		if l.histogram != nil {
+			l.histogram.observe(latency)
+		}
+		l.errorEWMA.UpdateWeighted(severity, weight)
+	}
+
+	l.recordProbe(latency, err)
+	l.releaseSlot()
 }
 
-func (l *Limiter) decreaseLimit() {
-	l.currentLimit -= l.cfg.DecreaseStep
-	if l.currentLimit < l.cfg.MinLimit {
-		l.currentLimit = l.cfg.MinLimit
+// sampled reports whether to feed an outcome to the averages, choosing
+// it with probability SampleRate, and the factor by which its weight is
+// then multiplied to stand in for the outcomes that are skipped.
+//
+// It is safe to call without l.mu.
+func (l *Limiter) sampled() (float64, bool) {
+	rate := math.Float64frombits(l.sampleRate.Load())
+	if rate >= 1 {
+		return 1, true
 	}
+	if rand.Float64() >= rate {
+		return 0, false
+	}
+	return 1 / rate, true
 }
 
-// CurrentLimit returns the current allowed rate.
+// RecordResult records whether a completed request succeeded, for call
+// sites that have no meaningful latency, such as a fire-and-forget
+// publish. Only the error rate is updated; the latency average and
+// percentiles are left untouched rather than being pulled down by a
+// fabricated zero latency. Mixing RecordResult and Record on one limiter
+// is fine.
+//
+// In concurrency mode, RecordResult frees the request's in-flight slot
+// like Record.
+func (l *Limiter) RecordResult(err error) {
+	if l.stopped.Load() {
+		return
+	}
+	l.wake()
+	l.samples.Add(1)
+	if scale, ok := l.sampled(); ok {
+		severity := 0.0
+		if err != nil {
+			severity = 1
+		}
+		l.errorEWMA.UpdateWeighted(severity, scale)
+	}
+
+	l.recordProbe(0, err)
+	l.releaseSlot()
+}
+
+// Sample is the outcome of one completed request, for RecordBatch.
+type Sample struct {
+	// Latency is how long the request took.
+	Latency time.Duration
+
+	// Err is non-nil if the request failed.
+	Err error
+}
+
+// RecordBatch records many request outcomes at once, as if Record had
+// been called for each sample in slice order, but takes each average's
+// lock only once. It suits callers that aggregate outcomes and flush them
+// periodically, or that replay historical data.
+//
+// Unlike Record, RecordBatch never frees in-flight slots in concurrency
+// mode.
+func (l *Limiter) RecordBatch(samples []Sample) {
+	if len(samples) == 0 || l.stopped.Load() {
+		return
+	}
+
+	l.wake()
+	l.samples.Add(int64(len(samples)))
+
+	l.latencyEWMA.mu.Lock()
+	for _, s := range samples {
+		l.latencyEWMA.update(micros(s.Latency), 1)
+	}
+	l.latencyEWMA.mu.Unlock()
+
+	for _, q := range l.latencyQuantiles {
+		for _, s := range samples {
+			q.Update(micros(s.Latency))
+		}
+	}
+	if l.histogram != nil {
+		for _, s := range samples {
+			l.histogram.observe(s.Latency)
+		}
+	}
+
+	l.errorEWMA.mu.Lock()
+	for _, s := range samples {
+		if s.Err != nil {
+			l.errorEWMA.update(1, 1)
+		} else {
+			l.errorEWMA.update(0, 1)
+		}
+	}
+	l.errorEWMA.mu.Unlock()
+}
+
+// Name returns the name set with WithName, or "" if the limiter is
+// unnamed.
+func (l *Limiter) Name() string {
+	return l.name
+}
+
+// CurrentLimit returns the current allowed rate, in requests per window.
 func (l *Limiter) CurrentLimit() int {
+	return l.limit()
+}
+
+// Remaining returns how many more units can be admitted right now
+// without exceeding the current limit.
+//
+// In token bucket mode this is the number of whole tokens available, in
+// leaky bucket mode the room left in the bucket, and in concurrency mode
+// the number of free in-flight slots.
+func (l *Limiter) Remaining() int {
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	return l.currentLimit
+
+	return l.remaining(l.clock.Now())
 }
 
-// ErrorRate returns the current smoothed error rate.
+// remaining reports the capacity left under the current limit.
 //
-// The returned value is between 0.0 and 1.0.
-func (l *Limiter) ErrorRate() float64 {
+// The caller must hold l.mu.
+func (l *Limiter) remaining(now time.Time) int {
+	var used float64
+	switch l.mode {
+	case modeTokenBucket:
+		l.takeTokens(0, now)
+		return int(l.tokens)
+	case modeLeakyBucket:
+		return l.burst - l.bucketDepth(now)
+	case modeSlidingWindow:
+		used = float64(l.prevCount)*l.slidingOverlap(now) + float64(l.count.Load())
+	default:
+		used = float64(l.windowCount())
+	}
+	return max(l.limit()-int(math.Ceil(used)), 0)
+}
+
+// TimeToReset returns the time remaining until the current window
+// resets and its capacity becomes available again.
+//
+// In token bucket mode it returns the time until the next token refills,
+// in leaky bucket mode the time until the bucket has room again, and in
+// concurrency mode, which has no window, it returns zero.
+//
+// The result is an estimate: the window resets when its ticker fires,
+// which may be slightly later than the window boundary.
+func (l *Limiter) TimeToReset() time.Duration {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	return l.errorEWMA.Value()
+	return l.timeToReset(l.clock.Now())
+}
+
+// timeToReset implements TimeToReset.
+//
+// The caller must hold l.mu.
+func (l *Limiter) timeToReset(now time.Time) time.Duration {
+	switch l.mode {
+	case modeConcurrency:
+		return 0
+	case modeTokenBucket:
+		l.takeTokens(0, now)
+		limit := l.limit()
+		if l.tokens >= 1 || limit <= 0 {
+			return 0
+		}
+		return time.Duration((1 - l.tokens) / float64(limit) * float64(l.cfg.window()))
+	case modeLeakyBucket:
+		// Room frees up once the oldest request beyond capacity-1
+		// drains.
+		full := l.drainedAt.Add(-time.Duration(l.burst-1) * l.drainInterval())
+		return max(full.Sub(now), 0)
+	default:
+		return max(l.cfg.window()-now.Sub(l.lastReset), 0)
+	}
+}
+
+// ErrorRate returns the current smoothed error rate.
+//
+// The returned value is between 0.0 and 1.0.
+func (l *Limiter) ErrorRate() float64 {
+	return l.Snapshot().ErrorRate
 }
 
 // AverageLatency returns the current smoothed average request latency.
 func (l *Limiter) AverageLatency() time.Duration {
-	l.mu.Lock()
-	defer l.mu.Unlock()
+	return l.Snapshot().AverageLatency
+}
+
+// averageLatency converts the latency EWMA, which is fed microsecond
+// samples by Record, back into a Duration.
+func (l *Limiter) averageLatency() time.Duration {
+	return fromMicros(l.latencyEWMA.Value())
+}
+
+// micros converts a latency to the fractional microseconds the latency
+// EWMA and quantiles are kept in, so sub-millisecond latencies are not
+// truncated.
+func micros(d time.Duration) float64 {
+	return float64(d) / float64(time.Microsecond)
+}
 
-	return time.Duration(l.latencyEWMA.Value())
+// fromMicros converts fractional microseconds back into a Duration.
+func fromMicros(us float64) time.Duration {
+	return time.Duration(us * float64(time.Microsecond))
 }
@@ -2,6 +2,11 @@ package adaptiveratelimit
 
 import (
 	"errors"
+	"math"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -48,7 +53,8 @@ func TestLimiterResetsAfterOneSecond(t *testing.T) {
 }
 
 func TestLimiterDecreasesLimitOnHighLatency(t *testing.T) {
-	limiter := NewAdaptivePerSecond(10, cfg)
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(10, cfg, WithClock(clock))
 	defer limiter.Stop()
 
 	// simulate bad latency
@@ -56,7 +62,7 @@ func TestLimiterDecreasesLimitOnHighLatency(t *testing.T) {
 		limiter.Record(500*time.Millisecond, nil)
 	}
 
-	time.Sleep(1100 * time.Millisecond)
+	clock.Advance(time.Second)
 
 	if limiter.CurrentLimit() >= 10 {
 		t.Fatal("expected limit to decrease due to high latency")
@@ -79,3 +85,822 @@ func TestLimiterMetrics(t *testing.T) {
 		t.Fatal("expected positive latency")
 	}
 }
+
+func TestGuaranteedRateFloorsSustainedHighLatency(t *testing.T) {
+	c := cfg
+	c.GuaranteedRate = 6
+	c.BreakerDuration = time.Second
+	c.Strategy = StrategyAIMD
+
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(20, c, WithClock(clock))
+	defer limiter.Stop()
+
+	for i := 0; i < 30; i++ {
+		limiter.Record(time.Second, errors.New("failed"))
+		clock.Advance(time.Second)
+		if got := limiter.CurrentLimit(); got < c.GuaranteedRate {
+			t.Fatalf("tick %d: expected the limit never to drop below %d, got %d", i, c.GuaranteedRate, got)
+		}
+	}
+
+	if got := limiter.CurrentLimit(); got != c.GuaranteedRate {
+		t.Fatalf("expected sustained high latency to pin the limit at %d, got %d", c.GuaranteedRate, got)
+	}
+	if got := limiter.State(); got != BreakerClosed {
+		t.Fatalf("expected GuaranteedRate to keep the breaker closed, got %v", got)
+	}
+	if got := limiter.Remaining(); got != c.GuaranteedRate {
+		t.Fatalf("expected the guaranteed rate to stay admissible, got %d remaining", got)
+	}
+}
+
+func TestLimiterAllowNConsumesMultipleUnits(t *testing.T) {
+	limiter := NewAdaptivePerSecond(5, cfg)
+	defer limiter.Stop()
+
+	if !limiter.AllowN(3) {
+		t.Fatal("expected request of 3 units to be allowed")
+	}
+
+	if limiter.AllowN(3) {
+		t.Fatal("expected request exceeding remaining capacity to be rejected")
+	}
+
+	if !limiter.AllowN(2) {
+		t.Fatal("expected rejected request to consume nothing")
+	}
+
+	if limiter.Allow() {
+		t.Fatal("expected window to be exhausted")
+	}
+}
+
+func TestLimiterAllowNRejectsInvalidCost(t *testing.T) {
+	limiter := NewAdaptivePerSecond(5, cfg)
+	defer limiter.Stop()
+
+	if limiter.AllowN(6) {
+		t.Fatal("expected request larger than the limit to be rejected")
+	}
+
+	if limiter.AllowN(0) || limiter.AllowN(-1) {
+		t.Fatal("expected non-positive cost to be rejected")
+	}
+
+	if !limiter.AllowN(5) {
+		t.Fatal("expected full window to still be available")
+	}
+}
+
+func TestLimiterAverageLatencyUnits(t *testing.T) {
+	limiter := NewAdaptivePerSecond(10, cfg)
+	defer limiter.Stop()
+
+	for i := 0; i < 5; i++ {
+		limiter.Record(100*time.Millisecond, nil)
+	}
+
+	avg := limiter.AverageLatency()
+	if avg < 95*time.Millisecond || avg > 105*time.Millisecond {
+		t.Fatalf("expected average latency near 100ms, got %v", avg)
+	}
+}
+
+func TestLimiterAdaptsToSubMillisecondTarget(t *testing.T) {
+	c := cfg
+	c.TargetLatency = 800 * time.Microsecond
+
+	for _, tc := range []struct {
+		latency time.Duration
+		want    func(got int) bool
+	}{
+		{latency: 950 * time.Microsecond, want: func(got int) bool { return got < 10 }},
+		{latency: 300 * time.Microsecond, want: func(got int) bool { return got > 10 }},
+	} {
+		clock := newFakeClock()
+		limiter := NewAdaptivePerSecond(10, c, WithClock(clock))
+
+		for range 5 {
+			limiter.Record(tc.latency, nil)
+		}
+		if avg := limiter.AverageLatency(); avg != tc.latency {
+			t.Fatalf("expected an average of %v, got %v", tc.latency, avg)
+		}
+
+		clock.Advance(time.Second)
+		if got := limiter.CurrentLimit(); !tc.want(got) {
+			t.Fatalf("%v against an 800µs target: unexpected limit %d", tc.latency, got)
+		}
+		limiter.Stop()
+	}
+}
+
+func TestLimiterStopIsIdempotent(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	limiter := NewAdaptivePerSecond(10, cfg)
+
+	limiter.Stop()
+	limiter.Stop()
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected background loops to exit, %d goroutines remain (started with %d)",
+				runtime.NumGoroutine(), before)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestLimiterAllowWithDoneRecordsOutcome(t *testing.T) {
+	limiter := NewAdaptivePerSecond(1, cfg)
+	defer limiter.Stop()
+
+	ok, done := limiter.AllowWithDone()
+	if !ok {
+		t.Fatal("expected request to be allowed")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	done(errors.New("failed"))
+	done(nil)
+
+	if limiter.ErrorRate() != 1 {
+		t.Fatalf("expected only the first done call to be recorded, got error rate %f", limiter.ErrorRate())
+	}
+
+	if limiter.AverageLatency() < 20*time.Millisecond {
+		t.Fatalf("expected recorded latency to cover the request, got %v", limiter.AverageLatency())
+	}
+
+	ok, done = limiter.AllowWithDone()
+	if ok {
+		t.Fatal("expected second request to be rate-limited")
+	}
+
+	done(nil)
+
+	if limiter.ErrorRate() != 1 {
+		t.Fatal("expected done from a rejected request to be a no-op")
+	}
+}
+
+func TestLimiterTimeToReset(t *testing.T) {
+	limiter := NewAdaptivePerSecond(1, cfg)
+	defer limiter.Stop()
+
+	if d := limiter.TimeToReset(); d <= 0 || d > time.Second {
+		t.Fatalf("expected time to reset within one window, got %v", d)
+	}
+}
+
+func TestLimiterTimeToResetTracksWindow(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(1, cfg, WithClock(clock))
+	defer limiter.Stop()
+
+	clock.Advance(time.Second)
+	if d := limiter.TimeToReset(); d != time.Second {
+		t.Fatalf("expected a full window right after a reset, got %v", d)
+	}
+
+	clock.Advance(990 * time.Millisecond)
+	if d := limiter.TimeToReset(); d != 10*time.Millisecond {
+		t.Fatalf("expected 10ms left just before the reset, got %v", d)
+	}
+}
+
+func TestLimiterRemaining(t *testing.T) {
+	limiter := NewAdaptivePerSecond(3, cfg)
+	defer limiter.Stop()
+
+	if got := limiter.Remaining(); got != 3 {
+		t.Fatalf("expected 3 remaining, got %d", got)
+	}
+
+	limiter.AllowN(2)
+
+	if got := limiter.Remaining(); got != 1 {
+		t.Fatalf("expected 1 remaining, got %d", got)
+	}
+}
+
+func TestLimiterCustomWindowResetsTwicePerSecond(t *testing.T) {
+	c := cfg
+	c.Window = 500 * time.Millisecond
+
+	limiter := NewAdaptivePerSecond(1, c)
+	defer limiter.Stop()
+
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if !limiter.Allow() {
+			t.Fatalf("expected request in window %d to be allowed", i+1)
+		}
+		waitForReset(t, limiter)
+	}
+
+	if elapsed := time.Since(start); elapsed > 1100*time.Millisecond {
+		t.Fatalf("expected two resets within a second, took %v", elapsed)
+	}
+}
+
+func TestNewAdaptivePerMinuteUsesMinuteWindow(t *testing.T) {
+	limiter := NewAdaptivePerMinute(1, cfg)
+	defer limiter.Stop()
+
+	limiter.Allow()
+
+	if d := limiter.TimeToReset(); d <= 59*time.Second {
+		t.Fatalf("expected a one-minute window, got %v to reset", d)
+	}
+}
+
+func TestLimiterReset(t *testing.T) {
+	limiter := NewAdaptivePerSecond(3, cfg)
+	defer limiter.Stop()
+
+	limiter.AllowN(3)
+	limiter.Record(500*time.Millisecond, errors.New("err"))
+
+	limiter.mu.Lock()
+	limiter.setLimit(1)
+	limiter.lastAdjustment = time.Now()
+	limiter.mu.Unlock()
+
+	limiter.Reset()
+
+	s := limiter.Snapshot()
+	if s.CurrentLimit != 3 || s.CountThisWindow != 0 || !s.LastAdjustment.IsZero() {
+		t.Fatalf("expected initial state after reset, got %+v", s)
+	}
+
+	if s.AverageLatency != 0 || s.ErrorRate != 0 {
+		t.Fatalf("expected averages cleared after reset, got %v and %f", s.AverageLatency, s.ErrorRate)
+	}
+
+	if !limiter.AllowN(3) {
+		t.Fatal("expected limiter to admit the initial limit after reset")
+	}
+}
+
+func TestLimiterHigherAlphaReactsFaster(t *testing.T) {
+	samplesToReach := func(alpha float64) int {
+		c := cfg
+		c.LatencyAlpha = alpha
+
+		limiter := NewAdaptivePerSecond(10, c)
+		defer limiter.Stop()
+
+		limiter.Record(10*time.Millisecond, nil)
+		for n := 1; n <= 100; n++ {
+			limiter.Record(100*time.Millisecond, nil)
+			if limiter.AverageLatency() >= 90*time.Millisecond {
+				return n
+			}
+		}
+		return 100
+	}
+
+	fast := samplesToReach(0.9)
+	slow := samplesToReach(0.1)
+
+	if fast >= slow {
+		t.Fatalf("expected alpha 0.9 to react faster than 0.1, took %d vs %d samples", fast, slow)
+	}
+}
+
+func TestLimiterHoldsLimitDuringWarmup(t *testing.T) {
+	c := cfg
+	c.Warmup = 3 * time.Second
+
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(10, c, WithClock(clock))
+	defer limiter.Stop()
+
+	for i := 0; i < 20; i++ {
+		limiter.Record(500*time.Millisecond, nil)
+	}
+
+	clock.Advance(2 * time.Second)
+
+	if got := limiter.CurrentLimit(); got != 10 {
+		t.Fatalf("expected limit to stay at 10 during warmup, got %d", got)
+	}
+
+	clock.Advance(time.Second)
+
+	if got := limiter.CurrentLimit(); got != 8 {
+		t.Fatalf("expected adaptation to resume from 10 after warmup, got %d", got)
+	}
+}
+
+func TestLimiterHoldsLimitBelowMinSamples(t *testing.T) {
+	c := cfg
+	c.MinSamples = 3
+
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(10, c, WithClock(clock))
+	defer limiter.Stop()
+
+	limiter.Record(2*time.Second, nil)
+	clock.Advance(time.Second)
+
+	if got := limiter.CurrentLimit(); got != 10 {
+		t.Fatalf("expected one slow sample not to lower the limit, got %d", got)
+	}
+
+	// Samples accumulate across skipped ticks.
+	limiter.Record(500*time.Millisecond, nil)
+	limiter.Record(500*time.Millisecond, nil)
+	clock.Advance(time.Second)
+
+	if got := limiter.CurrentLimit(); got != 8 {
+		t.Fatalf("expected a decrease once MinSamples were recorded, got %d", got)
+	}
+
+	// The count restarts after each adjustment.
+	clock.Advance(time.Second)
+
+	if got := limiter.CurrentLimit(); got != 8 {
+		t.Fatalf("expected the limit to hold without new samples, got %d", got)
+	}
+}
+
+func TestDecreaseCooldownShorterThanIncreaseCooldown(t *testing.T) {
+	c := cfg
+	c.DecreaseStep = 1
+	c.IncreaseCooldown = 3 * time.Second
+	c.DecreaseCooldown = time.Second
+
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(10, c, WithClock(clock))
+	defer limiter.Stop()
+
+	limiter.Record(500*time.Millisecond, nil)
+	for want := 9; want >= 7; want-- {
+		clock.Advance(time.Second)
+		if got := limiter.CurrentLimit(); got != want {
+			t.Fatalf("expected decreases every second, want %d got %d", want, got)
+		}
+	}
+
+	// Recover: with healthy signals the limit may only rise three
+	// seconds after the last decrease, and every three seconds after.
+	for i := 0; i < 10; i++ {
+		limiter.Record(10*time.Millisecond, nil)
+	}
+	clock.Advance(2 * time.Second)
+	if got := limiter.CurrentLimit(); got != 7 {
+		t.Fatalf("expected no increase within IncreaseCooldown, got %d", got)
+	}
+
+	clock.Advance(time.Second)
+	if got := limiter.CurrentLimit(); got != 8 {
+		t.Fatalf("expected an increase after IncreaseCooldown, got %d", got)
+	}
+
+	clock.Advance(2 * time.Second)
+	if got := limiter.CurrentLimit(); got != 8 {
+		t.Fatalf("expected increases to stay throttled, got %d", got)
+	}
+}
+
+func TestMinUtilizationHoldsLimitUnderLowTraffic(t *testing.T) {
+	c := cfg
+	c.MinUtilization = 0.75
+
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(10, c, WithClock(clock))
+	defer limiter.Stop()
+
+	events := limiter.Events()
+
+	for i := 0; i < 20; i++ {
+		limiter.AllowN(2)
+		limiter.Record(10*time.Millisecond, nil)
+		clock.Advance(time.Second)
+	}
+
+	if got := limiter.CurrentLimit(); got != 10 {
+		t.Fatalf("expected low traffic to hold the limit at 10, got %d", got)
+	}
+
+	if e := <-events; e.Decision != DecisionHold || e.Reason != ReasonLowUtilization {
+		t.Fatalf("expected a hold for low utilization, got %+v", e)
+	}
+
+	// Traffic that uses the capacity raises the limit again.
+	for i := 0; i < 3; i++ {
+		for limiter.Allow() {
+		}
+		clock.Advance(time.Second)
+	}
+
+	if got := limiter.CurrentLimit(); got != 13 {
+		t.Fatalf("expected saturating traffic to raise the limit to 13, got %d", got)
+	}
+}
+
+func TestIdleDecayRecoversLimitWithoutTraffic(t *testing.T) {
+	c := cfg
+	c.IdleDecay = true
+
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(10, c, WithClock(clock))
+	defer limiter.Stop()
+
+	limiter.Record(time.Second, nil)
+	clock.Advance(time.Second)
+
+	if got := limiter.CurrentLimit(); got != 8 {
+		t.Fatalf("expected a backoff to 8, got %d", got)
+	}
+
+	// The stale 1s average stays above TargetLatency for four more
+	// ticks, backing off to MinLimit, before the limit climbs again.
+	for i := 0; i < 20; i++ {
+		clock.Advance(time.Second)
+	}
+
+	if got := limiter.CurrentLimit(); got <= 10 {
+		t.Fatalf("expected the limit to recover past 10 while idle, got %d", got)
+	}
+}
+
+func TestWithoutIdleDecayLimitStaysLowWithoutTraffic(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(10, cfg, WithClock(clock))
+	defer limiter.Stop()
+
+	limiter.Record(time.Second, nil)
+	for i := 0; i < 10; i++ {
+		clock.Advance(time.Second)
+	}
+
+	if got := limiter.CurrentLimit(); got != 1 {
+		t.Fatalf("expected stale latency to keep backing off to 1, got %d", got)
+	}
+}
+
+func TestForceAdjustDecreasesWithoutWaiting(t *testing.T) {
+	c := cfg
+	c.Cooldown = time.Hour
+	c.Warmup = time.Hour
+	c.MinSamples = 100
+
+	var changes []string
+	c.OnLimitChange = func(old, new int, reason string) {
+		changes = append(changes, reason)
+	}
+
+	limiter := NewAdaptivePerSecond(10, c, WithClock(newFakeClock()))
+	defer limiter.Stop()
+
+	limiter.Record(time.Second, nil)
+
+	if got := limiter.ForceAdjust(); got != 8 {
+		t.Fatalf("expected ForceAdjust to back off to 8, got %d", got)
+	}
+	if got := limiter.ForceAdjust(); got != 6 {
+		t.Fatalf("expected a second ForceAdjust to ignore the cooldown, got %d", got)
+	}
+	if len(changes) != 2 || changes[0] != ReasonHighLatency {
+		t.Fatalf("expected two high latency callbacks, got %v", changes)
+	}
+}
+
+func TestZeroCooldownAdjustsEveryTick(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(10, cfg, WithClock(clock))
+	defer limiter.Stop()
+
+	limiter.Record(10*time.Millisecond, nil)
+	for i := 1; i <= 3; i++ {
+		clock.Advance(time.Second)
+		if got := limiter.CurrentLimit(); got != 10+i {
+			t.Fatalf("tick %d: expected limit %d, got %d", i, 10+i, got)
+		}
+	}
+}
+
+func TestLatencyAtTargetHoldsLimitSteady(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(10, cfg, WithClock(clock))
+	defer limiter.Stop()
+
+	for i := 0; i < 20; i++ {
+		limiter.Record(cfg.TargetLatency, nil)
+		clock.Advance(time.Second)
+
+		if got := limiter.CurrentLimit(); got != 10 {
+			t.Fatalf("tick %d: expected latency at target to hold the limit at 10, got %d", i, got)
+		}
+	}
+}
+
+func TestWatermarksBoundTheSteadyBand(t *testing.T) {
+	c := cfg
+	c.HighWatermark = 0.5
+	c.LowWatermark = 0.5
+
+	tests := []struct {
+		latency time.Duration
+		want    int
+	}{
+		{90 * time.Millisecond, 11},
+		{110 * time.Millisecond, 10},
+		{290 * time.Millisecond, 10},
+		{310 * time.Millisecond, 8},
+	}
+
+	for _, tt := range tests {
+		clock := newFakeClock()
+		limiter := NewAdaptivePerSecond(10, c, WithClock(clock))
+
+		limiter.Record(tt.latency, nil)
+		clock.Advance(time.Second)
+
+		if got := limiter.CurrentLimit(); got != tt.want {
+			t.Errorf("latency %v: expected limit %d, got %d", tt.latency, tt.want, got)
+		}
+		limiter.Stop()
+	}
+}
+
+func TestAllowConcurrentNeverExceedsLimit(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(50, cfg, WithClock(clock))
+	defer limiter.Stop()
+
+	var (
+		wg      sync.WaitGroup
+		allowed atomic.Int64
+	)
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if limiter.Allow() {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := allowed.Load(); got != 50 {
+		t.Fatalf("expected exactly 50 concurrent admissions, got %d", got)
+	}
+}
+
+func TestSaturatedSlidingWindowNeverExceedsLimit(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewAdaptiveSlidingWindow(50, cfg, WithClock(clock))
+	defer limiter.Stop()
+
+	var (
+		wg      sync.WaitGroup
+		allowed atomic.Int64
+	)
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if limiter.Allow() {
+					allowed.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := allowed.Load(); got != 50 {
+		t.Fatalf("expected exactly 50 admissions under saturation, got %d", got)
+	}
+	if got := limiter.Snapshot().RejectedTotal; got != 1950 {
+		t.Fatalf("expected 1950 rejections, got %d", got)
+	}
+}
+
+func TestRecordBatchMatchesSequentialRecord(t *testing.T) {
+	batch := NewAdaptivePerSecond(10, cfg, WithClock(newFakeClock()))
+	defer batch.Stop()
+	sequential := NewAdaptivePerSecond(10, cfg, WithClock(newFakeClock()))
+	defer sequential.Stop()
+
+	samples := []Sample{
+		{Latency: 100 * time.Millisecond},
+		{Latency: 400 * time.Millisecond, Err: errors.New("slow")},
+		{Latency: 50 * time.Millisecond},
+		{Latency: 250 * time.Millisecond, Err: errors.New("failed")},
+	}
+
+	batch.RecordBatch(samples)
+	for _, s := range samples {
+		sequential.Record(s.Latency, s.Err)
+	}
+
+	if got, want := batch.AverageLatency(), sequential.AverageLatency(); got != want {
+		t.Fatalf("expected batch latency %v to match sequential %v", got, want)
+	}
+	if got, want := batch.ErrorRate(), sequential.ErrorRate(); got != want {
+		t.Fatalf("expected batch error rate %f to match sequential %f", got, want)
+	}
+}
+
+func TestRecordResultMovesOnlyErrorRate(t *testing.T) {
+	limiter := NewAdaptivePerSecond(10, cfg, WithClock(newFakeClock()))
+	defer limiter.Stop()
+
+	limiter.Record(100*time.Millisecond, nil)
+	limiter.RecordResult(errors.New("publish failed"))
+	limiter.RecordResult(nil)
+
+	if got := limiter.AverageLatency(); got != 100*time.Millisecond {
+		t.Fatalf("expected RecordResult to leave latency at 100ms, got %v", got)
+	}
+	if got := limiter.ErrorRate(); got <= 0 {
+		t.Fatalf("expected RecordResult to raise the error rate, got %f", got)
+	}
+}
+
+func TestRecordWeightedMovesAverageMoreThanRecord(t *testing.T) {
+	unit := NewAdaptivePerSecond(10, cfg, WithClock(newFakeClock()))
+	defer unit.Stop()
+	heavy := NewAdaptivePerSecond(10, cfg, WithClock(newFakeClock()))
+	defer heavy.Stop()
+
+	for _, l := range []*Limiter{unit, heavy} {
+		l.Record(100*time.Millisecond, nil)
+	}
+
+	unit.Record(1000*time.Millisecond, errors.New("slow"))
+	heavy.RecordWeighted(1000*time.Millisecond, errors.New("slow"), 5)
+
+	if heavy.AverageLatency() <= unit.AverageLatency() {
+		t.Fatalf("expected weighted sample to raise latency more: %v vs %v", heavy.AverageLatency(), unit.AverageLatency())
+	}
+
+	if heavy.ErrorRate() <= unit.ErrorRate() {
+		t.Fatalf("expected weighted sample to raise error rate more: %v vs %v", heavy.ErrorRate(), unit.ErrorRate())
+	}
+}
+
+func TestSampledRecordConvergesOnFullRecord(t *testing.T) {
+	c := cfg
+	c.LatencyAlpha = 0.001
+	c.ErrorAlpha = 0.001
+	full := NewAdaptivePerSecond(10, c, WithClock(newFakeClock()))
+	defer full.Stop()
+	c.SampleRate = 0.25
+	sampled := NewAdaptivePerSecond(10, c, WithClock(newFakeClock()))
+	defer sampled.Stop()
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	for i := 0; i < 20000; i++ {
+		latency := 100*time.Millisecond + time.Duration(rng.Int64N(int64(200*time.Millisecond)))
+		var err error
+		if rng.Float64() < 0.1 {
+			err = errors.New("failed")
+		}
+		full.Record(latency, err)
+		sampled.Record(latency, err)
+	}
+
+	if got, want := sampled.AverageLatency(), full.AverageLatency(); (got - want).Abs() > 15*time.Millisecond {
+		t.Fatalf("expected sampled latency %v to be close to full %v", got, want)
+	}
+	if got, want := sampled.ErrorRate(), full.ErrorRate(); math.Abs(got-want) > 0.04 {
+		t.Fatalf("expected sampled error rate %f to be close to full %f", got, want)
+	}
+}
+
+func TestSampledRecordStillFreesSlots(t *testing.T) {
+	c := cfg
+	c.SampleRate = 0.01
+	limiter := NewAdaptiveConcurrency(1, c, WithClock(newFakeClock()))
+	defer limiter.Stop()
+
+	for i := 0; i < 100; i++ {
+		if !limiter.Allow() {
+			t.Fatalf("expected request %d to get the slot freed by Record", i)
+		}
+		limiter.Record(100*time.Millisecond, nil)
+	}
+}
+
+func TestShadowAdmitsAndCountsWouldBeRejections(t *testing.T) {
+	rejects := 0
+	c := cfg
+	c.Shadow = true
+	c.OnReject = func() { rejects++ }
+
+	limiter := NewAdaptivePerSecond(2, c, WithClock(newFakeClock()))
+	defer limiter.Stop()
+
+	for i := 0; i < 5; i++ {
+		if !limiter.Allow() {
+			t.Fatalf("request %d: expected shadow mode to admit every request", i)
+		}
+	}
+
+	s := limiter.Snapshot()
+	if s.ShadowRejected != 3 {
+		t.Fatalf("expected 3 would-be rejections, got %d", s.ShadowRejected)
+	}
+	if s.RejectedTotal != 0 || rejects != 0 {
+		t.Fatalf("expected no real rejections, got %d (OnReject fired %d times)", s.RejectedTotal, rejects)
+	}
+	if s.AllowedTotal != 5 {
+		t.Fatalf("expected every request to count as allowed, got %d", s.AllowedTotal)
+	}
+}
+
+func TestRecordClassifiedSeverityScalesErrorRate(t *testing.T) {
+	c := cfg
+	c.ErrorAlpha = 0.2
+
+	hard := NewAdaptivePerSecond(10, c)
+	defer hard.Stop()
+	soft := NewAdaptivePerSecond(10, c)
+	defer soft.Stop()
+
+	hard.Record(10*time.Millisecond, nil)
+	soft.Record(10*time.Millisecond, nil)
+	hard.RecordClassified(10*time.Millisecond, 1)
+	soft.RecordClassified(10*time.Millisecond, 0.5)
+
+	if got, want := soft.ErrorRate(), hard.ErrorRate()/2; math.Abs(got-want) > 1e-9 {
+		t.Fatalf("expected severity 0.5 to move the error rate half as much (%v), got %v", want, got)
+	}
+
+	soft.RecordClassified(10*time.Millisecond, 7)
+	if got := soft.ErrorRate(); got > 1 {
+		t.Fatalf("expected severity to be clamped to 1, got error rate %v", got)
+	}
+}
+
+func TestLimitNeverDropsBelowOne(t *testing.T) {
+	c := cfg
+	c.MinLimit = 0
+	c.DecreaseStep = 50
+
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(10, c, WithClock(clock))
+	defer limiter.Stop()
+
+	limiter.Record(500*time.Millisecond, errors.New("failed"))
+	clock.Advance(time.Second)
+	if got := limiter.CurrentLimit(); got != 1 {
+		t.Fatalf("expected a large DecreaseStep to stop at a limit of 1, got %d", got)
+	}
+	if !limiter.Allow() {
+		t.Fatal("expected a request to be admitted at the floor")
+	}
+
+	for limiter.AverageLatency() > 10*time.Millisecond || limiter.ErrorRate() > 0.01 {
+		limiter.Record(0, nil)
+	}
+	clock.Advance(time.Second)
+	if got := limiter.CurrentLimit(); got != 2 {
+		t.Fatalf("expected the limiter to recover from the floor, got %d", got)
+	}
+}
+
+func TestZeroInitialLimitStartsAtOne(t *testing.T) {
+	c := cfg
+	c.MinLimit = 0
+
+	limiter := NewAdaptivePerSecond(0, c, WithClock(newFakeClock()))
+	defer limiter.Stop()
+
+	if got := limiter.CurrentLimit(); got != 1 {
+		t.Fatalf("expected a zero initial limit to be raised to 1, got %d", got)
+	}
+	if !limiter.Allow() {
+		t.Fatal("expected the first request to be admitted")
+	}
+}
+
+func TestBreakerStillShedsWithZeroMinLimit(t *testing.T) {
+	c := cfg
+	c.MinLimit = 0
+	c.BreakerDuration = time.Second
+	c.BreakerOpenDuration = time.Minute
+
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(1, c, WithClock(clock))
+	defer limiter.Stop()
+
+	limiter.Record(10*time.Millisecond, errors.New("failed"))
+	clock.Advance(3 * time.Second)
+	if got := limiter.State(); got != BreakerOpen {
+		t.Fatalf("expected the breaker to open at the floor, got %v", got)
+	}
+	if limiter.Allow() {
+		t.Fatal("expected an open breaker to shed every request")
+	}
+}
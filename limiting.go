@@ -0,0 +1,28 @@
+package adaptiveratelimit
+
+import "time"
+
+// Limiting is the part of a limiter that request-path code depends on:
+// admitting requests and recording their outcomes. *Limiter implements
+// it, and the HTTP and gRPC wrappers accept it, so code built on them can
+// be tested against a fake, such as those of the mock package, instead
+// of a real limiter and its goroutines.
+//
+// The wrappers use further methods of *Limiter, such as TimeToReset or
+// AllowPriority, when the implementation provides them, and fall back to
+// plain Allow and Record otherwise.
+type Limiting interface {
+	// Allow reports whether one request is allowed, consuming one unit
+	// of capacity if so.
+	Allow() bool
+
+	// AllowN reports whether a request costing n units is allowed,
+	// consuming them if so.
+	AllowN(n int) bool
+
+	// Record records the outcome of a completed request.
+	Record(latency time.Duration, err error)
+
+	// CurrentLimit returns the current limit.
+	CurrentLimit() int
+}
@@ -0,0 +1,108 @@
+package adaptiveratelimit
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LogLevel is the severity of a logged event.
+type LogLevel int
+
+// Log levels, in increasing severity.
+const (
+	LevelDebug LogLevel = iota
+	LevelInfo
+	LevelWarn
+)
+
+// String returns the level's name.
+func (lv LogLevel) String() string {
+	switch lv {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	default:
+		return "unknown"
+	}
+}
+
+// Logger receives structured events from a Limiter. kv holds alternating
+// keys and values, as accepted by log/slog.
+type Logger interface {
+	Log(level LogLevel, msg string, kv ...any)
+}
+
+// NewSlogLogger returns a Logger that writes events to lg.
+func NewSlogLogger(lg *slog.Logger) Logger {
+	return slogLogger{lg: lg}
+}
+
+type slogLogger struct {
+	lg *slog.Logger
+}
+
+func (s slogLogger) Log(level LogLevel, msg string, kv ...any) {
+	var lv slog.Level
+	switch level {
+	case LevelDebug:
+		lv = slog.LevelDebug
+	case LevelWarn:
+		lv = slog.LevelWarn
+	default:
+		lv = slog.LevelInfo
+	}
+	s.lg.Log(context.Background(), lv, msg, kv...)
+}
+
+// log reports an event to the configured logger, which must be non-nil,
+// prefixing kv with the limiter's name if it has one.
+func (l *Limiter) log(level LogLevel, msg string, kv ...any) {
+	if l.name != "" {
+		kv = append([]any{"limiter", l.name}, kv...)
+	}
+	l.logger.Log(level, msg, kv...)
+}
+
+// logDecision reports d to the configured logger, if any. It must be
+// called without l.mu held.
+func (l *Limiter) logDecision(d decision) {
+	if l.logger == nil {
+		return
+	}
+
+	l.log(LevelDebug, "adaptive decision",
+		"latency", d.latency,
+		"error_rate", d.errorRate,
+		"limit", d.newLimit,
+		"reason", d.reason,
+	)
+	if d.newLimit != d.oldLimit {
+		l.log(LevelInfo, "limit changed",
+			"old", d.oldLimit,
+			"new", d.newLimit,
+			"reason", d.reason,
+		)
+	}
+	if d.newCeiling != d.oldCeiling {
+		l.log(LevelInfo, "ceiling changed",
+			"old", d.oldCeiling,
+			"new", d.newCeiling,
+			"reason", d.reason,
+		)
+	}
+	if d.pinned {
+		l.log(LevelWarn, "limit reached MinLimit",
+			"limit", d.newLimit,
+			"reason", d.reason,
+		)
+	}
+	if d.saturated {
+		l.log(LevelWarn, "sustained saturation",
+			"limit", d.newLimit,
+			"rejected_total", l.rejectedTotal.Load(),
+		)
+	}
+}
@@ -0,0 +1,108 @@
+package adaptiveratelimit
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type logEntry struct {
+	level LogLevel
+	msg   string
+	kv    []any
+}
+
+// recordingLogger captures logged events.
+type recordingLogger struct {
+	mu      sync.Mutex
+	entries []logEntry
+}
+
+func (r *recordingLogger) Log(level LogLevel, msg string, kv ...any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, logEntry{level, msg, kv})
+}
+
+func (r *recordingLogger) has(level LogLevel, msg string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, e := range r.entries {
+		if e.level == level && e.msg == msg {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLoggerReportsBackoffToMinLimit(t *testing.T) {
+	clock := newFakeClock()
+	lg := &recordingLogger{}
+
+	limiter := NewAdaptivePerSecond(3, cfg, WithClock(clock), WithLogger(lg))
+	defer limiter.Stop()
+
+	limiter.Record(500*time.Millisecond, nil)
+	clock.Advance(time.Second)
+
+	if limiter.CurrentLimit() != cfg.MinLimit {
+		t.Fatalf("expected limit to drop to %d, got %d", cfg.MinLimit, limiter.CurrentLimit())
+	}
+
+	for _, want := range []logEntry{
+		{level: LevelDebug, msg: "adaptive decision"},
+		{level: LevelInfo, msg: "limit changed"},
+		{level: LevelWarn, msg: "limit reached MinLimit"},
+	} {
+		if !lg.has(want.level, want.msg) {
+			t.Fatalf("expected %s %q to be logged, got %+v", want.level, want.msg, lg.entries)
+		}
+	}
+}
+
+func TestLoggerReportsSustainedSaturation(t *testing.T) {
+	clock := newFakeClock()
+	lg := &recordingLogger{}
+
+	c := cfg
+	c.IncreaseStep = 0
+	limiter := NewAdaptivePerSecond(1, c, WithClock(clock), WithLogger(lg))
+	defer limiter.Stop()
+
+	for i := 0; i < saturationTicks; i++ {
+		if lg.has(LevelWarn, "sustained saturation") {
+			t.Fatalf("expected no saturation warning after %d ticks", i)
+		}
+		limiter.Allow()
+		limiter.Allow()
+		clock.Advance(time.Second)
+	}
+
+	if !lg.has(LevelWarn, "sustained saturation") {
+		t.Fatalf("expected a saturation warning, got %+v", lg.entries)
+	}
+}
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	lg := NewSlogLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	lg.Log(LevelWarn, "limit reached MinLimit", "limit", 1)
+
+	out := buf.String()
+	if !strings.Contains(out, "level=WARN") || !strings.Contains(out, "limit=1") {
+		t.Fatalf("unexpected slog output %q", out)
+	}
+}
+
+func TestAllowDoesNotAllocateWithoutLogger(t *testing.T) {
+	limiter := NewAdaptivePerSecond(1_000_000, cfg, WithClock(newFakeClock()))
+	defer limiter.Stop()
+
+	if allocs := testing.AllocsPerRun(1000, func() { limiter.Allow() }); allocs != 0 {
+		t.Fatalf("expected Allow not to allocate, got %v allocations", allocs)
+	}
+}
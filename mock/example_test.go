@@ -0,0 +1,44 @@
+package mock_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	ratehttp "github.com/bhatpriyanka8/adaptiveratelimit/http"
+	"github.com/bhatpriyanka8/adaptiveratelimit/mock"
+)
+
+// A fake that admits one request exercises both the handler and the
+// rejection path of the HTTP middleware.
+func Example() {
+	limiter := mock.AllowThenReject(1)
+	handler := ratehttp.Middleware(limiter)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	for range 2 {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		fmt.Printf("%d Retry-After=%q\n", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	fmt.Println(len(limiter.Outcomes()), "outcome recorded")
+
+	// Output:
+	// 204 Retry-After=""
+	// 429 Retry-After="1"
+	// 1 outcome recorded
+}
+
+// Rejection handling in your own code can be tested without a real
+// limiter.
+func ExampleAlwaysReject() {
+	limiter := mock.AlwaysReject()
+
+	if !limiter.Allow() {
+		fmt.Println("shed load")
+	}
+
+	// Output:
+	// shed load
+}
@@ -0,0 +1,128 @@
+// Package mock provides a scriptable fake implementing
+// adaptiveratelimit.Limiting, for unit-testing code that handles
+// rejections without a real adaptive limiter and its goroutines.
+package mock
+
+import (
+	"sync"
+	"time"
+)
+
+// Outcome is a request outcome passed to Limiter.Record.
+type Outcome struct {
+	Latency time.Duration
+	Err     error
+}
+
+// Limiter is a fake limiter whose admission decisions are scripted. It
+// starts no goroutines and needs no Stop. It is safe for concurrent use.
+type Limiter struct {
+	mu       sync.Mutex
+	decide   func(call int) bool
+	calls    int
+	allowed  int
+	limit    int
+	outcomes []Outcome
+}
+
+// New returns a Limiter that asks decide whether to admit each request.
+// call counts admission requests from zero, whether admitted or not.
+func New(decide func(call int) bool) *Limiter {
+	return &Limiter{decide: decide}
+}
+
+// AlwaysAllow returns a Limiter that admits every request.
+func AlwaysAllow() *Limiter {
+	return New(func(int) bool { return true })
+}
+
+// AlwaysReject returns a Limiter that rejects every request.
+func AlwaysReject() *Limiter {
+	return New(func(int) bool { return false })
+}
+
+// AllowThenReject returns a Limiter that admits the first n requests and
+// rejects every later one, like a limiter whose capacity runs out.
+func AllowThenReject(n int) *Limiter {
+	return New(func(call int) bool { return call < n })
+}
+
+// Script returns a Limiter that answers requests with decisions in
+// order, and rejects once they run out.
+func Script(decisions ...bool) *Limiter {
+	return New(func(call int) bool {
+		return call < len(decisions) && decisions[call]
+	})
+}
+
+// Allow reports the next scripted decision.
+func (m *Limiter) Allow() bool {
+	return m.AllowN(1)
+}
+
+// AllowN reports the next scripted decision. The cost n is ignored,
+// except that a request for n <= 0 units is rejected without consuming a
+// decision, as with adaptiveratelimit.Limiter.
+func (m *Limiter) AllowN(n int) bool {
+	if n <= 0 {
+		return false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ok := m.decide(m.calls)
+	m.calls++
+	if ok {
+		m.allowed++
+	}
+	return ok
+}
+
+// Record stores the outcome for Outcomes.
+func (m *Limiter) Record(latency time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.outcomes = append(m.outcomes, Outcome{Latency: latency, Err: err})
+}
+
+// CurrentLimit returns the limit set with SetLimit, zero by default.
+func (m *Limiter) CurrentLimit() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.limit
+}
+
+// SetLimit sets the value returned by CurrentLimit.
+func (m *Limiter) SetLimit(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.limit = n
+}
+
+// Calls returns the number of admission requests made so far.
+func (m *Limiter) Calls() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.calls
+}
+
+// Allowed returns the number of requests admitted so far.
+func (m *Limiter) Allowed() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.allowed
+}
+
+// Outcomes returns a copy of the outcomes recorded so far, in order.
+func (m *Limiter) Outcomes() []Outcome {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Outcome(nil), m.outcomes...)
+}
@@ -0,0 +1,71 @@
+package mock
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+)
+
+var _ adaptiveratelimit.Limiting = (*Limiter)(nil)
+
+func TestScriptedDecisions(t *testing.T) {
+	tests := []struct {
+		name    string
+		limiter *Limiter
+		want    []bool
+	}{
+		{"always allow", AlwaysAllow(), []bool{true, true, true}},
+		{"always reject", AlwaysReject(), []bool{false, false, false}},
+		{"allow then reject", AllowThenReject(2), []bool{true, true, false, false}},
+		{"script", Script(false, true), []bool{false, true, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed := 0
+			for i, want := range tt.want {
+				if got := tt.limiter.Allow(); got != want {
+					t.Fatalf("call %d: expected %v, got %v", i, want, got)
+				}
+				if want {
+					allowed++
+				}
+			}
+			if got := tt.limiter.Calls(); got != len(tt.want) {
+				t.Fatalf("expected %d calls, got %d", len(tt.want), got)
+			}
+			if got := tt.limiter.Allowed(); got != allowed {
+				t.Fatalf("expected %d admitted, got %d", allowed, got)
+			}
+		})
+	}
+}
+
+func TestAllowNRejectsNonPositiveCost(t *testing.T) {
+	m := AlwaysAllow()
+	if m.AllowN(0) {
+		t.Fatal("expected a zero cost to be rejected")
+	}
+	if m.Calls() != 0 {
+		t.Fatal("expected a zero cost not to consume a decision")
+	}
+}
+
+func TestRecordAndLimit(t *testing.T) {
+	m := AlwaysAllow()
+	failure := errors.New("failed")
+
+	m.Record(10*time.Millisecond, nil)
+	m.Record(20*time.Millisecond, failure)
+	m.SetLimit(7)
+
+	got := m.Outcomes()
+	if len(got) != 2 || got[0].Latency != 10*time.Millisecond || got[1].Err != failure {
+		t.Fatalf("expected both outcomes in order, got %+v", got)
+	}
+	if m.CurrentLimit() != 7 {
+		t.Fatalf("expected the limit to be 7, got %d", m.CurrentLimit())
+	}
+}
@@ -0,0 +1,125 @@
+package adaptiveratelimit
+
+import "time"
+
+// MultiLimiter admits a request only if every one of several limiters
+// admits it, for example a global limit and a per-tenant limit.
+//
+// Limiters are consulted in order. If one rejects the request, the
+// capacity already taken from the limiters before it is handed back, so
+// a rejected request consumes nothing anywhere: their window counts drop
+// again (or their tokens, bucket room or in-flight slots are returned),
+// their AllowedTotal is decremented, and queued Wait callers may be
+// granted the freed capacity. Only what was actually taken is returned:
+// nothing for a limiter that admitted the request in Shadow mode or
+// while disabled, and a half-open breaker probe is withdrawn so that
+// another request can probe instead. Only the limiter that rejected counts the
+// request as rejected and fires OnReject. Limits are checked one at a
+// time, not atomically, so concurrent callers can briefly see capacity
+// that is about to be handed back. If a window resets between the
+// acquisition and the rollback, the returned unit is taken off the new
+// window's count instead.
+//
+// A MultiLimiter does not own its limiters; stop them separately. It is
+// safe for concurrent use.
+type MultiLimiter struct {
+	// unexported fields
+	limiters []*Limiter
+}
+
+// NewMultiLimiter returns a MultiLimiter over limiters, consulted in the
+// given order. Putting the limiter most likely to reject first avoids
+// needless rollbacks.
+func NewMultiLimiter(limiters ...*Limiter) *MultiLimiter {
+	return &MultiLimiter{limiters: append([]*Limiter(nil), limiters...)}
+}
+
+// Allow reports whether a request is allowed by every limiter.
+func (m *MultiLimiter) Allow() bool {
+	return m.AllowN(1)
+}
+
+// AllowN reports whether n units are allowed by every limiter, rolling
+// back any partial acquisition as described for MultiLimiter.
+func (m *MultiLimiter) AllowN(n int) bool {
+	var buf [4]grant
+	grants := buf[:0]
+	for i, l := range m.limiters {
+		if g, ok := l.takeN(n); ok {
+			grants = append(grants, g)
+			continue
+		}
+		for j, acquired := range m.limiters[:i] {
+			acquired.unadmit(grants[j])
+		}
+		return false
+	}
+	return true
+}
+
+// Record records the outcome of a completed request with every limiter.
+func (m *MultiLimiter) Record(latency time.Duration, err error) {
+	for _, l := range m.limiters {
+		l.Record(latency, err)
+	}
+}
+
+// CurrentLimit returns the smallest current limit of the limiters, or
+// zero if there are none. Together with Allow, AllowN and Record it makes
+// a MultiLimiter a Limiting, so it can be passed to the HTTP and gRPC
+// wrappers.
+func (m *MultiLimiter) CurrentLimit() int {
+	return m.least((*Limiter).CurrentLimit)
+}
+
+// Remaining returns the smallest number of units any of the limiters
+// could admit right now, or zero if there are none.
+func (m *MultiLimiter) Remaining() int {
+	return m.least((*Limiter).Remaining)
+}
+
+// TimeToReset returns the longest time until one of the limiters'
+// windows resets, so that a rejected caller retrying after it is not
+// turned away by a limiter that resets later.
+func (m *MultiLimiter) TimeToReset() time.Duration {
+	var longest time.Duration
+	for _, l := range m.limiters {
+		longest = max(longest, l.TimeToReset())
+	}
+	return longest
+}
+
+// least returns the smallest value of f over the limiters, or zero if
+// there are none.
+func (m *MultiLimiter) least(f func(*Limiter) int) int {
+	if len(m.limiters) == 0 {
+		return 0
+	}
+	least := f(m.limiters[0])
+	for _, l := range m.limiters[1:] {
+		least = min(least, f(l))
+	}
+	return least
+}
+
+// unadmit hands back what takeN granted, as if the request had never
+// been allowed: the capacity it consumed, if any, and its half-open probe,
+// so that the breaker does not wait for an outcome that will never be
+// recorded.
+func (l *Limiter) unadmit(g grant) {
+	l.allowedTotal.Add(^uint64(0))
+	if g.shadow {
+		l.shadowRejectedTotal.Add(^uint64(0))
+	}
+	if g.probe {
+		l.cancelProbe()
+	}
+	if g.units == 0 {
+		return
+	}
+
+	l.mu.Lock()
+	l.refund(g.units)
+	l.grantWaiters()
+	l.mu.Unlock()
+}
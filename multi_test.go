@@ -0,0 +1,148 @@
+package adaptiveratelimit
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMultiLimiterRequiresEveryLimiter(t *testing.T) {
+	global := NewAdaptivePerSecond(3, cfg, WithClock(newFakeClock()))
+	defer global.Stop()
+	tenant := NewAdaptivePerSecond(2, cfg, WithClock(newFakeClock()))
+	defer tenant.Stop()
+
+	m := NewMultiLimiter(global, tenant)
+
+	for i := 0; i < 2; i++ {
+		if !m.Allow() {
+			t.Fatalf("request %d: expected admission", i)
+		}
+	}
+	if m.Allow() {
+		t.Fatal("expected the tenant limit to reject the third request")
+	}
+}
+
+func TestMultiLimiterRollsBackPartialAcquire(t *testing.T) {
+	global := NewAdaptivePerSecond(5, cfg, WithClock(newFakeClock()))
+	defer global.Stop()
+	tenant := NewAdaptivePerSecond(1, cfg, WithClock(newFakeClock()))
+	defer tenant.Stop()
+
+	m := NewMultiLimiter(global, tenant)
+	m.Allow()
+
+	for i := 0; i < 3; i++ {
+		if m.Allow() {
+			t.Fatal("expected the exhausted tenant limit to reject")
+		}
+	}
+
+	if got := global.Remaining(); got != 4 {
+		t.Fatalf("expected rejected requests not to consume global capacity, got %d remaining", got)
+	}
+	if got := global.Allowed(); got != 1 {
+		t.Fatalf("expected global to count only the admitted request, got %d", got)
+	}
+	if got := global.Rejected(); got != 0 {
+		t.Fatalf("expected global not to count a rejection, got %d", got)
+	}
+	if got := tenant.Rejected(); got != 3 {
+		t.Fatalf("expected the tenant to count 3 rejections, got %d", got)
+	}
+}
+
+func TestMultiLimiterRollsBackTokenBucket(t *testing.T) {
+	bucket := NewAdaptiveTokenBucket(10, 2, cfg, WithClock(newFakeClock()))
+	defer bucket.Stop()
+	window := NewAdaptivePerSecond(1, cfg, WithClock(newFakeClock()))
+	defer window.Stop()
+
+	m := NewMultiLimiter(bucket, window)
+	m.Allow()
+	m.Allow()
+
+	if got := bucket.Remaining(); got != 1 {
+		t.Fatalf("expected the rejected request's token to be returned, got %d", got)
+	}
+}
+
+func TestMultiLimiterRollsBackOnlyWhatShadowTook(t *testing.T) {
+	c := cfg
+	c.Shadow = true
+	bucket := NewAdaptiveTokenBucket(10, 1, c, WithClock(newFakeClock()))
+	defer bucket.Stop()
+	window := NewAdaptivePerSecond(1, cfg, WithClock(newFakeClock()))
+	defer window.Stop()
+
+	bucket.Allow()
+	window.Allow()
+
+	if NewMultiLimiter(bucket, window).Allow() {
+		t.Fatal("expected the exhausted window to reject")
+	}
+	if got := bucket.Remaining(); got != 0 {
+		t.Fatalf("expected no token to be returned for a shadow admission, got %d remaining", got)
+	}
+	if got := bucket.Snapshot().ShadowRejected; got != 0 {
+		t.Fatalf("expected the rolled-back shadow admission not to be counted, got %d", got)
+	}
+}
+
+func TestMultiLimiterRollsBackBreakerProbe(t *testing.T) {
+	limiter, clock := newBreakerLimiter(t)
+	limiter.Record(10*time.Millisecond, errors.New("failed"))
+	clock.Advance(6 * time.Second)
+	if got := limiter.State(); got != BreakerHalfOpen {
+		t.Fatalf("expected the breaker to be half-open, got %v", got)
+	}
+
+	window := NewAdaptivePerSecond(1, cfg, WithClock(newFakeClock()))
+	defer window.Stop()
+	window.Allow()
+
+	if NewMultiLimiter(limiter, window).Allow() {
+		t.Fatal("expected the exhausted window to reject")
+	}
+	if !limiter.Allow() {
+		t.Fatal("expected the rolled-back probe to be available again")
+	}
+}
+
+func TestMultiLimiterRecordFansOut(t *testing.T) {
+	a := NewAdaptivePerSecond(5, cfg, WithClock(newFakeClock()))
+	defer a.Stop()
+	b := NewAdaptivePerSecond(5, cfg, WithClock(newFakeClock()))
+	defer b.Stop()
+
+	NewMultiLimiter(a, b).Record(120*time.Millisecond, nil)
+
+	for _, l := range []*Limiter{a, b} {
+		if got := l.AverageLatency(); got != 120*time.Millisecond {
+			t.Fatalf("expected each limiter to record 120ms, got %v", got)
+		}
+	}
+}
+
+func TestMultiLimiterReportsTightestBudget(t *testing.T) {
+	clock := newFakeClock()
+	global := NewAdaptivePerSecond(10, cfg, WithClock(clock))
+	defer global.Stop()
+	tenant := NewAdaptivePerMinute(3, cfg, WithClock(clock))
+	defer tenant.Stop()
+
+	var m Limiting = NewMultiLimiter(global, tenant)
+	m.Allow()
+
+	multi := m.(*MultiLimiter)
+	if got := multi.CurrentLimit(); got != 3 {
+		t.Fatalf("expected the smallest limit, got %d", got)
+	}
+	if got := multi.Remaining(); got != 2 {
+		t.Fatalf("expected the smallest remaining budget, got %d", got)
+	}
+	if got := multi.TimeToReset(); got != time.Minute {
+		t.Fatalf("expected the longest time to reset, got %v", got)
+	}
+}
@@ -0,0 +1,98 @@
+package adaptiveratelimit
+
+import (
+	"runtime"
+	"time"
+)
+
+// Option customizes a Limiter at construction.
+type Option func(*options)
+
+type options struct {
+	clock  Clock
+	logger Logger
+	name   string
+	shards int
+
+	histogram []time.Duration
+
+	// config, mode and burst are only used by NewAdaptive.
+	config []func(*AdaptiveConfig)
+	mode   Mode
+	burst  int
+}
+
+func newOptions(opts []Option) options {
+	o := options{clock: realClock{}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithClock makes the limiter read time from c and build its tickers from
+// it instead of using the system clock. It is intended for tests.
+func WithClock(c Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// WithLogger makes the limiter report control loop events to lg: each
+// adaptive decision at debug level, limit changes at info level, and the
+// limit reaching MinLimit or sustained rejections at warn level.
+//
+// Events are logged from the control loop, outside the limiter's lock.
+// Without a logger nothing is logged and the hot path is unaffected.
+func WithLogger(lg Logger) Option {
+	return func(o *options) {
+		o.logger = lg
+	}
+}
+
+// WithName names the limiter, so that applications with several
+// limiters, such as one per downstream, can tell them apart. The name is
+// reported in Stats, in every Event, under the "limiter" key in every
+// log line, in every ProbeResult, and as a label or attribute by the
+// Prometheus and OpenTelemetry exporters. Limiters are unnamed by
+// default.
+//
+// OnLimitChange, OnReject and OnSaturation are not passed the name. To
+// tell limiters apart in them, give each limiter its own callbacks that
+// close over its name, or watch Events instead of OnLimitChange.
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// WithShardedCount splits a fixed-window limiter's request count into
+// shards counters, so that Allow calls on many cores do not all contend
+// on one cache line. A value of zero or less uses one shard per
+// GOMAXPROCS.
+//
+// Each shard admits up to an equal share of the current limit, so the
+// limit is still never exceeded, but AllowN may reject a request for
+// more than one share while the window still has room, and Snapshot and
+// Remaining read the shards one at a time rather than as one snapshot.
+// Other admission modes ignore this option.
+func WithShardedCount(shards int) Option {
+	return func(o *options) {
+		if shards <= 0 {
+			shards = runtime.GOMAXPROCS(0)
+		}
+		o.shards = shards
+	}
+}
+
+// WithLatencyHistogram makes the limiter count every latency passed to
+// Record, RecordWeighted or RecordBatch into buckets with the given
+// inclusive upper bounds, plus one for larger latencies, for export with
+// HistogramSnapshot. The histogram is independent of the averages that
+// drive the control loop, and costs one atomic increment per sample.
+// Bounds need not be sorted.
+func WithLatencyHistogram(bounds ...time.Duration) Option {
+	return func(o *options) {
+		o.histogram = bounds
+	}
+}
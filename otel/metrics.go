@@ -0,0 +1,76 @@
+// Package otel exposes adaptive limiter state as OpenTelemetry metrics.
+package otel
+
+import (
+	"context"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Instrument names registered by Register.
+const (
+	CurrentLimitName   = "adaptiveratelimit.current_limit"
+	AverageLatencyName = "adaptiveratelimit.average_latency"
+	ErrorRateName      = "adaptiveratelimit.error_rate"
+	AllowedName        = "adaptiveratelimit.allowed"
+	RejectedName       = "adaptiveratelimit.rejected"
+)
+
+// Register creates observable instruments on meter that report the state
+// of l: the current limit, average latency (in seconds) and error rate as
+// gauges, and the allowed and rejected totals as counters.
+//
+// The instruments are read in a callback at collection time, which takes
+// a single Snapshot of the limiter, so the request path is unaffected.
+// Several limiters can be registered on one meter; use WithName or
+// WithAttributes to tell them apart. A limiter named with
+// adaptiveratelimit.WithName is given a NameKey attribute with its name
+// by default, which WithName overrides. Call Unregister on the returned
+// registration to stop reporting l.
+func Register(meter metric.Meter, l *adaptiveratelimit.Limiter, opts ...Option) (metric.Registration, error) {
+	if name := l.Name(); name != "" {
+		opts = append([]Option{WithName(name)}, opts...)
+	}
+	o := newOptions(opts)
+
+	currentLimit, err := meter.Int64ObservableGauge(CurrentLimitName,
+		metric.WithDescription("Current allowed rate of the adaptive limiter."))
+	if err != nil {
+		return nil, err
+	}
+	averageLatency, err := meter.Float64ObservableGauge(AverageLatencyName,
+		metric.WithDescription("Smoothed average request latency observed by the limiter."),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+	errorRate, err := meter.Float64ObservableGauge(ErrorRateName,
+		metric.WithDescription("Smoothed request error rate observed by the limiter (0.0-1.0)."))
+	if err != nil {
+		return nil, err
+	}
+	allowed, err := meter.Int64ObservableCounter(AllowedName,
+		metric.WithDescription("Total number of requests admitted by the limiter."))
+	if err != nil {
+		return nil, err
+	}
+	rejected, err := meter.Int64ObservableCounter(RejectedName,
+		metric.WithDescription("Total number of requests rejected by the limiter."))
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := metric.WithAttributeSet(attribute.NewSet(o.attributes...))
+	return meter.RegisterCallback(func(_ context.Context, obs metric.Observer) error {
+		s := l.Snapshot()
+
+		obs.ObserveInt64(currentLimit, int64(s.CurrentLimit), attrs)
+		obs.ObserveFloat64(averageLatency, s.AverageLatency.Seconds(), attrs)
+		obs.ObserveFloat64(errorRate, s.ErrorRate, attrs)
+		obs.ObserveInt64(allowed, int64(s.AllowedTotal), attrs)
+		obs.ObserveInt64(rejected, int64(s.RejectedTotal), attrs)
+		return nil
+	}, currentLimit, averageLatency, errorRate, allowed, rejected)
+}
@@ -0,0 +1,147 @@
+package otel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+var cfg = adaptiveratelimit.AdaptiveConfig{
+	TargetLatency: 200 * time.Millisecond,
+	MaxErrorRate:  0.05,
+	IncreaseStep:  1,
+	DecreaseStep:  2,
+	MinLimit:      1,
+	MaxLimit:      100,
+}
+
+// collect reads all metrics from reader, keyed by instrument name and
+// then by the limiter name attribute.
+func collect(t *testing.T, reader *sdkmetric.ManualReader) map[string]map[string]float64 {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+
+	values := map[string]map[string]float64{}
+	add := func(metric string, set attribute.Set, v float64) {
+		name, _ := set.Value(NameKey)
+		if values[metric] == nil {
+			values[metric] = map[string]float64{}
+		}
+		values[metric][name.AsString()] = v
+	}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Gauge[int64]:
+				for _, dp := range data.DataPoints {
+					add(m.Name, dp.Attributes, float64(dp.Value))
+				}
+			case metricdata.Gauge[float64]:
+				for _, dp := range data.DataPoints {
+					add(m.Name, dp.Attributes, dp.Value)
+				}
+			case metricdata.Sum[int64]:
+				for _, dp := range data.DataPoints {
+					add(m.Name, dp.Attributes, float64(dp.Value))
+				}
+			}
+		}
+	}
+	return values
+}
+
+func TestRegisterReportsLimiterState(t *testing.T) {
+	api := adaptiveratelimit.NewAdaptivePerSecond(2, cfg)
+	defer api.Stop()
+	export := adaptiveratelimit.NewAdaptivePerSecond(5, cfg)
+	defer export.Stop()
+
+	api.Allow()
+	api.Allow()
+	api.Allow()
+	api.Record(100*time.Millisecond, nil)
+
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+
+	if _, err := Register(meter, api, WithName("api")); err != nil {
+		t.Fatalf("register api: %v", err)
+	}
+	if _, err := Register(meter, export, WithName("export")); err != nil {
+		t.Fatalf("register export: %v", err)
+	}
+
+	values := collect(t, reader)
+
+	tests := []struct {
+		metric, name string
+		want         float64
+	}{
+		{CurrentLimitName, "api", 2},
+		{CurrentLimitName, "export", 5},
+		{AllowedName, "api", 2},
+		{RejectedName, "api", 1},
+		{RejectedName, "export", 0},
+		{AverageLatencyName, "api", 0.1},
+		{ErrorRateName, "api", 0},
+	}
+	for _, tt := range tests {
+		if got, ok := values[tt.metric][tt.name]; !ok || got != tt.want {
+			t.Errorf("%s{limiter=%q}: expected %v, got %v (present %v)", tt.metric, tt.name, tt.want, got, ok)
+		}
+	}
+}
+
+func TestRegisterDefaultsToLimiterName(t *testing.T) {
+	named := adaptiveratelimit.NewAdaptivePerSecond(3, cfg, adaptiveratelimit.WithName("search"))
+	defer named.Stop()
+	renamed := adaptiveratelimit.NewAdaptivePerSecond(4, cfg, adaptiveratelimit.WithName("ignored"))
+	defer renamed.Stop()
+
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+
+	if _, err := Register(meter, named); err != nil {
+		t.Fatalf("register named: %v", err)
+	}
+	if _, err := Register(meter, renamed, WithName("override")); err != nil {
+		t.Fatalf("register renamed: %v", err)
+	}
+
+	limits := collect(t, reader)[CurrentLimitName]
+	if limits["search"] != 3 || limits["override"] != 4 {
+		t.Fatalf("expected limits keyed by limiter name, got %v", limits)
+	}
+	if _, ok := limits["ignored"]; ok {
+		t.Fatalf("expected WithName to override the limiter's name, got %v", limits)
+	}
+}
+
+func TestUnregisterStopsReporting(t *testing.T) {
+	limiter := adaptiveratelimit.NewAdaptivePerSecond(2, cfg)
+	defer limiter.Stop()
+
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+
+	reg, err := Register(meter, limiter)
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if err := reg.Unregister(); err != nil {
+		t.Fatalf("unregister: %v", err)
+	}
+
+	if values := collect(t, reader); len(values[CurrentLimitName]) != 0 {
+		t.Fatalf("expected no observations after Unregister, got %v", values)
+	}
+}
@@ -0,0 +1,34 @@
+package otel
+
+import "go.opentelemetry.io/otel/attribute"
+
+// NameKey is the attribute key set by WithName.
+const NameKey = attribute.Key("limiter")
+
+// Option configures Register.
+type Option func(*options)
+
+type options struct {
+	attributes []attribute.KeyValue
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithName adds a NameKey attribute with the given value to every
+// observation, so that limiters sharing a meter can be distinguished.
+func WithName(name string) Option {
+	return WithAttributes(NameKey.String(name))
+}
+
+// WithAttributes adds attrs to every observation.
+func WithAttributes(attrs ...attribute.KeyValue) Option {
+	return func(o *options) {
+		o.attributes = append(o.attributes, attrs...)
+	}
+}
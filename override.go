@@ -0,0 +1,62 @@
+package adaptiveratelimit
+
+import "time"
+
+// ReasonOverride means the limit was set by SetLimit or OverrideLimit.
+const ReasonOverride = "override"
+
+// SetLimit sets the current limit to n, clamped into [MinLimit,
+// MaxLimit] (and never below GuaranteedRate), and ends any override in
+// progress. The control loop keeps adapting from the new value; use
+// OverrideLimit to hold it.
+//
+// OnLimitChange fires with ReasonOverride if the limit changes.
+func (l *Limiter) SetLimit(n int) {
+	l.override(n, 0)
+}
+
+// OverrideLimit pins the current limit to n, clamped as for SetLimit, for
+// duration d, for example during a sale or a planned migration. While
+// the override is active the control loop does not adjust the limit;
+// once d has elapsed, adaptation resumes from n on the next tick. A later
+// call to SetLimit or OverrideLimit replaces the override, as does Reset.
+//
+// OnLimitChange fires with ReasonOverride if the limit changes. A
+// non-positive d behaves like SetLimit.
+func (l *Limiter) OverrideLimit(n int, d time.Duration) {
+	l.override(n, max(d, 0))
+}
+
+// override sets the limit to n and holds it for d.
+func (l *Limiter) override(n int, d time.Duration) {
+	l.mu.Lock()
+	oldLimit := l.limit()
+	newLimit := l.cfg.clampLimit(n)
+	l.setLimit(newLimit)
+	l.overrideUntil = time.Time{}
+	if d > 0 {
+		l.overrideUntil = l.clock.Now().Add(d)
+	}
+	l.grantWaiters()
+	onLimitChange := l.cfg.OnLimitChange
+	l.mu.Unlock()
+
+	if onLimitChange != nil && newLimit != oldLimit {
+		onLimitChange(oldLimit, newLimit, ReasonOverride)
+	}
+}
+
+// overridden reports whether an override is active at now, clearing it
+// once it has expired.
+//
+// The caller must hold l.mu.
+func (l *Limiter) overridden(now time.Time) bool {
+	if l.overrideUntil.IsZero() {
+		return false
+	}
+	if now.Before(l.overrideUntil) {
+		return true
+	}
+	l.overrideUntil = time.Time{}
+	return false
+}
@@ -0,0 +1,63 @@
+package adaptiveratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetLimitClampsAndKeepsAdapting(t *testing.T) {
+	var reasons []string
+	c := cfg
+	c.OnLimitChange = func(_, _ int, reason string) { reasons = append(reasons, reason) }
+
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(10, c, WithClock(clock))
+	defer limiter.Stop()
+
+	limiter.SetLimit(1000)
+	if got := limiter.CurrentLimit(); got != c.MaxLimit {
+		t.Fatalf("expected SetLimit to clamp to MaxLimit, got %d", got)
+	}
+	limiter.SetLimit(50)
+	if got := limiter.CurrentLimit(); got != 50 {
+		t.Fatalf("expected the limit to be set to 50, got %d", got)
+	}
+	if len(reasons) != 2 || reasons[0] != ReasonOverride {
+		t.Fatalf("expected OnLimitChange with ReasonOverride for each change, got %v", reasons)
+	}
+	if limiter.Snapshot().Overridden {
+		t.Fatal("expected SetLimit not to hold the limit")
+	}
+
+	limiter.Record(time.Second, nil)
+	clock.Advance(time.Second)
+	if got := limiter.CurrentLimit(); got >= 50 {
+		t.Fatalf("expected the control loop to keep adapting after SetLimit, got %d", got)
+	}
+}
+
+func TestOverrideLimitHoldsUntilExpiry(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(10, cfg, WithClock(clock))
+	defer limiter.Stop()
+
+	limiter.OverrideLimit(40, 3*time.Second)
+	s := limiter.Snapshot()
+	if !s.Overridden || !s.OverrideUntil.Equal(clock.Now().Add(3*time.Second)) {
+		t.Fatalf("expected an active override in Stats, got %+v", s)
+	}
+
+	limiter.Record(time.Second, nil)
+	clock.Advance(2 * time.Second)
+	if got := limiter.CurrentLimit(); got != 40 {
+		t.Fatalf("expected the override to suppress adaptation, got %d", got)
+	}
+
+	clock.Advance(time.Second)
+	if limiter.Snapshot().Overridden {
+		t.Fatal("expected the override to expire")
+	}
+	if got := limiter.CurrentLimit(); got >= 40 {
+		t.Fatalf("expected adaptation to resume after the override, got %d", got)
+	}
+}
@@ -0,0 +1,44 @@
+package adaptiveratelimit
+
+// ReasonPolicy is the reason reported for an adjustment made by a
+// Decide policy whose Action has no Reason.
+const ReasonPolicy = "policy"
+
+// Action is what a Decide policy asks the control loop to do.
+type Action struct {
+	// Decision is the direction to move the limit. Values other than
+	// DecisionIncrease and DecisionDecrease hold it.
+	Decision Decision
+
+	// Step is how far to move the limit. Zero means IncreaseStep for an
+	// increase, and the configured Strategy's backoff for a decrease.
+	// The result is always clamped to [MinLimit, MaxLimit], and never
+	// below GuaranteedRate.
+	Step int
+
+	// Reason is reported to OnLimitChange, Event and the logger. Empty
+	// means ReasonPolicy.
+	Reason string
+}
+
+// reason returns the action's reason, defaulting to ReasonPolicy.
+func (a Action) reason() string {
+	if a.Reason == "" {
+		return ReasonPolicy
+	}
+	return a.Reason
+}
+
+// apply returns limit moved as a, an increase or a decrease, asks.
+func (c AdaptiveConfig) apply(limit int, a Action) int {
+	switch {
+	case a.Decision == DecisionDecrease && a.Step > 0:
+		return c.clampLimit(limit - a.Step)
+	case a.Decision == DecisionDecrease:
+		return c.decreased(limit, 1)
+	case a.Step > 0:
+		return c.clampLimit(limit + a.Step)
+	default:
+		return c.increased(limit, 1)
+	}
+}
@@ -0,0 +1,65 @@
+package adaptiveratelimit
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDecideCustomPolicyBacksOffOnlyOnErrors(t *testing.T) {
+	c := cfg
+	c.Decide = func(s Stats) Action {
+		if s.ErrorRate > c.MaxErrorRate {
+			return Action{Decision: DecisionDecrease, Step: 5, Reason: "errors"}
+		}
+		return Action{Decision: DecisionIncrease}
+	}
+
+	var reasons []string
+	c.OnLimitChange = func(old, new int, reason string) {
+		reasons = append(reasons, reason)
+	}
+
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(10, c, WithClock(clock))
+	defer limiter.Stop()
+
+	limiter.Record(time.Second, nil)
+	clock.Advance(time.Second)
+
+	if got := limiter.CurrentLimit(); got != 11 {
+		t.Fatalf("expected high latency alone to raise the limit to 11, got %d", got)
+	}
+
+	limiter.Record(10*time.Millisecond, errors.New("failed"))
+	clock.Advance(time.Second)
+
+	if got := limiter.CurrentLimit(); got != 6 {
+		t.Fatalf("expected errors to lower the limit by 5 to 6, got %d", got)
+	}
+
+	if len(reasons) != 2 || reasons[0] != ReasonPolicy || reasons[1] != "errors" {
+		t.Fatalf("expected reasons [policy errors], got %v", reasons)
+	}
+}
+
+func TestDecideHoldKeepsLimit(t *testing.T) {
+	c := cfg
+	c.Decide = func(Stats) Action { return Action{} }
+
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(10, c, WithClock(clock))
+	defer limiter.Stop()
+
+	events := limiter.Events()
+
+	limiter.Record(time.Second, errors.New("failed"))
+	clock.Advance(time.Second)
+
+	if got := limiter.CurrentLimit(); got != 10 {
+		t.Fatalf("expected a hold to keep the limit at 10, got %d", got)
+	}
+	if e := <-events; e.Decision != DecisionHold || e.Reason != ReasonPolicy {
+		t.Fatalf("expected a policy hold event, got %+v", e)
+	}
+}
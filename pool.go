@@ -0,0 +1,104 @@
+package adaptiveratelimit
+
+import (
+	"cmp"
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+// PoolLimiter spreads requests over a pool of backends, such as the
+// instances behind a client-side load balancer, each guarded by its own
+// Limiter.
+//
+// Acquire sends each request to the least saturated backend that admits
+// it, where saturation is the fraction of a backend's current limit in
+// use, as reported in AllowInfo. Requests are therefore shared in
+// proportion to the backends' limits, like a weighted round-robin whose
+// weights are the limits, and since each limiter adapts to the latency
+// and errors recorded for its backend, traffic shifts away from a slow or
+// failing backend as its limit drops and towards the healthy ones.
+//
+// A PoolLimiter does not own its limiters; stop them separately. It is
+// safe for concurrent use.
+type PoolLimiter struct {
+	// unexported fields
+	limiters []*Limiter
+
+	// next is where the next Acquire starts breaking ties.
+	next atomic.Uint64
+}
+
+// NewPoolLimiter returns a PoolLimiter over limiters, one per backend.
+// The index of a backend is the position of its limiter.
+func NewPoolLimiter(limiters ...*Limiter) *PoolLimiter {
+	return &PoolLimiter{limiters: append([]*Limiter(nil), limiters...)}
+}
+
+// Acquire picks a backend for a request and takes one unit of its
+// capacity, returning its index. Backends are tried from the least to the
+// most saturated until one admits the request, so ok is false only if
+// every backend rejected it, in which case index is -1. Each backend that
+// is tried and turns the request away counts it as rejected and fires its
+// OnReject, as with Allow.
+//
+// Backends equally saturated, such as all of them while idle, are tried
+// in round-robin order: each call starts one backend further along the
+// pool than the last, so ties do not always favour the first backend.
+//
+// Saturation is read without reserving capacity, so concurrent callers
+// may pick the same backend; one that finds it full moves on to the next.
+func (p *PoolLimiter) Acquire() (index int, ok bool) {
+	n := len(p.limiters)
+	if n == 0 {
+		return -1, false
+	}
+
+	start := int(p.next.Add(1)-1) % n
+	order := make([]int, n)
+	saturations := make([]float64, n)
+	for i := range order {
+		order[i] = (start + i) % n
+		saturations[order[i]] = p.limiters[order[i]].allowInfo().Saturation
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(saturations[a], saturations[b])
+	})
+
+	for _, i := range order {
+		if p.limiters[i].Allow() {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// Record records the outcome of a request sent to the backend at index,
+// as returned by Acquire. It is a no-op for an index outside the pool.
+func (p *PoolLimiter) Record(index int, latency time.Duration, err error) {
+	if index < 0 || index >= len(p.limiters) {
+		return
+	}
+	p.limiters[index].Record(latency, err)
+}
+
+// Len returns the number of backends in the pool.
+func (p *PoolLimiter) Len() int {
+	return len(p.limiters)
+}
+
+// Limiter returns the limiter of the backend at index, which must be
+// within the pool.
+func (p *PoolLimiter) Limiter(index int) *Limiter {
+	return p.limiters[index]
+}
+
+// Stats returns a Snapshot of every backend's limiter, indexed like the
+// pool.
+func (p *PoolLimiter) Stats() []Stats {
+	stats := make([]Stats, len(p.limiters))
+	for i, l := range p.limiters {
+		stats[i] = l.Snapshot()
+	}
+	return stats
+}
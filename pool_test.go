@@ -0,0 +1,101 @@
+package adaptiveratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPoolLimiterSharesByLimit(t *testing.T) {
+	small := NewAdaptivePerSecond(10, cfg, WithClock(newFakeClock()))
+	defer small.Stop()
+	large := NewAdaptivePerSecond(20, cfg, WithClock(newFakeClock()))
+	defer large.Stop()
+
+	p := NewPoolLimiter(small, large)
+
+	counts := make([]int, p.Len())
+	for i := 0; i < 30; i++ {
+		index, ok := p.Acquire()
+		if !ok {
+			t.Fatalf("request %d: expected a backend to admit it", i)
+		}
+		counts[index]++
+	}
+	if counts[0] != 10 || counts[1] != 20 {
+		t.Fatalf("expected requests shared 10/20 by limit, got %v", counts)
+	}
+
+	if index, ok := p.Acquire(); ok || index != -1 {
+		t.Fatalf("expected a saturated pool to reject, got index %d", index)
+	}
+	stats := p.Stats()
+	if stats[0].RejectedTotal != 1 || stats[1].RejectedTotal != 1 {
+		t.Fatalf("expected each backend to count the rejection, got %d and %d", stats[0].RejectedTotal, stats[1].RejectedTotal)
+	}
+}
+
+func TestPoolLimiterBreaksTiesRoundRobin(t *testing.T) {
+	clock := newFakeClock()
+	var limiters []*Limiter
+	for i := 0; i < 3; i++ {
+		l := NewAdaptivePerSecond(10, cfg, WithClock(clock))
+		defer l.Stop()
+		limiters = append(limiters, l)
+	}
+
+	p := NewPoolLimiter(limiters...)
+
+	for i := 0; i < 6; i++ {
+		if index, _ := p.Acquire(); index != i%3 {
+			t.Fatalf("request %d: expected backend %d, got %d", i, i%3, index)
+		}
+	}
+}
+
+func TestPoolLimiterShiftsTrafficFromSlowBackend(t *testing.T) {
+	clock := newFakeClock()
+	slow := NewAdaptivePerSecond(10, cfg, WithClock(clock))
+	defer slow.Stop()
+	fast := NewAdaptivePerSecond(10, cfg, WithClock(clock))
+	defer fast.Stop()
+
+	p := NewPoolLimiter(slow, fast)
+	latencies := []time.Duration{time.Second, 50 * time.Millisecond}
+
+	var counts []int
+	for window := 0; window < 5; window++ {
+		counts = make([]int, p.Len())
+		for {
+			index, ok := p.Acquire()
+			if !ok {
+				break
+			}
+			counts[index]++
+			p.Record(index, latencies[index], nil)
+		}
+		clock.Advance(time.Second)
+	}
+
+	if counts[1] <= 2*counts[0] {
+		t.Fatalf("expected traffic to shift to the fast backend, got %v", counts)
+	}
+	if p.Limiter(0).CurrentLimit() >= 10 {
+		t.Fatalf("expected the slow backend's limit to drop, got %d", p.Limiter(0).CurrentLimit())
+	}
+}
+
+func TestPoolLimiterIgnoresUnknownIndex(t *testing.T) {
+	l := NewAdaptivePerSecond(10, cfg, WithClock(newFakeClock()))
+	defer l.Stop()
+
+	p := NewPoolLimiter(l)
+	p.Record(-1, time.Second, nil)
+	p.Record(1, time.Second, nil)
+
+	if got := l.AverageLatency(); got != 0 {
+		t.Fatalf("expected records for unknown backends to be dropped, got %v", got)
+	}
+	if _, ok := NewPoolLimiter().Acquire(); ok {
+		t.Fatal("expected an empty pool to reject")
+	}
+}
@@ -0,0 +1,122 @@
+package adaptiveratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// Priority orders requests for load shedding. Higher priorities are
+// admitted preferentially when the limiter is near capacity.
+type Priority int
+
+const (
+	// PriorityLow is shed first.
+	PriorityLow Priority = iota
+
+	// PriorityNormal is the priority of ordinary traffic.
+	PriorityNormal
+
+	// PriorityHigh is shed last and may use the full limit.
+	PriorityHigh
+)
+
+// String returns the lower-case name of the priority.
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityNormal:
+		return "normal"
+	case PriorityHigh:
+		return "high"
+	default:
+		return "unknown"
+	}
+}
+
+// PriorityThresholds is the fraction of capacity, in (0, 1], that each
+// priority may use. A request of a given priority is admitted only while
+// usage, including the request itself, stays within its fraction.
+type PriorityThresholds struct {
+	// Low is the fraction available to PriorityLow. Zero means 0.6.
+	Low float64
+
+	// Normal is the fraction available to PriorityNormal. Zero means 0.85.
+	Normal float64
+
+	// High is the fraction available to PriorityHigh. Zero means 1.0.
+	High float64
+}
+
+// Default priority thresholds.
+const (
+	defaultLowThreshold    = 0.6
+	defaultNormalThreshold = 0.85
+	defaultHighThreshold   = 1.0
+)
+
+// threshold returns the capacity fraction for p, applying defaults.
+func (t PriorityThresholds) threshold(p Priority) float64 {
+	var v, def float64
+	switch {
+	case p <= PriorityLow:
+		v, def = t.Low, defaultLowThreshold
+	case p == PriorityNormal:
+		v, def = t.Normal, defaultNormalThreshold
+	default:
+		v, def = t.High, defaultHighThreshold
+	}
+	if v == 0 {
+		return def
+	}
+	return v
+}
+
+// valid reports whether every threshold is unset or within (0, 1].
+func (t PriorityThresholds) valid() bool {
+	for _, v := range []float64{t.Low, t.Normal, t.High} {
+		if v < 0 || v > 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// AllowPriority reports whether a request of priority p is allowed, and
+// if so consumes one unit of capacity.
+//
+// Each priority may only use its fraction of the current capacity (see
+// AdaptiveConfig.PriorityThresholds), so as the limiter saturates, low
+// priority requests are shed first while high priority requests can
+// still use the full limit. In the window modes capacity is the current
+// limit; in token bucket mode it is the burst size.
+func (l *Limiter) AllowPriority(p Priority) bool {
+	l.mu.Lock()
+	fraction := l.cfg.PriorityThresholds.threshold(p)
+	l.mu.Unlock()
+
+	_, ok := l.allowN(1, fraction)
+	return ok
+}
+
+// AllowPriorityWithDone is like AllowWithDone, but admits the request as
+// AllowPriority does.
+func (l *Limiter) AllowPriorityWithDone(p Priority) (ok bool, done func(err error)) {
+	return l.allowWithDone(l.AllowPriority(p), func(start time.Time, err error) {
+		l.Record(l.clock.Now().Sub(start), err)
+	})
+}
+
+// withinFraction reports whether admitting n more units keeps usage
+// within fraction of the current capacity.
+//
+// The caller must hold l.mu.
+func (l *Limiter) withinFraction(n int, fraction float64, now time.Time) bool {
+	capacity := l.limit()
+	if l.mode == modeTokenBucket || l.mode == modeLeakyBucket {
+		capacity = l.burst
+	}
+
+	used := capacity - l.remaining(now)
+	return float64(used+n) <= math.Floor(fraction*float64(capacity)+1e-9)
+}
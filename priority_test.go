@@ -0,0 +1,111 @@
+package adaptiveratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAllowPriorityShedsLowFirst(t *testing.T) {
+	limiter := NewAdaptivePerSecond(10, cfg)
+	defer limiter.Stop()
+
+	admitted := map[Priority]int{}
+	for i := 0; i < 10; i++ {
+		for _, p := range []Priority{PriorityLow, PriorityNormal, PriorityHigh} {
+			if limiter.AllowPriority(p) {
+				admitted[p]++
+			}
+		}
+	}
+
+	if limiter.Remaining() != 0 {
+		t.Fatalf("expected the limiter to be saturated, %d remaining", limiter.Remaining())
+	}
+
+	if admitted[PriorityHigh] <= admitted[PriorityLow] {
+		t.Fatalf("expected high priority to be admitted more than low under saturation, got %v", admitted)
+	}
+
+	if limiter.AllowPriority(PriorityLow) || limiter.AllowPriority(PriorityNormal) {
+		t.Fatal("expected low and normal priority to be shed at saturation")
+	}
+}
+
+func TestAllowPriorityThresholds(t *testing.T) {
+	limiter := NewAdaptivePerSecond(10, cfg)
+	defer limiter.Stop()
+
+	lows := 0
+	for limiter.AllowPriority(PriorityLow) {
+		lows++
+	}
+	if lows != 6 {
+		t.Fatalf("expected low priority cut off at 60%% of 10, admitted %d", lows)
+	}
+
+	normals := 0
+	for limiter.AllowPriority(PriorityNormal) {
+		normals++
+	}
+	if normals != 2 {
+		t.Fatalf("expected normal priority cut off at 85%% of 10, admitted %d more", normals)
+	}
+
+	highs := 0
+	for limiter.AllowPriority(PriorityHigh) {
+		highs++
+	}
+	if highs != 2 {
+		t.Fatalf("expected high priority to use the full limit, admitted %d more", highs)
+	}
+}
+
+func TestAllowPriorityCustomThresholds(t *testing.T) {
+	c := cfg
+	c.PriorityThresholds = PriorityThresholds{Low: 0.2}
+
+	limiter := NewAdaptivePerSecond(10, c)
+	defer limiter.Stop()
+
+	lows := 0
+	for limiter.AllowPriority(PriorityLow) {
+		lows++
+	}
+	if lows != 2 {
+		t.Fatalf("expected custom low threshold of 20%%, admitted %d", lows)
+	}
+}
+
+func TestAllowPriorityWithDoneReleasesSlot(t *testing.T) {
+	c := cfg
+	c.MaxHoldDuration = time.Second
+	c.MinSamples = 1000 // keep the limit fixed
+
+	clock := newFakeClock()
+	limiter := NewAdaptiveConcurrency(10, c, WithClock(clock))
+	defer limiter.Stop()
+
+	for i := 0; i < 6; i++ {
+		if ok, _ := limiter.AllowPriorityWithDone(PriorityLow); !ok {
+			t.Fatalf("expected low priority request %d to be admitted", i+1)
+		}
+	}
+	if ok, _ := limiter.AllowPriorityWithDone(PriorityLow); ok {
+		t.Fatal("expected low priority to be shed at 60% capacity")
+	}
+
+	ok, done := limiter.AllowPriorityWithDone(PriorityHigh)
+	if !ok {
+		t.Fatal("expected high priority to still be admitted")
+	}
+	done(nil)
+	done(nil)
+	if got := limiter.InFlight(); got != 6 {
+		t.Fatalf("expected done to free exactly one slot, got %d in flight", got)
+	}
+
+	clock.Advance(2 * time.Second)
+	if got := limiter.InFlight(); got != 0 {
+		t.Fatalf("expected the unreleased slots to be reclaimed, got %d in flight", got)
+	}
+}
@@ -0,0 +1,93 @@
+// Package prometheus exposes adaptive limiter state as Prometheus metrics.
+package prometheus
+
+import (
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// nameLabel is the constant label carrying the name of a named limiter.
+const nameLabel = "limiter"
+
+// descs are the metric descriptions of one collector.
+type descs struct {
+	currentLimit   *prometheus.Desc
+	averageLatency *prometheus.Desc
+	errorRate      *prometheus.Desc
+	allowedTotal   *prometheus.Desc
+	rejectedTotal  *prometheus.Desc
+}
+
+// newDescs builds the metric descriptions, labelled with name if it is
+// not empty.
+func newDescs(name string) descs {
+	var labels prometheus.Labels
+	if name != "" {
+		labels = prometheus.Labels{nameLabel: name}
+	}
+	return descs{
+		currentLimit: prometheus.NewDesc(
+			"adaptiveratelimit_current_limit",
+			"Current allowed rate of the adaptive limiter.",
+			nil, labels,
+		),
+		averageLatency: prometheus.NewDesc(
+			"adaptiveratelimit_average_latency_seconds",
+			"Smoothed average request latency observed by the limiter.",
+			nil, labels,
+		),
+		errorRate: prometheus.NewDesc(
+			"adaptiveratelimit_error_rate",
+			"Smoothed request error rate observed by the limiter (0.0-1.0).",
+			nil, labels,
+		),
+		allowedTotal: prometheus.NewDesc(
+			"adaptiveratelimit_allowed_total",
+			"Total number of requests admitted by the limiter.",
+			nil, labels,
+		),
+		rejectedTotal: prometheus.NewDesc(
+			"adaptiveratelimit_rejected_total",
+			"Total number of requests rejected by the limiter.",
+			nil, labels,
+		),
+	}
+}
+
+// collector reads a Limiter snapshot on every scrape.
+type collector struct {
+	limiter *adaptiveratelimit.Limiter
+	descs   descs
+}
+
+// NewCollector returns a prometheus.Collector that publishes the state of
+// l on every scrape.
+//
+// Each scrape takes a single Snapshot of the limiter, so collection only
+// briefly holds the limiter's lock and does not block request handling.
+// If the limiter was named with adaptiveratelimit.WithName, every metric
+// carries the name in a "limiter" label, so collectors for several named
+// limiters can share a registry.
+func NewCollector(l *adaptiveratelimit.Limiter) prometheus.Collector {
+	return &collector{limiter: l, descs: newDescs(l.Name())}
+}
+
+// Describe implements prometheus.Collector.
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.descs.currentLimit
+	ch <- c.descs.averageLatency
+	ch <- c.descs.errorRate
+	ch <- c.descs.allowedTotal
+	ch <- c.descs.rejectedTotal
+}
+
+// Collect implements prometheus.Collector.
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	s := c.limiter.Snapshot()
+
+	ch <- prometheus.MustNewConstMetric(c.descs.currentLimit, prometheus.GaugeValue, float64(s.CurrentLimit))
+	ch <- prometheus.MustNewConstMetric(c.descs.averageLatency, prometheus.GaugeValue, s.AverageLatency.Seconds())
+	ch <- prometheus.MustNewConstMetric(c.descs.errorRate, prometheus.GaugeValue, s.ErrorRate)
+	ch <- prometheus.MustNewConstMetric(c.descs.allowedTotal, prometheus.CounterValue, float64(s.AllowedTotal))
+	ch <- prometheus.MustNewConstMetric(c.descs.rejectedTotal, prometheus.CounterValue, float64(s.RejectedTotal))
+}
@@ -0,0 +1,89 @@
+package prometheus
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollectorPublishesLimiterState(t *testing.T) {
+	limiter := adaptiveratelimit.NewAdaptivePerSecond(2, adaptiveratelimit.AdaptiveConfig{
+		TargetLatency: 200 * time.Millisecond,
+		MaxErrorRate:  0.05,
+		IncreaseStep:  1,
+		DecreaseStep:  2,
+		MinLimit:      1,
+		MaxLimit:      100,
+	})
+	defer limiter.Stop()
+
+	limiter.Allow()
+	limiter.Allow()
+	limiter.Allow()
+
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(NewCollector(limiter)); err != nil {
+		t.Fatalf("failed to register collector: %v", err)
+	}
+
+	expected := `
+# HELP adaptiveratelimit_allowed_total Total number of requests admitted by the limiter.
+# TYPE adaptiveratelimit_allowed_total counter
+adaptiveratelimit_allowed_total 2
+# HELP adaptiveratelimit_current_limit Current allowed rate of the adaptive limiter.
+# TYPE adaptiveratelimit_current_limit gauge
+adaptiveratelimit_current_limit 2
+# HELP adaptiveratelimit_rejected_total Total number of requests rejected by the limiter.
+# TYPE adaptiveratelimit_rejected_total counter
+adaptiveratelimit_rejected_total 1
+`
+
+	err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"adaptiveratelimit_allowed_total",
+		"adaptiveratelimit_current_limit",
+		"adaptiveratelimit_rejected_total",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := testutil.CollectAndCount(NewCollector(limiter)); n != 5 {
+		t.Fatalf("expected 5 metrics, got %d", n)
+	}
+}
+
+func TestCollectorLabelsNamedLimiters(t *testing.T) {
+	newLimiter := func(limit int, name string) *adaptiveratelimit.Limiter {
+		return adaptiveratelimit.NewAdaptivePerSecond(limit, adaptiveratelimit.AdaptiveConfig{
+			TargetLatency: 200 * time.Millisecond,
+			MinLimit:      1,
+			MaxLimit:      100,
+		}, adaptiveratelimit.WithName(name))
+	}
+	api := newLimiter(2, "api")
+	defer api.Stop()
+	export := newLimiter(5, "export")
+	defer export.Stop()
+
+	registry := prometheus.NewRegistry()
+	for _, l := range []*adaptiveratelimit.Limiter{api, export} {
+		if err := registry.Register(NewCollector(l)); err != nil {
+			t.Fatalf("failed to register collector for %q: %v", l.Name(), err)
+		}
+	}
+
+	expected := `
+# HELP adaptiveratelimit_current_limit Current allowed rate of the adaptive limiter.
+# TYPE adaptiveratelimit_current_limit gauge
+adaptiveratelimit_current_limit{limiter="api"} 2
+adaptiveratelimit_current_limit{limiter="export"} 5
+`
+	err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "adaptiveratelimit_current_limit")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,187 @@
+package adaptiveratelimit
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Quantile estimates a single quantile of a stream of samples using the
+// P² algorithm (Jain and Chlamtac), in constant memory.
+//
+// Quantile is safe for concurrent use.
+type Quantile struct {
+	// unexported fields
+	mu sync.Mutex
+	p  float64
+
+	count   int
+	heights [5]float64
+	pos     [5]int
+	desired [5]float64
+	incr    [5]float64
+}
+
+// NewQuantile creates a new estimator for the p-quantile, where p is
+// between 0 and 1 (for example 0.99 for the 99th percentile).
+func NewQuantile(p float64) *Quantile {
+	return &Quantile{
+		p:    p,
+		incr: [5]float64{0, p / 2, p, (1 + p) / 2, 1},
+	}
+}
+
+// Update incorporates a new sample into the estimate.
+func (q *Quantile) Update(sample float64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.count < 5 {
+		q.heights[q.count] = sample
+		q.count++
+		if q.count == 5 {
+			sort.Float64s(q.heights[:])
+			for i := range q.pos {
+				q.pos[i] = i + 1
+			}
+			q.desired = [5]float64{1, 1 + 2*q.p, 1 + 4*q.p, 3 + 2*q.p, 5}
+		}
+		return
+	}
+	q.count++
+
+	// Find the cell containing the sample, extending the extremes.
+	var k int
+	switch {
+	case sample < q.heights[0]:
+		q.heights[0] = sample
+	case sample >= q.heights[4]:
+		q.heights[4] = sample
+		k = 3
+	default:
+		for k = 0; k < 3 && sample >= q.heights[k+1]; k++ {
+		}
+	}
+
+	for i := k + 1; i < 5; i++ {
+		q.pos[i]++
+	}
+	for i := range q.desired {
+		q.desired[i] += q.incr[i]
+	}
+
+	// Adjust the three middle markers toward their desired positions.
+	for i := 1; i <= 3; i++ {
+		d := q.desired[i] - float64(q.pos[i])
+		if (d >= 1 && q.pos[i+1]-q.pos[i] > 1) || (d <= -1 && q.pos[i-1]-q.pos[i] < -1) {
+			step := 1
+			if d < 0 {
+				step = -1
+			}
+
+			h := q.parabolic(i, step)
+			if q.heights[i-1] >= h || h >= q.heights[i+1] {
+				h = q.linear(i, step)
+			}
+			q.heights[i] = h
+			q.pos[i] += step
+		}
+	}
+}
+
+func (q *Quantile) parabolic(i, d int) float64 {
+	n, h := q.pos, q.heights
+	fd := float64(d)
+	return h[i] + fd/float64(n[i+1]-n[i-1])*
+		(float64(n[i]-n[i-1]+d)*(h[i+1]-h[i])/float64(n[i+1]-n[i])+
+			float64(n[i+1]-n[i]-d)*(h[i]-h[i-1])/float64(n[i]-n[i-1]))
+}
+
+func (q *Quantile) linear(i, d int) float64 {
+	return q.heights[i] + float64(d)*(q.heights[i+d]-q.heights[i])/float64(q.pos[i+d]-q.pos[i])
+}
+
+// Value returns the current quantile estimate, or zero if no samples
+// have been observed.
+func (q *Quantile) Value() float64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	switch {
+	case q.count == 0:
+		return 0
+	case q.count < 5:
+		// Too few samples for P²; answer exactly from what we have.
+		s := append([]float64(nil), q.heights[:q.count]...)
+		sort.Float64s(s)
+		return s[int(q.p*float64(q.count-1)+0.5)]
+	default:
+		return q.heights[2]
+	}
+}
+
+// Reset discards all samples.
+func (q *Quantile) Reset() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.count = 0
+	q.heights = [5]float64{}
+	q.pos = [5]int{}
+	q.desired = [5]float64{}
+}
+
+// trackedQuantiles are the percentiles estimated when UsePercentile is
+// enabled, in addition to LatencyPercentile.
+var trackedQuantiles = []float64{0.5, 0.9, 0.95, 0.99}
+
+// defaultLatencyPercentile is used when LatencyPercentile is unset.
+const defaultLatencyPercentile = 0.95
+
+// newLatencyQuantiles builds estimators for trackedQuantiles plus p.
+func newLatencyQuantiles(p float64) []*Quantile {
+	qs := make([]*Quantile, 0, len(trackedQuantiles)+1)
+	for _, tq := range trackedQuantiles {
+		qs = append(qs, NewQuantile(tq))
+	}
+	if !slices.Contains(trackedQuantiles, p) {
+		qs = append(qs, NewQuantile(p))
+	}
+	return qs
+}
+
+// latencyPercentile returns the percentile used for adaptation, applying
+// the default when unset.
+func (c AdaptiveConfig) latencyPercentile() float64 {
+	if c.LatencyPercentile == 0 {
+		return defaultLatencyPercentile
+	}
+	return c.LatencyPercentile
+}
+
+// LatencyQuantile returns the estimated q-quantile of request latency.
+//
+// Percentiles are only tracked when UsePercentile is enabled; otherwise
+// LatencyQuantile returns zero. The 0.5, 0.9, 0.95 and 0.99 quantiles
+// and LatencyPercentile are tracked, and any other q is answered by the
+// nearest tracked quantile.
+func (l *Limiter) LatencyQuantile(q float64) time.Duration {
+	return l.latencyQuantile(q)
+}
+
+// latencyQuantile converts the nearest tracked quantile estimate, in
+// microseconds, to a Duration.
+func (l *Limiter) latencyQuantile(q float64) time.Duration {
+	var nearest *Quantile
+	for _, est := range l.latencyQuantiles {
+		if nearest == nil || math.Abs(est.p-q) < math.Abs(nearest.p-q) {
+			nearest = est
+		}
+	}
+	if nearest == nil {
+		return 0
+	}
+	return fromMicros(nearest.Value())
+}
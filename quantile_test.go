@@ -0,0 +1,115 @@
+package adaptiveratelimit
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestQuantileEstimatesUniformDistribution(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	tests := []float64{0.5, 0.9, 0.99}
+	for _, p := range tests {
+		q := NewQuantile(p)
+		for i := 0; i < 10000; i++ {
+			q.Update(rng.Float64() * 1000)
+		}
+
+		want := p * 1000
+		if got := q.Value(); got < want-20 || got > want+20 {
+			t.Fatalf("expected p%.0f near %.0f, got %.1f", p*100, want, got)
+		}
+	}
+}
+
+func TestQuantileWithFewSamples(t *testing.T) {
+	q := NewQuantile(0.5)
+
+	if q.Value() != 0 {
+		t.Fatal("expected zero with no samples")
+	}
+
+	q.Update(30)
+	q.Update(10)
+	q.Update(20)
+
+	if got := q.Value(); got != 20 {
+		t.Fatalf("expected exact median of 3 samples, got %f", got)
+	}
+
+	q.Reset()
+	if q.Value() != 0 {
+		t.Fatal("expected zero after reset")
+	}
+}
+
+func TestLimiterLatencyQuantileCapturesTail(t *testing.T) {
+	c := cfg
+	c.UsePercentile = true
+
+	limiter := NewAdaptivePerSecond(10, c)
+	defer limiter.Stop()
+
+	// 98% fast requests, 2% very slow ones.
+	for i := 0; i < 5000; i++ {
+		latency := 10 * time.Millisecond
+		if i%50 == 0 {
+			latency = 2 * time.Second
+		}
+		limiter.Record(latency, nil)
+	}
+
+	p50 := limiter.LatencyQuantile(0.5)
+	p99 := limiter.LatencyQuantile(0.99)
+
+	if p50 > 20*time.Millisecond {
+		t.Fatalf("expected p50 near 10ms, got %v", p50)
+	}
+
+	if p99 < time.Second {
+		t.Fatalf("expected p99 to reflect the slow tail, got %v", p99)
+	}
+}
+
+func TestLimiterPercentileTriggersBackoff(t *testing.T) {
+	record := func(l *Limiter) {
+		for i := 0; i < 1000; i++ {
+			latency := 10 * time.Millisecond
+			if i%10 == 0 {
+				latency = time.Second
+			}
+			l.Record(latency, nil)
+		}
+	}
+
+	c := cfg
+	c.UsePercentile = true
+	c.LatencyPercentile = 0.95
+
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(10, c, WithClock(clock))
+	defer limiter.Stop()
+
+	record(limiter)
+	clock.Advance(time.Second)
+
+	if got := limiter.CurrentLimit(); got >= 10 {
+		t.Fatalf("expected p95 above target to trigger backoff, got limit %d", got)
+	}
+
+	if limiter.LatencyQuantile(0.5) >= c.TargetLatency {
+		t.Fatal("expected median to stay below target")
+	}
+}
+
+func TestLimiterLatencyQuantileDisabledByDefault(t *testing.T) {
+	limiter := NewAdaptivePerSecond(10, cfg)
+	defer limiter.Stop()
+
+	limiter.Record(time.Second, nil)
+
+	if got := limiter.LatencyQuantile(0.99); got != 0 {
+		t.Fatalf("expected zero without UsePercentile, got %v", got)
+	}
+}
@@ -0,0 +1,125 @@
+// Package rate adapts an adaptive limiter to the method set of
+// golang.org/x/time/rate, so code written against *rate.Limiter can switch
+// to adaptive limiting by changing its import path and constructor.
+//
+// # Semantic differences
+//
+// The limit of an adaptive limiter floats: it is raised and lowered by the
+// control loop, so the rate observed by callers is not fixed as it is
+// with x/time/rate. Delays reported by queued reservations are estimates
+// based on the time until the current window resets, re-estimated on
+// every call, and may be longer if the limit drops or other callers are
+// ahead in the queue; a reservation whose Delay is zero has been
+// admitted. The now arguments that are accepted for compatibility are
+// ignored; the limiter always uses its own clock. Finally, callers must
+// still invoke Record on the underlying limiter for it to adapt.
+package rate
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+)
+
+// InfDuration is the duration returned by Delay when a Reservation is not
+// OK.
+const InfDuration = time.Duration(math.MaxInt64)
+
+// Limiter wraps an adaptive limiter with the x/time/rate method set.
+type Limiter struct {
+	l *adaptiveratelimit.Limiter
+}
+
+// NewLimiter returns a Limiter backed by l. The caller remains
+// responsible for recording outcomes on l and stopping it.
+func NewLimiter(l *adaptiveratelimit.Limiter) *Limiter {
+	return &Limiter{l: l}
+}
+
+// Allow reports whether an event may happen now.
+func (r *Limiter) Allow() bool {
+	return r.l.Allow()
+}
+
+// AllowN reports whether n events may happen now. The now argument is
+// ignored.
+func (r *Limiter) AllowN(_ time.Time, n int) bool {
+	return r.l.AllowN(n)
+}
+
+// Wait blocks until an event is allowed or ctx is done.
+func (r *Limiter) Wait(ctx context.Context) error {
+	return r.l.Wait(ctx)
+}
+
+// Reserve is shorthand for ReserveN(time.Now(), 1).
+func (r *Limiter) Reserve() *Reservation {
+	return r.ReserveN(time.Now(), 1)
+}
+
+// ReserveN returns a Reservation indicating how long the caller must wait
+// before n events may happen. The now argument is ignored.
+//
+// The reservation is made with Reserve on the underlying limiter: if the
+// events fit under the current limit and no one is waiting they are
+// admitted immediately and the delay is zero, and otherwise they are
+// queued behind other waiters and admitted when capacity frees up. The
+// reservation is not OK if it could never be admitted at the current
+// limit, if the wait queue is full, or once the limiter is stopped or
+// draining.
+func (r *Limiter) ReserveN(_ time.Time, n int) *Reservation {
+	res, err := r.l.Reserve(n)
+	if err != nil {
+		return &Reservation{}
+	}
+	return &Reservation{r: res}
+}
+
+// Reservation holds information about events that are permitted by a
+// Limiter to happen after a delay.
+type Reservation struct {
+	// r is the underlying reservation, or nil if it is not OK.
+	r *adaptiveratelimit.Reservation
+}
+
+// OK reports whether the limiter can provide the requested events. If OK
+// is false, Delay returns InfDuration and Cancel does nothing.
+func (r *Reservation) OK() bool {
+	return r.r != nil
+}
+
+// Delay is shorthand for DelayFrom(time.Now()).
+func (r *Reservation) Delay() time.Duration {
+	return r.DelayFrom(time.Now())
+}
+
+// DelayFrom returns the estimated duration the caller must wait before
+// acting on the reservation. Zero means act immediately: the events have
+// been admitted, and their capacity is taken. The now argument is
+// ignored; the delay is measured from the limiter's current time.
+func (r *Reservation) DelayFrom(_ time.Time) time.Duration {
+	if r.r == nil {
+		return InfDuration
+	}
+	return r.r.Delay()
+}
+
+// Cancel is shorthand for CancelAt(time.Now()).
+func (r *Reservation) Cancel() {
+	r.CancelAt(time.Now())
+}
+
+// CancelAt indicates that the reservation holder will not perform the
+// reserved action. As with x/time/rate, the reserved capacity is given
+// back where possible: a queued reservation is withdrawn, returning its
+// place to other callers, and capacity already admitted is refunded
+// unless the window it was admitted in has since reset. The now argument
+// is ignored.
+func (r *Reservation) CancelAt(_ time.Time) {
+	if r.r == nil {
+		return
+	}
+	r.r.Cancel()
+}
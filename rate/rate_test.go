@@ -0,0 +1,165 @@
+package rate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bhatpriyanka8/adaptiveratelimit"
+)
+
+const window = 100 * time.Millisecond
+
+var cfg = adaptiveratelimit.AdaptiveConfig{
+	TargetLatency: 200 * time.Millisecond,
+	MaxErrorRate:  0.05,
+	MinLimit:      1,
+	MaxLimit:      100,
+	Window:        window,
+}
+
+func newLimiter(t *testing.T, limit int) *Limiter {
+	t.Helper()
+
+	l := adaptiveratelimit.NewAdaptivePerSecond(limit, cfg)
+	t.Cleanup(l.Stop)
+	return NewLimiter(l)
+}
+
+func TestAllow(t *testing.T) {
+	r := newLimiter(t, 2)
+
+	if !r.Allow() || !r.Allow() {
+		t.Fatal("expected the first two events to be allowed")
+	}
+	if r.Allow() {
+		t.Fatal("expected the third event to be rejected")
+	}
+}
+
+func TestAllowN(t *testing.T) {
+	r := newLimiter(t, 3)
+	now := time.Now()
+
+	if !r.AllowN(now, 2) {
+		t.Fatal("expected AllowN(2) to be allowed")
+	}
+	if r.AllowN(now, 2) {
+		t.Fatal("expected AllowN(2) beyond the limit to be rejected")
+	}
+	if !r.AllowN(now, 1) {
+		t.Fatal("expected AllowN(1) to use the remaining capacity")
+	}
+}
+
+func TestReserveImmediate(t *testing.T) {
+	r := newLimiter(t, 1)
+
+	res := r.Reserve()
+	if !res.OK() {
+		t.Fatal("expected reservation to be OK")
+	}
+	if d := res.Delay(); d != 0 {
+		t.Fatalf("expected zero delay, got %v", d)
+	}
+	if r.Allow() {
+		t.Fatal("expected the reservation to consume capacity")
+	}
+}
+
+func TestReserveDelayed(t *testing.T) {
+	r := newLimiter(t, 1)
+	r.Allow()
+
+	res := r.Reserve()
+	if !res.OK() {
+		t.Fatal("expected reservation to be OK")
+	}
+	d := res.Delay()
+	if d <= 0 || d > window {
+		t.Fatalf("expected delay within (0, %v], got %v", window, d)
+	}
+
+	time.Sleep(d + window/2)
+
+	if r.Allow() {
+		t.Fatal("expected the reservation to hold the next window's capacity")
+	}
+}
+
+func TestReserveCancelReturnsCapacity(t *testing.T) {
+	r := newLimiter(t, 1)
+	r.Allow()
+
+	res := r.Reserve()
+	d := res.Delay()
+	res.Cancel()
+
+	time.Sleep(d + window/2)
+
+	if !r.Allow() {
+		t.Fatal("expected a cancelled reservation not to consume capacity")
+	}
+}
+
+func TestReserveNNotOK(t *testing.T) {
+	r := newLimiter(t, 2)
+
+	res := r.ReserveN(time.Now(), 5)
+	if res.OK() {
+		t.Fatal("expected reservation beyond the limit not to be OK")
+	}
+	if d := res.Delay(); d != InfDuration {
+		t.Fatalf("expected InfDuration, got %v", d)
+	}
+	res.Cancel()
+}
+
+func TestWait(t *testing.T) {
+	r := newLimiter(t, 1)
+	r.Allow()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*window)
+	defer cancel()
+
+	if err := r.Wait(ctx); err != nil {
+		t.Fatalf("expected Wait to succeed after the window resets, got %v", err)
+	}
+}
+
+func TestReserveCancelRefundsAdmittedCapacity(t *testing.T) {
+	r := newLimiter(t, 1)
+
+	res := r.Reserve()
+	if d := res.Delay(); d != 0 {
+		t.Fatalf("expected zero delay, got %v", d)
+	}
+	res.Cancel()
+
+	if !r.Allow() {
+		t.Fatal("expected cancelling an admitted reservation to refund its capacity")
+	}
+}
+
+func TestReserveNQueuesSeveralEvents(t *testing.T) {
+	r := newLimiter(t, 2)
+	r.Allow()
+
+	res := r.ReserveN(time.Now(), 2)
+	if !res.OK() {
+		t.Fatal("expected a reservation within the limit to be OK")
+	}
+	d := res.Delay()
+	if d <= 0 || d > window {
+		t.Fatalf("expected delay within (0, %v], got %v", window, d)
+	}
+
+	time.Sleep(d + window/2)
+
+	if d := res.Delay(); d != 0 {
+		t.Fatalf("expected the reservation to be admitted after its delay, got %v", d)
+	}
+	if r.Allow() {
+		t.Fatal("expected the admitted reservation to hold both units")
+	}
+}
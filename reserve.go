@@ -0,0 +1,273 @@
+package adaptiveratelimit
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrWaiterQueueFull is returned by Wait and Reserve when MaxWaiters
+// callers are already queued for capacity.
+var ErrWaiterQueueFull = errors.New("adaptiveratelimit: waiter queue full")
+
+// ErrExceedsLimit is returned by Reserve and WaitN when n is larger than
+// the limiter's current capacity, so the request could never be admitted
+// at the current limit.
+var ErrExceedsLimit = errors.New("adaptiveratelimit: request exceeds limit")
+
+// Reservation holds capacity reserved by Reserve. Act on it once Delay
+// reaches zero, or call Cancel to give it up.
+//
+// The limit may change between Reserve and use. Capacity that was
+// already admitted is kept even if the limit drops. A queued reservation
+// is admitted only once it fits under the limit in force at that time,
+// so its Delay is re-estimated on every call.
+type Reservation struct {
+	l *Limiter
+	n int
+
+	// w and elem are the queued waiter of a reservation that could not
+	// be admitted immediately; w is nil otherwise.
+	w    *waiter
+	elem *list.Element
+
+	// admitted is set for reservations admitted by Reserve itself, and
+	// window is the window they were admitted in.
+	admitted bool
+	window   time.Time
+
+	// turn and end are, in leaky bucket mode, when the reservation's
+	// turn to drain comes and the drainedAt it left behind.
+	turn time.Time
+	end  time.Time
+
+	// done is set once the reservation is cancelled or waited for. It
+	// is guarded by l.mu.
+	done bool
+}
+
+// Reserve reserves n units of capacity and returns a Reservation
+// reporting how long the caller must wait before using them, mirroring
+// golang.org/x/time/rate. It lets callers decide whether the wait is
+// acceptable before committing, and Cancel the reservation if it is not.
+//
+// If the units fit under the current limit and no one else is waiting,
+// they are admitted immediately and Delay is zero. Otherwise the
+// reservation is queued behind callers of Wait in FIFO order and admitted
+// when capacity frees up; in leaky bucket mode it takes the next turns
+// to drain instead. Reserve fails with ErrExceedsLimit if n exceeds the
+// current limit (or the bucket size in token and leaky bucket modes),
+// with ErrWaiterQueueFull if it would queue behind MaxWaiters others,
+// with ErrDraining after GracefulStop, with ErrStopped after Stop, and
+// in leaky bucket mode with ErrBucketFull or ErrBreakerOpen as Wait
+// does.
+//
+// While the limiter is disabled, or for n <= 0, Reserve returns a
+// reservation that holds no capacity and has no delay.
+func (l *Limiter) Reserve(n int) (*Reservation, error) {
+	switch {
+	case l.draining.Load():
+		return nil, ErrDraining
+	case l.stopped.Load():
+		return nil, ErrStopped
+	}
+	l.wake()
+	r := &Reservation{l: l, n: n}
+	if l.disabled.Load() || n <= 0 {
+		r.done = true
+		return r, nil
+	}
+	if l.mode == modeLeakyBucket {
+		return r, l.leakyReserve(r)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if n > l.capacity() {
+		return nil, ErrExceedsLimit
+	}
+
+	now := l.clock.Now()
+	if l.waiters.Len() == 0 && l.State() == BreakerClosed && l.admit(n, now) {
+		r.admitted = true
+		r.window = l.lastReset
+		return r, nil
+	}
+
+	if l.cfg.MaxWaiters > 0 && l.waiters.Len() >= l.cfg.MaxWaiters {
+		return nil, ErrWaiterQueueFull
+	}
+	r.w = &waiter{ready: make(chan struct{}), n: n, queued: now}
+	r.elem = l.waiters.PushBack(r.w)
+	l.peakWaiters = max(l.peakWaiters, l.waiters.Len())
+	return r, nil
+}
+
+// leakyReserve places r in the leaky bucket, taking the next n turns to
+// drain.
+func (l *Limiter) leakyReserve(r *Reservation) error {
+	if l.State() != BreakerClosed {
+		return ErrBreakerOpen
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if r.n > l.burst {
+		return ErrExceedsLimit
+	}
+	now := l.clock.Now()
+	interval := l.drainInterval()
+	if interval <= 0 || l.bucketDepth(now)+r.n > l.burst {
+		return ErrBucketFull
+	}
+
+	r.turn = later(l.drainedAt, now)
+	r.end = r.turn.Add(time.Duration(r.n) * interval)
+	l.drainedAt = r.end
+	return nil
+}
+
+// capacity returns the most units a single request can be admitted for.
+func (l *Limiter) capacity() int {
+	if l.mode == modeTokenBucket || l.mode == modeLeakyBucket {
+		return l.burst
+	}
+	return l.limit()
+}
+
+// Delay returns the estimated time until the reserved capacity can be
+// used. Zero means act now.
+//
+// For a queued reservation the estimate assumes the capacity is granted
+// at the next window reset (or, in token bucket mode, once n tokens have
+// refilled); it may be longer if other callers are ahead in the queue or
+// the limit is lowered, and in concurrency mode, which has no window, it
+// is zero until a slot is released.
+func (r *Reservation) Delay() time.Duration {
+	l := r.l
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	switch {
+	case r.done || r.admitted:
+		return 0
+	case r.w == nil:
+		return max(r.turn.Sub(now), 0)
+	}
+
+	select {
+	case <-r.w.ready:
+		return 0
+	default:
+	}
+	if l.mode == modeTokenBucket {
+		l.takeTokens(0, now)
+		limit := l.limit()
+		if limit <= 0 || l.tokens >= float64(r.n) {
+			return 0
+		}
+		return time.Duration((float64(r.n) - l.tokens) / float64(limit) * float64(l.cfg.window()))
+	}
+	return l.timeToReset(now)
+}
+
+// Cancel gives up the reservation, returning its capacity so it is not
+// counted against the limit. A queued reservation is withdrawn from the
+// queue. Capacity admitted in a fixed or sliding window that has since
+// reset is not refunded, since the reset already discarded it.
+//
+// Call Cancel only if the reserved action will not be performed. Only
+// the first call has any effect.
+func (r *Reservation) Cancel() {
+	l := r.l
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if r.done {
+		return
+	}
+	r.done = true
+
+	switch {
+	case l.mode == modeLeakyBucket:
+		// Hand the turns back only if no one queued behind them;
+		// otherwise their turns are already fixed.
+		if l.drainedAt.Equal(r.end) {
+			l.drainedAt = later(r.turn, l.clock.Now())
+		}
+	case r.admitted:
+		l.refundWindow(r.n, r.window)
+	default:
+		select {
+		case <-r.w.ready:
+			// Capacity was granted before the cancellation; hand
+			// it back so it is not lost.
+			l.refundWindow(r.n, r.w.window)
+		default:
+			l.waiters.Remove(r.elem)
+			// A large reservation at the head of the queue may
+			// have been holding back smaller ones behind it.
+			l.grantWaiters()
+		}
+	}
+}
+
+// refundWindow returns n units admitted in the window that started at
+// window, unless a fixed or sliding window has reset since.
+//
+// The caller must hold l.mu.
+func (l *Limiter) refundWindow(n int, window time.Time) {
+	if (l.mode == modeFixedWindow || l.mode == modeSlidingWindow) && !l.lastReset.Equal(window) {
+		return
+	}
+	l.refund(n)
+	l.grantWaiters()
+	l.notifyIdle()
+}
+
+// wait blocks until the reserved capacity can be used or ctx is done,
+// cancelling the reservation in the latter case.
+func (r *Reservation) wait(ctx context.Context) error {
+	l := r.l
+
+	var ready <-chan time.Time
+	var delay time.Duration
+	switch {
+	case r.done || r.admitted:
+		return nil
+	case r.w == nil:
+		l.mu.Lock()
+		delay = r.turn.Sub(l.clock.Now())
+		l.mu.Unlock()
+		if delay <= 0 {
+			return nil
+		}
+		timer := l.clock.NewTicker(delay)
+		defer timer.Stop()
+		ready = timer.C()
+	}
+
+	var err error
+	select {
+	case <-ready:
+		l.recordWait(delay)
+		return nil
+	case <-r.w.readyChan():
+		return nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-l.drainCh:
+		err = ErrDraining
+	case <-l.stopCh:
+		err = ErrStopped
+		if l.draining.Load() {
+			err = ErrDraining
+		}
+	}
+	r.Cancel()
+	return err
+}
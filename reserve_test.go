@@ -0,0 +1,151 @@
+package adaptiveratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// reserveCfg keeps the limit fixed, so tests can reason about window
+// capacity across resets.
+var reserveCfg = func() AdaptiveConfig {
+	c := cfg
+	c.MinSamples = 1000
+	return c
+}()
+
+func TestReserveAdmitsImmediately(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(5, reserveCfg, WithClock(clock))
+	defer limiter.Stop()
+
+	r, err := limiter.Reserve(3)
+	if err != nil {
+		t.Fatalf("expected Reserve to succeed, got %v", err)
+	}
+	if d := r.Delay(); d != 0 {
+		t.Fatalf("expected no delay with capacity available, got %v", d)
+	}
+	if got := limiter.Remaining(); got != 2 {
+		t.Fatalf("expected the reservation to consume capacity, got %d remaining", got)
+	}
+
+	r.Cancel()
+	r.Cancel()
+	if got := limiter.Remaining(); got != 5 {
+		t.Fatalf("expected Cancel to restore capacity exactly once, got %d remaining", got)
+	}
+}
+
+func TestReserveEstimatesDelayUntilReset(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(2, reserveCfg, WithClock(clock))
+	defer limiter.Stop()
+
+	limiter.AllowN(2)
+	clock.Advance(400 * time.Millisecond)
+
+	r, err := limiter.Reserve(2)
+	if err != nil {
+		t.Fatalf("expected Reserve to queue, got %v", err)
+	}
+	if d := r.Delay(); d != 600*time.Millisecond {
+		t.Fatalf("expected a delay until the window resets, got %v", d)
+	}
+
+	clock.Advance(600 * time.Millisecond)
+	if d := r.Delay(); d != 0 {
+		t.Fatalf("expected the reservation to be granted at the reset, got %v", d)
+	}
+	if limiter.Allow() {
+		t.Fatal("expected the granted reservation to hold the window's capacity")
+	}
+}
+
+func TestReserveCancelWithdrawsQueuedReservation(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(2, reserveCfg, WithClock(clock))
+	defer limiter.Stop()
+
+	limiter.AllowN(2)
+	r, err := limiter.Reserve(2)
+	if err != nil {
+		t.Fatalf("expected Reserve to queue, got %v", err)
+	}
+	r.Cancel()
+
+	clock.Advance(time.Second)
+	if got := limiter.Remaining(); got != 2 {
+		t.Fatalf("expected a cancelled reservation to leave the next window untouched, got %d remaining", got)
+	}
+}
+
+func TestReserveCancelAfterResetDoesNotRefund(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(2, reserveCfg, WithClock(clock))
+	defer limiter.Stop()
+
+	r, _ := limiter.Reserve(2)
+	clock.Advance(time.Second)
+	limiter.Allow()
+
+	r.Cancel()
+	if got := limiter.Remaining(); got != 1 {
+		t.Fatalf("expected no refund into a later window, got %d remaining", got)
+	}
+}
+
+func TestReserveRejectsOversizedRequest(t *testing.T) {
+	limiter := NewAdaptivePerSecond(2, cfg)
+	defer limiter.Stop()
+
+	if _, err := limiter.Reserve(3); !errors.Is(err, ErrExceedsLimit) {
+		t.Fatalf("expected ErrExceedsLimit, got %v", err)
+	}
+	if err := limiter.WaitN(context.Background(), 3); !errors.Is(err, ErrExceedsLimit) {
+		t.Fatalf("expected WaitN to fail with ErrExceedsLimit, got %v", err)
+	}
+}
+
+func TestReserveLeakyBucketTurns(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewAdaptiveLeakyBucket(10, 5, reserveCfg, WithClock(clock))
+	defer limiter.Stop()
+
+	first, _ := limiter.Reserve(2)
+	second, err := limiter.Reserve(1)
+	if err != nil {
+		t.Fatalf("expected Reserve to take a turn, got %v", err)
+	}
+	if d := first.Delay(); d != 0 {
+		t.Fatalf("expected the first reservation to drain now, got %v", d)
+	}
+	if d := second.Delay(); d != 200*time.Millisecond {
+		t.Fatalf("expected the second reservation to wait two drain intervals, got %v", d)
+	}
+
+	second.Cancel()
+	if got := limiter.QueueDepth(); got != 2 {
+		t.Fatalf("expected Cancel to hand the turn back, got depth %d", got)
+	}
+}
+
+func TestWaitNBlocksUntilGranted(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(3, reserveCfg, WithClock(clock))
+	defer limiter.Stop()
+
+	limiter.Allow()
+	done := make(chan error, 1)
+	go func() { done <- limiter.WaitN(context.Background(), 3) }()
+
+	time.Sleep(20 * time.Millisecond)
+	clock.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Fatalf("expected WaitN to succeed after the reset, got %v", err)
+	}
+	if limiter.Allow() {
+		t.Fatal("expected WaitN to consume the whole window")
+	}
+}
@@ -0,0 +1,95 @@
+package adaptiveratelimit
+
+import (
+	"math/rand/v2"
+	"sync/atomic"
+)
+
+// countShard is one slice of a sharded window count, padded so that
+// neighbouring shards do not share a cache line.
+type countShard struct {
+	n atomic.Int64
+	_ [56]byte
+}
+
+// shardedAdmit admits n requests against the sharded window count.
+//
+// Each shard holds an equal share of the current limit, so the shards
+// together never admit more than the limit. A request starts at a random
+// shard and moves on to the others only if that shard's share is used
+// up. A request for more than one share can be rejected even though the
+// window as a whole has room.
+func (l *Limiter) shardedAdmit(n int) bool {
+	limit := l.currentLimit.Load()
+	start := int(rand.Uint32() % uint32(len(l.shards)))
+	for i := range l.shards {
+		idx := (start + i) % len(l.shards)
+		quota := shardQuota(limit, idx, len(l.shards))
+		s := &l.shards[idx]
+		for {
+			count := s.n.Load()
+			if count+int64(n) > quota {
+				break
+			}
+			if s.n.CompareAndSwap(count, count+int64(n)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// shardQuota returns the share of limit held by shard i of shards,
+// spreading any remainder over the first shards.
+func shardQuota(limit int64, i, shards int) int64 {
+	quota := limit / int64(shards)
+	if int64(i) < limit%int64(shards) {
+		quota++
+	}
+	return quota
+}
+
+// windowCount returns the number of requests admitted in the current
+// window, summing the shards if the count is sharded.
+func (l *Limiter) windowCount() int64 {
+	if l.shards == nil {
+		return l.count.Load()
+	}
+
+	var total int64
+	for i := range l.shards {
+		total += l.shards[i].n.Load()
+	}
+	return total
+}
+
+// swapCount clears the window count and returns its previous value.
+func (l *Limiter) swapCount() int64 {
+	if l.shards == nil {
+		return l.count.Swap(0)
+	}
+
+	var total int64
+	for i := range l.shards {
+		total += l.shards[i].n.Swap(0)
+	}
+	return total
+}
+
+// refundShards returns n admitted requests to the shards, taking them
+// from whichever shards have counted them.
+func (l *Limiter) refundShards(n int64) {
+	for i := range l.shards {
+		s := &l.shards[i]
+		for n > 0 {
+			count := s.n.Load()
+			take := min(count, n)
+			if take == 0 {
+				break
+			}
+			if s.n.CompareAndSwap(count, count-take) {
+				n -= take
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package adaptiveratelimit
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestShardedCountNeverExceedsLimit(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(50, cfg, WithClock(clock), WithShardedCount(8))
+	defer limiter.Stop()
+
+	var (
+		wg      sync.WaitGroup
+		allowed atomic.Int64
+	)
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if limiter.Allow() {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := allowed.Load(); got != 50 {
+		t.Fatalf("expected exactly 50 admissions across shards, got %d", got)
+	}
+	if got := limiter.Snapshot().CountThisWindow; got != 50 {
+		t.Fatalf("expected the shards to sum to 50, got %d", got)
+	}
+}
+
+func TestShardedCountResetsWithWindow(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(5, cfg, WithClock(clock), WithShardedCount(3))
+	defer limiter.Stop()
+
+	for limiter.Allow() {
+	}
+	if got := limiter.Remaining(); got != 0 {
+		t.Fatalf("expected no capacity left, got %d", got)
+	}
+
+	limiter.Record(10*time.Millisecond, nil)
+	clock.Advance(time.Second)
+
+	if got, want := limiter.Remaining(), limiter.CurrentLimit(); got != want {
+		t.Fatalf("expected full capacity %d after the window reset, got %d", want, got)
+	}
+}
+
+func TestShardedCountIgnoredOutsideFixedWindow(t *testing.T) {
+	limiter := NewAdaptiveSlidingWindow(5, cfg, WithClock(newFakeClock()), WithShardedCount(4))
+	defer limiter.Stop()
+
+	if limiter.shards != nil {
+		t.Fatal("expected sliding window mode to keep a single counter")
+	}
+}
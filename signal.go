@@ -0,0 +1,45 @@
+package adaptiveratelimit
+
+import "maps"
+
+// ReasonSignal means the limit was lowered because a signal recorded with
+// RecordSignal exceeded its threshold in SignalThresholds.
+const ReasonSignal = "signal"
+
+// RecordSignal records the latest value of a named external health
+// signal, such as downstream queue depth or database connection
+// saturation, complementing the built-in latency and error signals.
+//
+// Each call replaces the signal's previous value. The control loop backs
+// off while any signal exceeds its threshold in SignalThresholds, and a
+// Decide policy can read every recorded signal from Stats.Signals.
+func (l *Limiter) RecordSignal(name string, value float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.signals == nil {
+		l.signals = make(map[string]float64)
+	}
+	l.signals[name] = value
+}
+
+// signalBreached reports whether any of values exceeds its threshold.
+func signalBreached(thresholds, values map[string]float64) bool {
+	for name, threshold := range thresholds {
+		if value, ok := values[name]; ok && value > threshold {
+			return true
+		}
+	}
+	return false
+}
+
+// signalValues returns a copy of the recorded signals, or nil if none
+// have been recorded.
+//
+// The caller must hold l.mu.
+func (l *Limiter) signalValues() map[string]float64 {
+	if len(l.signals) == 0 {
+		return nil
+	}
+	return maps.Clone(l.signals)
+}
@@ -0,0 +1,57 @@
+package adaptiveratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordSignalBreachForcesDecrease(t *testing.T) {
+	var reasons []string
+	c := cfg
+	c.SignalThresholds = map[string]float64{"queue_depth": 100}
+	c.OnLimitChange = func(_, _ int, reason string) { reasons = append(reasons, reason) }
+
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(10, c, WithClock(clock))
+	defer limiter.Stop()
+
+	limiter.Record(10*time.Millisecond, nil)
+	limiter.RecordSignal("queue_depth", 50)
+	clock.Advance(time.Second)
+	if got := limiter.CurrentLimit(); got != 11 {
+		t.Fatalf("expected a signal within its threshold to allow an increase, got %d", got)
+	}
+
+	limiter.Record(10*time.Millisecond, nil)
+	limiter.RecordSignal("queue_depth", 150)
+	clock.Advance(time.Second)
+	if got := limiter.CurrentLimit(); got >= 11 {
+		t.Fatalf("expected a breached signal to force a decrease despite healthy latency, got %d", got)
+	}
+	if last := reasons[len(reasons)-1]; last != ReasonSignal {
+		t.Fatalf("expected ReasonSignal, got %q", last)
+	}
+}
+
+func TestRecordSignalVisibleToDecide(t *testing.T) {
+	var seen float64
+	c := cfg
+	c.Decide = func(s Stats) Action {
+		seen = s.Signals["db_saturation"]
+		return Action{Decision: DecisionHold}
+	}
+
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(10, c, WithClock(clock))
+	defer limiter.Stop()
+
+	if limiter.Snapshot().Signals != nil {
+		t.Fatal("expected no signals before any are recorded")
+	}
+	limiter.RecordSignal("db_saturation", 0.9)
+	clock.Advance(time.Second)
+
+	if seen != 0.9 {
+		t.Fatalf("expected Decide to see the recorded signal, got %v", seen)
+	}
+}
@@ -0,0 +1,44 @@
+package adaptiveratelimit
+
+import "time"
+
+// NewAdaptiveSlidingWindow creates an adaptive limiter that uses a
+// sliding-window counter instead of a fixed window.
+//
+// The previous window's count is weighted by the fraction of it that
+// still overlaps the rolling window ending now, and a request is admitted
+// only if prevCount*overlap + count stays within the current limit. This
+// keeps the effective rate over any rolling window near the limit,
+// avoiding the double-rate burst a fixed window permits around its
+// reset. State is O(1): two counters and the window start.
+//
+// Invalid input is clamped as described for NewAdaptivePerSecond.
+func NewAdaptiveSlidingWindow(limit int, cfg AdaptiveConfig, opts ...Option) *Limiter {
+	cfg = cfg.sanitize(limit)
+
+	return newLimiter(cfg.clampLimit(limit), cfg, func(l *Limiter) {
+		l.mode = modeSlidingWindow
+	}, opts)
+}
+
+// slidingAdmit admits n units if the weighted rolling count allows it.
+//
+// The caller must hold l.mu.
+func (l *Limiter) slidingAdmit(n int, now time.Time) bool {
+	estimate := float64(l.prevCount)*l.slidingOverlap(now) + float64(l.count.Load())
+	if estimate+float64(n) > float64(l.limit()) {
+		return false
+	}
+
+	l.count.Add(int64(n))
+	return true
+}
+
+// slidingOverlap returns the fraction of the previous window that still
+// overlaps the rolling window ending at now.
+//
+// The caller must hold l.mu.
+func (l *Limiter) slidingOverlap(now time.Time) float64 {
+	overlap := 1 - float64(now.Sub(l.lastReset))/float64(l.cfg.window())
+	return min(max(overlap, 0), 1)
+}
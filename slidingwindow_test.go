@@ -0,0 +1,100 @@
+package adaptiveratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// waitForReset blocks until the limiter starts a new window.
+func waitForReset(t *testing.T, l *Limiter) {
+	t.Helper()
+
+	l.mu.Lock()
+	last := l.lastReset
+	l.mu.Unlock()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		l.mu.Lock()
+		reset := l.lastReset.After(last)
+		l.mu.Unlock()
+		if reset {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("timed out waiting for window reset")
+}
+
+func TestSlidingWindowAllowsUpToLimit(t *testing.T) {
+	limiter := NewAdaptiveSlidingWindow(3, cfg)
+	defer limiter.Stop()
+
+	for i := 0; i < 3; i++ {
+		if !limiter.Allow() {
+			t.Fatalf("expected request %d to be allowed", i+1)
+		}
+	}
+
+	if limiter.Allow() {
+		t.Fatal("expected request beyond limit to be rejected")
+	}
+}
+
+func TestSlidingWindowSmoothsBoundaryBurst(t *testing.T) {
+	const limit = 10
+
+	limiter := NewAdaptiveSlidingWindow(limit, cfg)
+	defer limiter.Stop()
+
+	// Let one window pass so the burst lands near the end of a window.
+	waitForReset(t, limiter)
+	time.Sleep(900 * time.Millisecond)
+
+	admitted := 0
+	for i := 0; i < 2*limit; i++ {
+		if limiter.Allow() {
+			admitted++
+		}
+	}
+
+	waitForReset(t, limiter)
+
+	for i := 0; i < 2*limit; i++ {
+		if limiter.Allow() {
+			admitted++
+		}
+	}
+
+	// A fixed window would admit 2*limit across the boundary.
+	if admitted > limit+2 {
+		t.Fatalf("expected roughly %d admissions across the boundary, got %d", limit, admitted)
+	}
+}
+
+func TestSlidingWindowResetsAfterClockJump(t *testing.T) {
+	c := cfg
+	c.MinSamples = 1000 // keep the limit fixed
+
+	clock := newFakeClock()
+	limiter := NewAdaptiveSlidingWindow(10, c, WithClock(clock))
+	defer limiter.Stop()
+
+	for limiter.Allow() {
+	}
+
+	// A pause spanning many windows delivers a single tick; the full
+	// window before it must not be carried into the new one.
+	clock.Jump(time.Hour)
+	if got := limiter.Snapshot().CountThisWindow; got != 0 {
+		t.Fatalf("expected the count to be reset after the jump, got %d", got)
+	}
+	for i := 0; i < 10; i++ {
+		if !limiter.Allow() {
+			t.Fatalf("expected request %d to be allowed after the jump", i+1)
+		}
+	}
+	if limiter.Allow() {
+		t.Fatal("expected the limit to apply to the new window")
+	}
+}
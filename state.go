@@ -0,0 +1,95 @@
+package adaptiveratelimit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ErrInvalidState is returned (wrapped) by RestoreState when the data is
+// not a state produced by a compatible MarshalState.
+var ErrInvalidState = errors.New("adaptiveratelimit: invalid state")
+
+// stateVersion identifies the format written by MarshalState.
+const stateVersion = 1
+
+// microsPerMilli scales the latency average between the microseconds it
+// is kept in and the milliseconds it is persisted in, which keeps the
+// format compatible with earlier versions.
+const microsPerMilli = float64(time.Millisecond / time.Microsecond)
+
+// persistedState is the JSON document written by MarshalState.
+type persistedState struct {
+	Version      int      `json:"version"`
+	CurrentLimit int      `json:"current_limit"`
+	LatencyEWMA  *float64 `json:"latency_ewma_ms,omitempty"`
+	ErrorEWMA    *float64 `json:"error_ewma,omitempty"`
+}
+
+// MarshalState encodes the learned state of the limiter, its current
+// limit and latency and error averages, so that a new process can resume
+// from it with RestoreState instead of re-learning a safe rate from the
+// initial limit.
+//
+// The encoding is JSON and versioned. Timestamps, window counts, latency
+// percentiles and lifetime counters are not included.
+func (l *Limiter) MarshalState() ([]byte, error) {
+	l.mu.Lock()
+	s := persistedState{
+		Version:      stateVersion,
+		CurrentLimit: l.limit(),
+		LatencyEWMA:  scaleState(l.latencyEWMA.state(), 1/microsPerMilli),
+		ErrorEWMA:    l.errorEWMA.state(),
+	}
+	l.mu.Unlock()
+
+	return json.Marshal(s)
+}
+
+// RestoreState seeds the limiter with state produced by MarshalState.
+//
+// The restored limit is clamped into the configured bounds. Timestamps are
+// not restored: the current window, cooldown and warmup are unaffected,
+// so a freshly constructed limiter starts a fresh window with the learned
+// limit. The OnLimitChange callback is not fired.
+//
+// RestoreState returns an error wrapping ErrInvalidState, leaving the
+// limiter unchanged, if data is malformed, has an unknown version or holds
+// out-of-range values.
+func (l *Limiter) RestoreState(data []byte) error {
+	var s persistedState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidState, err)
+	}
+
+	switch {
+	case s.Version != stateVersion:
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidState, s.Version)
+	case s.CurrentLimit < 0:
+		return fmt.Errorf("%w: negative limit %d", ErrInvalidState, s.CurrentLimit)
+	case s.LatencyEWMA != nil && !(*s.LatencyEWMA >= 0 && !math.IsInf(*s.LatencyEWMA, 0)):
+		return fmt.Errorf("%w: latency average %v out of range", ErrInvalidState, *s.LatencyEWMA)
+	case s.ErrorEWMA != nil && !(*s.ErrorEWMA >= 0 && *s.ErrorEWMA <= 1):
+		return fmt.Errorf("%w: error average %v out of range", ErrInvalidState, *s.ErrorEWMA)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.setLimit(l.cfg.clampLimit(s.CurrentLimit))
+	l.latencyEWMA.restore(scaleState(s.LatencyEWMA, microsPerMilli))
+	l.errorEWMA.restore(s.ErrorEWMA)
+	l.grantWaiters()
+	return nil
+}
+
+// scaleState returns v multiplied by factor, or nil if v is nil.
+func scaleState(v *float64, factor float64) *float64 {
+	if v == nil {
+		return nil
+	}
+	scaled := *v * factor
+	return &scaled
+}
@@ -0,0 +1,111 @@
+package adaptiveratelimit
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStateRoundTrip(t *testing.T) {
+	clock := newFakeClock()
+	src := NewAdaptivePerSecond(10, cfg, WithClock(clock))
+	defer src.Stop()
+
+	src.Record(500*time.Millisecond, errors.New("boom"))
+	clock.Advance(time.Second)
+
+	data, err := src.MarshalState()
+	if err != nil {
+		t.Fatalf("MarshalState: %v", err)
+	}
+
+	dst := NewAdaptivePerSecond(50, cfg, WithClock(newFakeClock()))
+	defer dst.Stop()
+
+	if err := dst.RestoreState(data); err != nil {
+		t.Fatalf("RestoreState: %v", err)
+	}
+
+	if got, want := dst.CurrentLimit(), src.CurrentLimit(); got != want {
+		t.Fatalf("expected limit %d, got %d", want, got)
+	}
+	if got, want := dst.AverageLatency(), src.AverageLatency(); got != want {
+		t.Fatalf("expected latency %v, got %v", want, got)
+	}
+	if got, want := dst.ErrorRate(), src.ErrorRate(); got != want {
+		t.Fatalf("expected error rate %v, got %v", want, got)
+	}
+
+	// The restored averages blend with new samples rather than being
+	// replaced by the first one.
+	dst.Record(0, nil)
+	if dst.AverageLatency() == 0 {
+		t.Fatal("expected the restored latency average to be initialized")
+	}
+}
+
+func TestStateRoundTripWithoutSamples(t *testing.T) {
+	src := NewAdaptivePerSecond(10, cfg, WithClock(newFakeClock()))
+	defer src.Stop()
+
+	data, err := src.MarshalState()
+	if err != nil {
+		t.Fatalf("MarshalState: %v", err)
+	}
+
+	dst := NewAdaptivePerSecond(20, cfg, WithClock(newFakeClock()))
+	defer dst.Stop()
+	dst.Record(300*time.Millisecond, nil)
+
+	if err := dst.RestoreState(data); err != nil {
+		t.Fatalf("RestoreState: %v", err)
+	}
+
+	dst.Record(100*time.Millisecond, nil)
+	if got := dst.AverageLatency(); got != 100*time.Millisecond {
+		t.Fatalf("expected the first sample after restore to set the average, got %v", got)
+	}
+}
+
+func TestRestoreStateClampsLimit(t *testing.T) {
+	limiter := NewAdaptivePerSecond(10, cfg, WithClock(newFakeClock()))
+	defer limiter.Stop()
+
+	if err := limiter.RestoreState([]byte(`{"version":1,"current_limit":5000}`)); err != nil {
+		t.Fatalf("RestoreState: %v", err)
+	}
+
+	if got := limiter.CurrentLimit(); got != cfg.MaxLimit {
+		t.Fatalf("expected limit clamped to %d, got %d", cfg.MaxLimit, got)
+	}
+}
+
+func TestRestoreStateRejectsInvalidData(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"garbage", "not json"},
+		{"empty", ""},
+		{"unknown version", `{"version":2,"current_limit":10}`},
+		{"missing version", `{"current_limit":10}`},
+		{"negative limit", `{"version":1,"current_limit":-1}`},
+		{"negative latency", `{"version":1,"current_limit":10,"latency_ewma_ms":-5}`},
+		{"error rate above one", `{"version":1,"current_limit":10,"error_ewma":1.5}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := NewAdaptivePerSecond(10, cfg, WithClock(newFakeClock()))
+			defer limiter.Stop()
+
+			if err := limiter.RestoreState([]byte(tt.data)); !errors.Is(err, ErrInvalidState) {
+				t.Fatalf("expected ErrInvalidState, got %v", err)
+			}
+
+			if got := limiter.CurrentLimit(); got != 10 {
+				t.Fatalf("expected limiter to be unchanged, got limit %d", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package adaptiveratelimit
+
+import "time"
+
+// Stats is a point-in-time view of a Limiter's state.
+//
+// All fields are read under a single lock acquisition, so they are
+// mutually consistent.
+type Stats struct {
+	// Name is the limiter's name; see WithName.
+	Name string
+
+	// CurrentLimit is the current allowed rate (or concurrency ceiling
+	// in concurrency mode).
+	CurrentLimit int
+
+	// Ceiling is the upper bound currently in force on the limit:
+	// AdaptiveConfig.MaxLimit, or higher once the ceiling ramp has raised
+	// it; see AdaptiveConfig.AbsoluteMaxLimit.
+	Ceiling int
+
+	// AverageLatency is the smoothed average request latency.
+	AverageLatency time.Duration
+
+	// LatencyPercentile is the estimated AdaptiveConfig.LatencyPercentile
+	// of request latency when UsePercentile is set, and zero otherwise.
+	LatencyPercentile time.Duration
+
+	// ErrorRate is the smoothed error rate, between 0.0 and 1.0.
+	ErrorRate float64
+
+	// HealthScore blends latency and the error rate into one number, the
+	// mean of latency over TargetLatency and ErrorRate over MaxErrorRate
+	// weighted by LatencyWeight and ErrorWeight: 0 is idle, and 1 means
+	// the signals are on target on average. StrategyWeighted adjusts the
+	// limit by it; with the other strategies it is informational.
+	HealthScore float64
+
+	// LatencySamples and ErrorSamples count the samples behind
+	// AverageLatency and ErrorRate since the limiter was created, reset
+	// or restored, so that readings backed by few samples can be
+	// discounted. LatencyInitialized and ErrorInitialized report whether
+	// each average holds a value at all; until then it reads as zero.
+	LatencySamples     uint64
+	ErrorSamples       uint64
+	LatencyInitialized bool
+	ErrorInitialized   bool
+
+	// CountThisWindow is the number of units admitted in the current
+	// window (the in-flight count in concurrency mode).
+	CountThisWindow int
+
+	// TimeToReset is the time until the current window resets; see
+	// Limiter.TimeToReset.
+	TimeToReset time.Duration
+
+	// Waiters is the number of callers of Wait, and reservations, queued
+	// for capacity. PeakWaiters is the most that have been queued at once
+	// since the limiter was created or reset.
+	Waiters     int
+	PeakWaiters int
+
+	// WaitLatency is the smoothed time queued callers spent waiting
+	// before being granted capacity; see Limiter.WaitLatency.
+	WaitLatency time.Duration
+
+	// AllowedTotal is the number of requests admitted by Allow or AllowN
+	// since the limiter was created.
+	AllowedTotal uint64
+
+	// RejectedTotal is the number of requests rejected by Allow or AllowN
+	// since the limiter was created.
+	RejectedTotal uint64
+
+	// ShadowRejected is the number of requests that would have been
+	// rejected but were admitted because of AdaptiveConfig.Shadow. They
+	// are counted in AllowedTotal, not RejectedTotal.
+	ShadowRejected uint64
+
+	// LastAdjustment is when the control loop last adjusted the limit.
+	// It is the zero time if no adjustment has happened yet.
+	LastAdjustment time.Time
+
+	// TimeSinceAdjustment is the time elapsed since LastAdjustment, or
+	// zero if no adjustment has happened yet.
+	TimeSinceAdjustment time.Duration
+
+	// Overridden reports whether an OverrideLimit is holding the limit,
+	// and OverrideUntil is when it expires. OverrideUntil is the zero
+	// time if no override is active.
+	Overridden    bool
+	OverrideUntil time.Time
+
+	// Signals holds the latest value of each signal recorded with
+	// RecordSignal, or is nil if none have been recorded. It is a copy.
+	Signals map[string]float64
+}
+
+// Snapshot returns a consistent view of the limiter's current state.
+//
+// Snapshot is intended for metrics collectors and debug handlers.
+func (l *Limiter) Snapshot() Stats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.snapshot()
+}
+
+// snapshot builds a Stats from the limiter's current state.
+//
+// The caller must hold l.mu.
+func (l *Limiter) snapshot() Stats {
+	s := Stats{
+		Name:           l.name,
+		CurrentLimit:   l.limit(),
+		Ceiling:        l.ceiling(),
+		AverageLatency: l.averageLatency(),
+		ErrorRate:      l.errorEWMA.Value(),
+
+		LatencySamples:     l.latencyEWMA.Count(),
+		ErrorSamples:       l.errorEWMA.Count(),
+		LatencyInitialized: l.latencyEWMA.Initialized(),
+		ErrorInitialized:   l.errorEWMA.Initialized(),
+
+		CountThisWindow: int(l.windowCount()),
+		Waiters:         l.waiters.Len(),
+		PeakWaiters:     l.peakWaiters,
+		WaitLatency:     fromMicros(l.waitEWMA.Value()),
+		AllowedTotal:    l.allowedTotal.Load(),
+		RejectedTotal:   l.rejectedTotal.Load(),
+		ShadowRejected:  l.shadowRejectedTotal.Load(),
+		LastAdjustment:  l.lastAdjustment,
+		Signals:         l.signalValues(),
+	}
+	latency := s.AverageLatency
+	if l.cfg.UsePercentile {
+		s.LatencyPercentile = l.latencyQuantile(l.cfg.latencyPercentile())
+		latency = s.LatencyPercentile
+	}
+	s.HealthScore = l.cfg.healthScore(latency, s.ErrorRate)
+	now := l.clock.Now()
+	s.TimeToReset = l.timeToReset(now)
+	if !l.lastAdjustment.IsZero() {
+		s.TimeSinceAdjustment = now.Sub(l.lastAdjustment)
+	}
+	if now.Before(l.overrideUntil) {
+		s.Overridden, s.OverrideUntil = true, l.overrideUntil
+	}
+	return s
+}
+
+// Allowed returns the number of requests admitted by Allow, AllowN or
+// AllowPriority since the limiter was created. It is monotonic and is not
+// reset with the window or by Reset.
+func (l *Limiter) Allowed() uint64 {
+	return l.allowedTotal.Load()
+}
+
+// Rejected returns the number of requests rejected by Allow, AllowN or
+// AllowPriority since the limiter was created. It is monotonic and is not
+// reset with the window or by Reset.
+func (l *Limiter) Rejected() uint64 {
+	return l.rejectedTotal.Load()
+}
@@ -0,0 +1,128 @@
+package adaptiveratelimit
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSnapshotReportsState(t *testing.T) {
+	limiter := NewAdaptivePerSecond(5, cfg)
+	defer limiter.Stop()
+
+	limiter.AllowN(3)
+	limiter.AllowN(3)
+	limiter.Record(100*time.Millisecond, nil)
+	limiter.Record(100*time.Millisecond, errors.New("err"))
+
+	s := limiter.Snapshot()
+
+	if s.CurrentLimit != 5 {
+		t.Fatalf("expected CurrentLimit 5, got %d", s.CurrentLimit)
+	}
+
+	if s.CountThisWindow != 3 {
+		t.Fatalf("expected CountThisWindow 3, got %d", s.CountThisWindow)
+	}
+
+	if s.AllowedTotal != 1 || s.RejectedTotal != 1 {
+		t.Fatalf("expected 1 allowed and 1 rejected, got %d and %d", s.AllowedTotal, s.RejectedTotal)
+	}
+
+	if s.AverageLatency < 95*time.Millisecond || s.AverageLatency > 105*time.Millisecond {
+		t.Fatalf("expected AverageLatency near 100ms, got %v", s.AverageLatency)
+	}
+
+	if s.ErrorRate <= 0 {
+		t.Fatal("expected non-zero error rate")
+	}
+
+	if !s.LastAdjustment.IsZero() || s.TimeSinceAdjustment != 0 {
+		t.Fatal("expected no adjustment before the first control tick")
+	}
+}
+
+func TestSnapshotTracksAdjustment(t *testing.T) {
+	limiter := NewAdaptivePerSecond(5, cfg)
+	defer limiter.Stop()
+
+	time.Sleep(1100 * time.Millisecond)
+
+	s := limiter.Snapshot()
+	if s.LastAdjustment.IsZero() {
+		t.Fatal("expected an adjustment after the first control tick")
+	}
+
+	if s.TimeSinceAdjustment <= 0 || s.TimeSinceAdjustment > time.Second {
+		t.Fatalf("expected TimeSinceAdjustment within the last second, got %v", s.TimeSinceAdjustment)
+	}
+}
+
+func TestSnapshotReportsTimeToReset(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(5, cfg, WithClock(clock))
+	defer limiter.Stop()
+
+	clock.Advance(300 * time.Millisecond)
+	if got := limiter.Snapshot().TimeToReset; got != 700*time.Millisecond {
+		t.Fatalf("expected 700ms until the window resets, got %v", got)
+	}
+
+	clock.Advance(700 * time.Millisecond)
+	s := limiter.Snapshot()
+	if s.TimeToReset != time.Second || s.TimeSinceAdjustment != 0 {
+		t.Fatalf("expected a fresh window at the adjustment, got %v to reset and %v since", s.TimeToReset, s.TimeSinceAdjustment)
+	}
+}
+
+func TestAllowedAndRejectedCountersAreMonotonic(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(2, cfg, WithClock(clock))
+	defer limiter.Stop()
+
+	for i := 0; i < 3; i++ {
+		limiter.Allow()
+	}
+
+	clock.Advance(time.Second)
+
+	for i := 0; i < 4; i++ {
+		limiter.Allow()
+	}
+
+	if got := limiter.Allowed(); got != 5 {
+		t.Fatalf("expected 5 allowed across windows, got %d", got)
+	}
+
+	if got := limiter.Rejected(); got != 2 {
+		t.Fatalf("expected 2 rejected across windows, got %d", got)
+	}
+
+	limiter.Reset()
+
+	if limiter.Allowed() != 5 || limiter.Rejected() != 2 {
+		t.Fatal("expected counters to survive Reset")
+	}
+}
+
+func TestSnapshotReportsSampleCounts(t *testing.T) {
+	limiter := NewAdaptivePerSecond(5, cfg)
+	defer limiter.Stop()
+
+	s := limiter.Snapshot()
+	if s.LatencyInitialized || s.ErrorInitialized || s.LatencySamples != 0 {
+		t.Fatalf("expected no samples before any are recorded, got %+v", s)
+	}
+
+	limiter.Record(10*time.Millisecond, nil)
+	limiter.Record(20*time.Millisecond, nil)
+	limiter.RecordResult(errors.New("failed"))
+
+	s = limiter.Snapshot()
+	if s.LatencySamples != 2 || s.ErrorSamples != 3 {
+		t.Fatalf("expected 2 latency and 3 error samples, got %d and %d", s.LatencySamples, s.ErrorSamples)
+	}
+	if !s.LatencyInitialized || !s.ErrorInitialized {
+		t.Fatalf("expected both averages to be initialized, got %+v", s)
+	}
+}
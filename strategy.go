@@ -0,0 +1,284 @@
+package adaptiveratelimit
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Strategy selects how the control loop changes the limit.
+type Strategy int
+
+const (
+	// StrategyLinear raises the limit by IncreaseStep when healthy and
+	// lowers it by DecreaseStep under stress. It is the default.
+	StrategyLinear Strategy = iota
+
+	// StrategyAIMD raises the limit additively by IncreaseStep when
+	// healthy and lowers it multiplicatively by DecreaseFactor under
+	// stress, as in TCP congestion control.
+	StrategyAIMD
+
+	// StrategyGradient sets the limit proportionally to the ratio of
+	// TargetLatency to observed latency, so it converges on the limit at
+	// which latency meets the target instead of sawtoothing around it.
+	// Each tick moves at most halfway toward the computed limit, and the
+	// ratio is bounded to [0.5, 2] to limit swings. Error rate breaches
+	// still back off by DecreaseStep.
+	StrategyGradient
+
+	// StrategyProportional scales IncreaseStep and DecreaseStep by how
+	// far the signals are from their thresholds, so a deep breach backs
+	// off harder than a marginal one and recovery is quicker the
+	// healthier the downstream is. A decrease is multiplied by
+	// 1 + the relative excess of latency over TargetLatency (or of the
+	// error rate over MaxErrorRate, whichever is larger), and an increase
+	// by 1 + the relative headroom below TargetLatency, so the configured
+	// steps act as minimums. The multiplier is capped at
+	// maxProportionalScale.
+	StrategyProportional
+
+	// StrategyWeighted blends latency and errors into a single health
+	// score, the weighted mean of latency over TargetLatency and error
+	// rate over MaxErrorRate, weighted by LatencyWeight and ErrorWeight,
+	// and adjusts the limit in proportion to it, instead of reacting to
+	// whichever threshold is crossed first. A score of 1 means the
+	// signals are on target on average: above 1 + HighWatermark the limit
+	// drops by DecreaseStep multiplied by the score, below
+	// 1 - LowWatermark it rises by IncreaseStep multiplied by 1 plus the
+	// headroom, and in between it holds. So a marginal error rate on a
+	// fast downstream no longer forces a backoff on its own, while a
+	// breach of both backs off harder than either. Multipliers are capped
+	// at maxProportionalScale.
+	StrategyWeighted
+)
+
+// String returns the lower-case name of the strategy.
+func (s Strategy) String() string {
+	switch s {
+	case StrategyLinear:
+		return "linear"
+	case StrategyAIMD:
+		return "aimd"
+	case StrategyGradient:
+		return "gradient"
+	case StrategyProportional:
+		return "proportional"
+	case StrategyWeighted:
+		return "weighted"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText encodes the strategy by name, or fails for an unknown
+// strategy.
+func (s Strategy) MarshalText() ([]byte, error) {
+	if !s.valid() {
+		return nil, fmt.Errorf("%w: unknown Strategy %d", ErrInvalidConfig, int(s))
+	}
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a strategy name written by MarshalText.
+func (s *Strategy) UnmarshalText(text []byte) error {
+	for v := StrategyLinear; v.valid(); v++ {
+		if string(text) == v.String() {
+			*s = v
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown strategy %q", text)
+}
+
+// valid reports whether s is a known strategy.
+func (s Strategy) valid() bool {
+	return s >= StrategyLinear && s <= StrategyWeighted
+}
+
+// maxProportionalScale caps the step multiplier of StrategyProportional
+// to limit overshoot.
+const maxProportionalScale = 10.0
+
+const (
+	// gradientSmoothing is the fraction of the distance to the computed
+	// gradient limit covered on each tick.
+	gradientSmoothing = 0.5
+
+	// minGradient and maxGradient bound the per-tick latency ratio.
+	minGradient = 0.5
+	maxGradient = 2.0
+
+	// gradientEpsilon guards against division by a zero latency.
+	gradientEpsilon = time.Microsecond
+)
+
+// defaultDecreaseFactor is used by StrategyAIMD when DecreaseFactor is
+// unset.
+const defaultDecreaseFactor = 0.5
+
+// decreaseFactor returns the multiplicative backoff factor, applying the
+// default when unset.
+func (c AdaptiveConfig) decreaseFactor() float64 {
+	if c.DecreaseFactor == 0 {
+		return defaultDecreaseFactor
+	}
+	return c.DecreaseFactor
+}
+
+// multiplicativeDecrease scales limit by factor, always lowering it by at
+// least one so integer rounding can never stall the backoff.
+func multiplicativeDecrease(limit int, factor float64) int {
+	next := int(float64(limit) * factor)
+	if next >= limit {
+		next = limit - 1
+	}
+	return next
+}
+
+// gradientLimit computes the smoothed next limit for the gradient
+// strategy. Whenever the raw target differs from limit by at least one,
+// the result moves by at least one so rounding cannot stall convergence.
+func gradientLimit(limit int, target, observed time.Duration) int {
+	gradient := float64(target) / float64(max(observed, gradientEpsilon))
+	gradient = min(max(gradient, minGradient), maxGradient)
+
+	raw := float64(limit) * gradient
+	next := int(math.Round(float64(limit) + gradientSmoothing*(raw-float64(limit))))
+
+	switch {
+	case raw <= float64(limit-1) && next >= limit:
+		next = limit - 1
+	case raw >= float64(limit+1) && next <= limit:
+		next = limit + 1
+	}
+	return next
+}
+
+// stepScale returns the factor by which IncreaseStep or DecreaseStep is
+// multiplied for the observed signals. It is 1 unless Strategy is
+// StrategyProportional.
+func (c AdaptiveConfig) stepScale(latency time.Duration, errorRate float64, decrease bool) float64 {
+	if c.Strategy != StrategyProportional || c.TargetLatency <= 0 {
+		return 1
+	}
+
+	target := float64(c.TargetLatency)
+	var distance float64
+	if decrease {
+		distance = max((float64(latency)-target)/target, 0)
+		if c.MaxErrorRate > 0 {
+			distance = max(distance, (errorRate-c.MaxErrorRate)/c.MaxErrorRate)
+		}
+	} else {
+		distance = max((target-float64(latency))/target, 0)
+	}
+	return min(1+distance, maxProportionalScale)
+}
+
+// scaleStep multiplies step by scale, rounding to the nearest whole step
+// but never below step.
+func scaleStep(step int, scale float64) int {
+	return max(int(math.Round(float64(step)*scale)), step)
+}
+
+// step computes the built-in adjustment of limit for the observed
+// signals: the next limit, the direction it moves in and the reason.
+// signal reports whether a signal exceeds its SignalThresholds entry.
+func (c AdaptiveConfig) step(limit int, latency time.Duration, errorRate float64, signal bool) (int, Decision, string) {
+	switch {
+	case !signal && c.Strategy == StrategyWeighted:
+		return c.weightedStep(limit, latency, errorRate)
+	case !signal && c.Strategy == StrategyGradient && errorRate <= c.MaxErrorRate:
+		next := gradientLimit(limit, c.TargetLatency, latency)
+		if next < limit {
+			return c.clampLimit(next), DecisionDecrease, ReasonHighLatency
+		}
+		return c.clampLimit(next), DecisionIncrease, ReasonHealthy
+	case latency > c.highLatency():
+		return c.decreased(limit, c.stepScale(latency, errorRate, true)), DecisionDecrease, ReasonHighLatency
+	case errorRate > c.MaxErrorRate:
+		return c.decreased(limit, c.stepScale(latency, errorRate, true)), DecisionDecrease, ReasonHighErrorRate
+	case signal:
+		return c.decreased(limit, 1), DecisionDecrease, ReasonSignal
+	case latency >= c.lowLatency():
+		return limit, DecisionHold, ReasonWithinBand
+	default:
+		return c.increased(limit, c.stepScale(latency, errorRate, false)), DecisionIncrease, ReasonHealthy
+	}
+}
+
+// increased returns limit raised by IncreaseStep multiplied by scale,
+// capped at MaxLimit.
+func (c AdaptiveConfig) increased(limit int, scale float64) int {
+	return min(limit+scaleStep(c.IncreaseStep, scale), c.MaxLimit)
+}
+
+// decreased returns limit lowered by DecreaseStep multiplied by scale, or
+// multiplicatively for StrategyAIMD, but never below the floor.
+func (c AdaptiveConfig) decreased(limit int, scale float64) int {
+	switch c.Strategy {
+	case StrategyAIMD:
+		limit = multiplicativeDecrease(limit, c.decreaseFactor())
+	default:
+		limit -= scaleStep(c.DecreaseStep, scale)
+	}
+	return max(limit, c.floor())
+}
+
+// weights returns LatencyWeight and ErrorWeight, weighting both signals
+// equally when neither is set.
+func (c AdaptiveConfig) weights() (latency, errors float64) {
+	if c.LatencyWeight == 0 && c.ErrorWeight == 0 {
+		return 1, 1
+	}
+	return c.LatencyWeight, c.ErrorWeight
+}
+
+// healthScores returns the weighted contributions of latency and errors
+// to the health score, which is their sum.
+func (c AdaptiveConfig) healthScores(latency time.Duration, errorRate float64) (fromLatency, fromErrors float64) {
+	lw, ew := c.weights()
+	total := lw + ew
+	return lw / total * ratio(float64(latency), float64(c.TargetLatency)),
+		ew / total * ratio(errorRate, c.MaxErrorRate)
+}
+
+// healthScore returns the health score of latency and errorRate; see
+// StrategyWeighted.
+func (c AdaptiveConfig) healthScore(latency time.Duration, errorRate float64) float64 {
+	fromLatency, fromErrors := c.healthScores(latency, errorRate)
+	return fromLatency + fromErrors
+}
+
+// ratio returns v relative to the threshold, or maxProportionalScale for
+// a positive v against a zero threshold.
+func ratio(v, threshold float64) float64 {
+	switch {
+	case threshold > 0:
+		return min(v/threshold, maxProportionalScale)
+	case v > 0:
+		return maxProportionalScale
+	default:
+		return 0
+	}
+}
+
+// weightedStep is step for StrategyWeighted. A decrease is attributed to
+// whichever signal contributes more to the score.
+func (c AdaptiveConfig) weightedStep(limit int, latency time.Duration, errorRate float64) (int, Decision, string) {
+	fromLatency, fromErrors := c.healthScores(latency, errorRate)
+	score := fromLatency + fromErrors
+	switch {
+	case score > 1+c.highWatermark():
+		reason := ReasonHighLatency
+		if fromErrors > fromLatency {
+			reason = ReasonHighErrorRate
+		}
+		return c.decreased(limit, min(score, maxProportionalScale)), DecisionDecrease, reason
+	case score >= 1-c.lowWatermark():
+		return limit, DecisionHold, ReasonWithinBand
+	default:
+		return c.increased(limit, 2-score), DecisionIncrease, ReasonHealthy
+	}
+}
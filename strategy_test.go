@@ -0,0 +1,222 @@
+package adaptiveratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMultiplicativeDecreaseAlwaysMakesProgress(t *testing.T) {
+	tests := []struct {
+		limit  int
+		factor float64
+		want   int
+	}{
+		{100, 0.5, 50},
+		{10, 0.9, 9},
+		{3, 0.9, 2},
+		{1, 0.99, 0},
+	}
+
+	for _, tt := range tests {
+		if got := multiplicativeDecrease(tt.limit, tt.factor); got != tt.want {
+			t.Fatalf("multiplicativeDecrease(%d, %v) = %d, want %d", tt.limit, tt.factor, got, tt.want)
+		}
+	}
+}
+
+func TestAIMDBacksOffFasterThanLinear(t *testing.T) {
+	run := func(c AdaptiveConfig) int {
+		clock := newFakeClock()
+		limiter := NewAdaptivePerSecond(100, c, WithClock(clock))
+		defer limiter.Stop()
+
+		for i := 0; i < 20; i++ {
+			limiter.Record(500*time.Millisecond, nil)
+		}
+
+		clock.Advance(3 * time.Second)
+		return limiter.CurrentLimit()
+	}
+
+	linear := run(cfg)
+
+	aimd := cfg
+	aimd.Strategy = StrategyAIMD
+	aimd.DecreaseFactor = 0.5
+	multiplicative := run(aimd)
+
+	if linear != 94 {
+		t.Fatalf("expected linear backoff to reach 94 after 3 ticks, got %d", linear)
+	}
+
+	if multiplicative != 12 {
+		t.Fatalf("expected AIMD backoff to reach 12 after 3 ticks, got %d", multiplicative)
+	}
+}
+
+func TestAIMDRespectsMinLimit(t *testing.T) {
+	c := cfg
+	c.Strategy = StrategyAIMD
+	c.MinLimit = 5
+
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(8, c, WithClock(clock))
+	defer limiter.Stop()
+
+	limiter.Record(time.Second, nil)
+	clock.Advance(5 * time.Second)
+
+	if got := limiter.CurrentLimit(); got != 5 {
+		t.Fatalf("expected AIMD backoff clamped to MinLimit 5, got %d", got)
+	}
+}
+
+func TestGradientConvergesToStableLimit(t *testing.T) {
+	c := cfg
+	c.Strategy = StrategyGradient
+	c.TargetLatency = 100 * time.Millisecond
+
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(10, c, WithClock(clock))
+	defer limiter.Stop()
+
+	// Simulate a backend whose latency grows with load: 2ms per unit of
+	// limit, so the target is met at a limit of 50.
+	var history []int
+	for tick := 0; tick < 30; tick++ {
+		latency := time.Duration(limiter.CurrentLimit()) * 2 * time.Millisecond
+		for i := 0; i < 20; i++ {
+			limiter.Record(latency, nil)
+		}
+		clock.Advance(time.Second)
+		history = append(history, limiter.CurrentLimit())
+	}
+
+	tail := history[len(history)-10:]
+	for _, limit := range tail {
+		if limit < 48 || limit > 52 {
+			t.Fatalf("expected limit to settle near 50, got history %v", history)
+		}
+	}
+
+	lo, hi := tail[0], tail[0]
+	for _, limit := range tail {
+		lo, hi = min(lo, limit), max(hi, limit)
+	}
+	if hi-lo > 1 {
+		t.Fatalf("expected a stable limit rather than a sawtooth, got %v", tail)
+	}
+}
+
+func TestGradientLimitMovesTowardTarget(t *testing.T) {
+	if got := gradientLimit(100, 100*time.Millisecond, 200*time.Millisecond); got != 75 {
+		t.Fatalf("expected halfway move from 100 toward 50, got %d", got)
+	}
+
+	if got := gradientLimit(100, 100*time.Millisecond, 100*time.Millisecond); got != 100 {
+		t.Fatalf("expected no change when latency meets target, got %d", got)
+	}
+
+	if got := gradientLimit(10, 100*time.Millisecond, 0); got != 15 {
+		t.Fatalf("expected bounded increase with zero latency, got %d", got)
+	}
+}
+
+func TestProportionalLargeBreachDecreasesMore(t *testing.T) {
+	c := cfg
+	c.Strategy = StrategyProportional
+
+	step := func(latency time.Duration) int {
+		clock := newFakeClock()
+		limiter := NewAdaptivePerSecond(100, c, WithClock(clock))
+		defer limiter.Stop()
+
+		limiter.Record(latency, nil)
+		clock.Advance(time.Second)
+		return 100 - limiter.CurrentLimit()
+	}
+
+	marginal := step(220 * time.Millisecond)
+	large := step(1000 * time.Millisecond)
+
+	if marginal != 2 {
+		t.Fatalf("expected a marginal breach to keep DecreaseStep 2, got %d", marginal)
+	}
+	if large != 10 {
+		t.Fatalf("expected a 5x latency breach to decrease by 10, got %d", large)
+	}
+}
+
+func TestProportionalStepScale(t *testing.T) {
+	c := cfg
+	c.Strategy = StrategyProportional
+
+	tests := []struct {
+		name      string
+		latency   time.Duration
+		errorRate float64
+		decrease  bool
+		want      float64
+	}{
+		{"at target", 200 * time.Millisecond, 0, true, 1},
+		{"double target", 400 * time.Millisecond, 0, true, 2},
+		{"error rate breach", 0, 0.2, true, 4},
+		{"capped", 10 * time.Second, 0, true, maxProportionalScale},
+		{"idle increase", 0, 0, false, 2},
+		{"healthy increase", 100 * time.Millisecond, 0, false, 1.5},
+	}
+
+	for _, tt := range tests {
+		if got := c.stepScale(tt.latency, tt.errorRate, tt.decrease); got != tt.want {
+			t.Errorf("%s: expected scale %v, got %v", tt.name, tt.want, got)
+		}
+	}
+
+	if got := cfg.stepScale(10*time.Second, 1, true); got != 1 {
+		t.Errorf("expected linear strategy not to scale steps, got %v", got)
+	}
+}
+
+func TestWeightedDecisionsFollowDominantSignal(t *testing.T) {
+	latencyHeavy := cfg
+	latencyHeavy.Strategy = StrategyWeighted
+	latencyHeavy.LatencyWeight, latencyHeavy.ErrorWeight = 3, 1
+	errorHeavy := latencyHeavy
+	errorHeavy.LatencyWeight, errorHeavy.ErrorWeight = 1, 3
+
+	tests := []struct {
+		name      string
+		c         AdaptiveConfig
+		latency   time.Duration
+		errorRate float64
+		want      int
+		reason    string
+	}{
+		{"slow, latency dominates", latencyHeavy, 300 * time.Millisecond, 0.01, 8, ReasonHighLatency},
+		{"slow, errors dominate", errorHeavy, 300 * time.Millisecond, 0.01, 11, ReasonHealthy},
+		{"failing, latency dominates", latencyHeavy, 100 * time.Millisecond, 0.1, 11, ReasonHealthy},
+		{"failing, errors dominate", errorHeavy, 100 * time.Millisecond, 0.1, 7, ReasonHighErrorRate},
+		{"on target", latencyHeavy, 200 * time.Millisecond, 0.05, 10, ReasonWithinBand},
+	}
+
+	for _, tt := range tests {
+		got, _, reason := tt.c.step(10, tt.latency, tt.errorRate, false)
+		if got != tt.want || reason != tt.reason {
+			t.Errorf("%s: expected %d (%s), got %d (%s)", tt.name, tt.want, tt.reason, got, reason)
+		}
+	}
+}
+
+func TestStatsReportsHealthScore(t *testing.T) {
+	c := cfg
+	c.LatencyWeight, c.ErrorWeight = 3, 1
+
+	limiter := NewAdaptivePerSecond(10, c, WithClock(newFakeClock()))
+	defer limiter.Stop()
+
+	limiter.Record(300*time.Millisecond, nil)
+	// (3 * 300ms/200ms + 1 * 0/0.05) / 4
+	if got := limiter.Snapshot().HealthScore; got != 1.125 {
+		t.Fatalf("expected a health score of 1.125, got %v", got)
+	}
+}
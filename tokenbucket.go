@@ -0,0 +1,50 @@
+package adaptiveratelimit
+
+import "time"
+
+// NewAdaptiveTokenBucket creates an adaptive limiter that admits requests
+// from a token bucket instead of a fixed window.
+//
+// Tokens refill continuously at rate tokens per second (or per
+// cfg.Window, if set), up to burst tokens, and each admitted request
+// consumes one token. Unlike the fixed window, this never admits more
+// than burst requests back-to-back, even across a second boundary.
+//
+// The adaptive control loop adjusts the refill rate between MinLimit and
+// MaxLimit; burst stays fixed. In this mode CurrentLimit reports the
+// current refill rate in tokens per window.
+//
+// Invalid input is clamped as described for NewAdaptivePerSecond, and a
+// burst below one is raised to one.
+func NewAdaptiveTokenBucket(rate int, burst int, cfg AdaptiveConfig, opts ...Option) *Limiter {
+	cfg = cfg.sanitize(rate)
+	burst = max(burst, 1)
+
+	return newLimiter(cfg.clampLimit(rate), cfg, func(l *Limiter) {
+		l.mode = modeTokenBucket
+		l.burst = burst
+		l.tokens = float64(burst)
+		l.lastRefill = l.lastReset
+	}, opts)
+}
+
+// takeTokens refills the bucket for the time elapsed since the last
+// refill and then consumes n tokens if available.
+//
+// The caller must hold l.mu.
+func (l *Limiter) takeTokens(n int, now time.Time) bool {
+	if elapsed := now.Sub(l.lastRefill); elapsed > 0 {
+		l.tokens += float64(elapsed) / float64(l.cfg.window()) * float64(l.limit())
+		if l.tokens > float64(l.burst) {
+			l.tokens = float64(l.burst)
+		}
+		l.lastRefill = now
+	}
+
+	if l.tokens < float64(n) {
+		return false
+	}
+
+	l.tokens -= float64(n)
+	return true
+}
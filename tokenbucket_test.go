@@ -0,0 +1,60 @@
+package adaptiveratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBucketAllowsUpToBurst(t *testing.T) {
+	limiter := NewAdaptiveTokenBucket(10, 3, cfg)
+	defer limiter.Stop()
+
+	for i := 0; i < 3; i++ {
+		if !limiter.Allow() {
+			t.Fatalf("expected request %d within burst to be allowed", i+1)
+		}
+	}
+
+	if limiter.Allow() {
+		t.Fatal("expected request beyond burst to be rejected")
+	}
+}
+
+func TestTokenBucketRefillsContinuously(t *testing.T) {
+	limiter := NewAdaptiveTokenBucket(20, 1, cfg)
+	defer limiter.Stop()
+
+	if !limiter.Allow() {
+		t.Fatal("expected first request to be allowed")
+	}
+
+	if limiter.Allow() {
+		t.Fatal("expected bucket to be empty")
+	}
+
+	// at 20 tokens/sec one token refills every 50ms
+	time.Sleep(80 * time.Millisecond)
+
+	if !limiter.Allow() {
+		t.Fatal("expected a token to refill well before the next second")
+	}
+}
+
+func TestTokenBucketCurrentLimitIsRefillRate(t *testing.T) {
+	limiter := NewAdaptiveTokenBucket(10, 5, cfg)
+	defer limiter.Stop()
+
+	if got := limiter.CurrentLimit(); got != 10 {
+		t.Fatalf("expected CurrentLimit to report refill rate 10, got %d", got)
+	}
+
+	for i := 0; i < 20; i++ {
+		limiter.Record(500*time.Millisecond, nil)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+
+	if limiter.CurrentLimit() >= 10 {
+		t.Fatal("expected refill rate to decrease due to high latency")
+	}
+}
@@ -0,0 +1,104 @@
+package adaptiveratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// waiter is a goroutine parked in Wait, or a queued Reservation, until n
+// units of capacity are granted to it.
+type waiter struct {
+	ready chan struct{}
+	n     int
+
+	// queued is when the waiter joined the queue.
+	queued time.Time
+
+	// window is the window the capacity was granted in. It is set
+	// before ready is closed.
+	window time.Time
+}
+
+// readyChan returns the channel closed when w is granted, or nil if w is
+// nil.
+func (w *waiter) readyChan() <-chan struct{} {
+	if w == nil {
+		return nil
+	}
+	return w.ready
+}
+
+// Wait blocks until a request is allowed under the current rate limit
+// or until ctx is done.
+//
+// If capacity is immediately available and no other callers are
+// waiting, Wait returns nil without blocking. Otherwise the caller is
+// queued and woken when a window reset (or a limit increase) frees
+// capacity. Waiters are admitted in FIFO order.
+//
+// If MaxWaiters callers are already queued, Wait fails immediately with
+// ErrWaiterQueueFull instead of queueing. If ctx is cancelled or its
+// deadline expires before capacity is granted, Wait returns ctx.Err()
+// and does not consume any capacity.
+// After GracefulStop it returns ErrDraining in the same way, and after
+// Stop ErrStopped.
+//
+// Leaky bucket limiters instead queue the caller in the bucket; see
+// NewAdaptiveLeakyBucket.
+func (l *Limiter) Wait(ctx context.Context) error {
+	return l.WaitN(ctx, 1)
+}
+
+// WaitN is like Wait but blocks until n units of capacity are granted at
+// once. It fails immediately with ErrExceedsLimit if n exceeds the current
+// limit; see Reserve.
+func (l *Limiter) WaitN(ctx context.Context, n int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r, err := l.Reserve(n)
+	if err != nil {
+		return err
+	}
+	return r.wait(ctx)
+}
+
+// grantWaiters admits queued waiters in FIFO order while capacity
+// remains under the current limit.
+//
+// The caller must hold l.mu.
+func (l *Limiter) grantWaiters() {
+	if l.State() != BreakerClosed {
+		return
+	}
+
+	now := l.clock.Now()
+	for l.waiters.Len() > 0 {
+		elem := l.waiters.Front()
+		w := elem.Value.(*waiter)
+		if !l.admit(w.n, now) {
+			return
+		}
+		l.waiters.Remove(elem)
+		l.recordWait(now.Sub(w.queued))
+		w.window = l.lastReset
+		close(w.ready)
+	}
+}
+
+// recordWait feeds the time a queued caller spent waiting for capacity
+// into the wait latency average.
+func (l *Limiter) recordWait(d time.Duration) {
+	l.waitEWMA.Update(micros(d))
+}
+
+// WaitLatency returns the smoothed time that callers of Wait, and queued
+// reservations, spent waiting before being granted capacity. Callers
+// admitted without queueing, and those that gave up, are not counted, so
+// it measures the delay the limiter itself adds to requests it admits
+// late rather than rejects. It is zero until a queued caller has been
+// granted.
+func (l *Limiter) WaitLatency() time.Duration {
+	return fromMicros(l.waitEWMA.Value())
+}
@@ -0,0 +1,188 @@
+package adaptiveratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWaitReturnsImmediatelyWhenCapacityAvailable(t *testing.T) {
+	limiter := NewAdaptivePerSecond(1, cfg)
+	defer limiter.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if err := limiter.Wait(ctx); err != nil {
+		t.Fatalf("expected Wait to succeed, got %v", err)
+	}
+
+	if limiter.Allow() {
+		t.Fatal("expected Wait to consume capacity")
+	}
+}
+
+func TestWaitBlocksUntilReset(t *testing.T) {
+	limiter := NewAdaptivePerSecond(1, cfg)
+	defer limiter.Stop()
+
+	if !limiter.Allow() {
+		t.Fatal("expected first request to be allowed")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	if err := limiter.Wait(ctx); err != nil {
+		t.Fatalf("expected Wait to succeed after reset, got %v", err)
+	}
+
+	if time.Since(start) < 10*time.Millisecond {
+		t.Fatal("expected Wait to block until the window reset")
+	}
+}
+
+func TestWaitReturnsContextError(t *testing.T) {
+	limiter := NewAdaptivePerSecond(1, cfg)
+	defer limiter.Stop()
+
+	limiter.Allow()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := limiter.Wait(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+
+	limiter.mu.Lock()
+	waiting := limiter.waiters.Len()
+	limiter.mu.Unlock()
+
+	if waiting != 0 {
+		t.Fatalf("expected cancelled waiter to be removed, got %d queued", waiting)
+	}
+}
+
+func TestWaitAdmitsWaitersInOrder(t *testing.T) {
+	// Hold the limit at one so each reset admits exactly one waiter.
+	c := cfg
+	c.IncreaseStep = 0
+
+	limiter := NewAdaptivePerSecond(1, c)
+	defer limiter.Stop()
+
+	limiter.Allow()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	order := make(chan int, 2)
+	for i := 0; i < 2; i++ {
+		i := i
+		go func() {
+			if err := limiter.Wait(ctx); err == nil {
+				order <- i
+			}
+		}()
+		// give each waiter time to park before queueing the next one
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if first := <-order; first != 0 {
+		t.Fatalf("expected first waiter to be admitted first, got %d", first)
+	}
+	if second := <-order; second != 1 {
+		t.Fatalf("expected second waiter to be admitted second, got %d", second)
+	}
+}
+
+func TestWaitRejectsBeyondMaxWaiters(t *testing.T) {
+	const maxWaiters = 3
+	c := cfg
+	c.MaxWaiters = maxWaiters
+
+	limiter := NewAdaptivePerSecond(1, c, WithClock(newFakeClock()))
+	defer limiter.Stop()
+	limiter.Allow()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errs := make(chan error, maxWaiters)
+	for i := 0; i < maxWaiters; i++ {
+		go func() { errs <- limiter.Wait(ctx) }()
+	}
+	deadline := time.Now().Add(time.Second)
+	for limiter.Snapshot().Waiters < maxWaiters {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d parked waiters, got %d", maxWaiters, limiter.Snapshot().Waiters)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := limiter.Wait(ctx); !errors.Is(err, ErrWaiterQueueFull) {
+		t.Fatalf("expected waiter %d to get ErrWaiterQueueFull, got %v", maxWaiters+1, err)
+	}
+
+	cancel()
+	for i := 0; i < maxWaiters; i++ {
+		if err := <-errs; !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected queued waiters to be cancelled, got %v", err)
+		}
+	}
+	if got := limiter.Snapshot().Waiters; got != 0 {
+		t.Fatalf("expected the queue to drain, got %d waiters", got)
+	}
+}
+
+func TestWaitReportsQueueingMetrics(t *testing.T) {
+	const parked = 3
+	c := cfg
+	c.MinSamples = 1000 // keep the limit fixed
+
+	clock := newFakeClock()
+	limiter := NewAdaptivePerSecond(parked, c, WithClock(clock))
+	defer limiter.Stop()
+	for limiter.Allow() {
+	}
+
+	if got := limiter.WaitLatency(); got != 0 {
+		t.Fatalf("expected no wait latency before anyone queued, got %v", got)
+	}
+
+	errs := make(chan error, parked)
+	for i := 0; i < parked; i++ {
+		go func() { errs <- limiter.Wait(context.Background()) }()
+	}
+	deadline := time.Now().Add(time.Second)
+	for limiter.Snapshot().Waiters < parked {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d parked waiters, got %d", parked, limiter.Snapshot().Waiters)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	clock.Advance(time.Second)
+	for i := 0; i < parked; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("expected queued waiters to be granted, got %v", err)
+		}
+	}
+
+	stats := limiter.Snapshot()
+	if stats.Waiters != 0 || stats.PeakWaiters != parked {
+		t.Fatalf("expected an empty queue that peaked at %d, got %d peaking at %d", parked, stats.Waiters, stats.PeakWaiters)
+	}
+	if got := limiter.WaitLatency(); got != time.Second || stats.WaitLatency != got {
+		t.Fatalf("expected waiters to have queued for the rest of the window, got %v (stats %v)", got, stats.WaitLatency)
+	}
+
+	limiter.Reset()
+	if stats := limiter.Snapshot(); stats.PeakWaiters != 0 || stats.WaitLatency != 0 {
+		t.Fatalf("expected Reset to clear the queue metrics, got %+v", stats)
+	}
+}